@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// EnqueueAndWait submits a job for payload due now and blocks until it is completed, failed or expired,
+// returning the job in its final state, or ctx's error if ctx is done first. The job must be
+// processed by this scheduler instance: jobs handled by another instance sharing the store, or
+// whose result couldn't be saved, are never reported and the call waits until ctx is done.
+func (s *Scheduler[T]) EnqueueAndWait(ctx context.Context, payload T, opts ...JobOption[T]) (*Job[T], error) {
+	job := s.NewJob(time.Now(), payload, opts...)
+
+	// Register before submitting so a quick result can't be missed
+	result := make(chan *Job[T], 1)
+	s.waitersMu.Lock()
+	s.waiters[job.Id] = result
+	s.waitersMu.Unlock()
+
+	defer func() {
+		s.waitersMu.Lock()
+		delete(s.waiters, job.Id)
+		s.waitersMu.Unlock()
+	}()
+
+	if err := s.SubmitJobContext(ctx, job); err != nil {
+		return nil, err
+	}
+
+	select {
+	case final := <-result:
+		return final, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// notifyWaiter hands a job that reached a final status to the EnqueueAndWait call waiting for it
+func (s *Scheduler[T]) notifyWaiter(job *Job[T]) {
+	if job.Status != "completed" && job.Status != "failed" && job.Status != "expired" {
+		return
+	}
+
+	s.waitersMu.Lock()
+	defer s.waitersMu.Unlock()
+
+	if result, ok := s.waiters[job.Id]; ok {
+		final := *job
+		result <- &final
+		delete(s.waiters, job.Id)
+	}
+}
@@ -0,0 +1,64 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestEnqueueAndWait(t *testing.T) {
+	tests := []struct {
+		name       string
+		handlerErr error
+		run        bool // Whether the scheduler runs, nothing processes the job otherwise
+		wantStatus string
+		wantErr    error
+	}{
+		{name: "completed", run: true, wantStatus: "completed"},
+		{name: "failed", run: true, handlerErr: errors.New("boom"), wantStatus: "failed"},
+		{name: "context done first", wantErr: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runCtx, stop := context.WithCancel(context.Background())
+			defer stop()
+
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				if job.Payload != "request" {
+					t.Errorf("handler got payload %q, want %q", job.Payload, "request")
+				}
+				return tt.handlerErr
+			}
+			store := storage.NewMemoryStore[string]()
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger())
+			if tt.run {
+				s.Run(runCtx)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			job, err := s.EnqueueAndWait(ctx, "request")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EnqueueAndWait() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if store.Len() != 1 {
+					t.Fatalf("store holds %d jobs, want the submitted job", store.Len())
+				}
+				return
+			}
+
+			if job.Status != tt.wantStatus || job.Payload != "request" {
+				t.Fatalf("EnqueueAndWait() = %s job with payload %q, want %s", job.Status, job.Payload, tt.wantStatus)
+			}
+			if stored := store.GetJobs()[job.Id]; stored.Status != tt.wantStatus {
+				t.Fatalf("stored job status = %q, want %q", stored.Status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// BatchJobHandler processes several jobs in a single call
+// The returned slice must have one entry per job, a non-nil entry marks that job failed
+type BatchJobHandler[T any] func(ctx context.Context, jobs []Job[T]) []error
+
+// batchWorker processes batches of jobs until the channel is closed, returning the errors of saving
+// results that failed once shutdown began
+func (s *Scheduler[T]) batchWorker(ctx context.Context, workerId int, jobs chan *Job[T]) error {
+	if !s.startWorker(ctx, workerId) {
+		return nil
+	}
+	defer s.stopWorker(workerId)
+
+	var errs []error
+	for job := range jobs {
+		batch := []*Job[T]{job}
+		closed := false
+
+		// Wait up to batchMaxWait for the batch to fill
+		timer := time.NewTimer(s.batchMaxWait)
+	collect:
+		for len(batch) < s.batchSize {
+			select {
+			case next, ok := <-jobs:
+				if !ok {
+					closed = true
+					break collect
+				}
+				batch = append(batch, next)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		if err := s.processBatch(ctx, workerId, batch); err != nil && ctx.Err() != nil {
+			errs = append(errs, err)
+		}
+
+		if closed {
+			break
+		}
+	}
+
+	s.log.Debug("worker finished", "worker-id", workerId)
+	return errors.Join(errs...)
+}
+
+// processBatch runs the batch handler and records each job's result, returning the errors of saving them
+func (s *Scheduler[T]) processBatch(ctx context.Context, workerId int, batch []*Job[T]) error {
+	startTime := time.Now()
+	s.log.Debug("processing job batch", "batch-size", len(batch), "worker-id", workerId)
+
+	// Pass jobs by value to prevent modifications, jobs whose payload can't be decrypted fail
+	// without reaching the handler
+	var saveErrs []error
+	opened := batch[:0]
+	values := make([]Job[T], 0, len(batch))
+	for _, job := range batch {
+		value, err := s.openPayload(ctx, job)
+		if err != nil {
+			saveErrs = append(saveErrs, s.finishJob(ctx, job, err, workerId, time.Since(startTime)))
+			continue
+		}
+		opened = append(opened, job)
+		values = append(values, value)
+	}
+	batch = opened
+	if len(batch) == 0 {
+		return errors.Join(saveErrs...)
+	}
+
+	s.beginJobs(batch...)
+	defer s.endJobs(batch...)
+
+	stopRefresh := s.startVisibilityRefresh(ctx, batch...)
+	errs := s.batchHandler(ctx, values)
+	stopRefresh()
+	duration := time.Since(startTime)
+
+	if len(errs) != len(batch) {
+		err := fmt.Errorf("batch handler returned %d results for %d jobs", len(errs), len(batch))
+		errs = make([]error, len(batch))
+		for i := range errs {
+			errs[i] = err
+		}
+	}
+
+	for i, job := range batch {
+		saveErrs = append(saveErrs, s.finishJob(ctx, job, errs[i], workerId, duration))
+	}
+
+	return errors.Join(saveErrs...)
+}
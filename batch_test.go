@@ -0,0 +1,77 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestBatchHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		jobs      int
+		batchSize int
+		failEven  bool // Fail the jobs with an even payload
+		wantCalls int
+	}{
+		{name: "full batches", jobs: 100, batchSize: 10, wantCalls: 10},
+		{name: "last batch partial", jobs: 25, batchSize: 10, wantCalls: 3},
+		{name: "per job results", jobs: 10, batchSize: 10, failEven: true, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var mu sync.Mutex
+			var calls []int
+			handler := func(ctx context.Context, jobs []scheduler.Job[int]) []error {
+				mu.Lock()
+				calls = append(calls, len(jobs))
+				mu.Unlock()
+
+				errs := make([]error, len(jobs))
+				for i, job := range jobs {
+					if tt.failEven && job.Payload%2 == 0 {
+						errs[i] = errors.New("invalid recipient")
+					}
+				}
+				return errs
+			}
+
+			store := storage.NewMemoryStore[int]()
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, noopHandler[int], discardLogger(),
+				scheduler.WithBatchHandler[int](handler, tt.batchSize, 200*time.Millisecond),
+			)
+			ids := make([]string, tt.jobs)
+			for i := range tt.jobs {
+				job := s.NewJob(time.Now(), i)
+				ids[i] = job.Id
+				if err := s.SubmitJob(job); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s.Run(ctx)
+
+			for i, id := range ids {
+				status := "completed"
+				if tt.failEven && i%2 == 0 {
+					status = "failed"
+				}
+				waitForStatus(t, store, id, status, 5*time.Second)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(calls) != tt.wantCalls {
+				t.Fatalf("batch handler called %d times with %v jobs, want %d calls", len(calls), calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package scheduler_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage/noop"
+)
+
+// benchmarkThroughput runs a scheduler against the no-op store until handler has completed b.N jobs,
+// so the result reflects fetch and dispatch overhead only
+func benchmarkThroughput(b *testing.B, workers int, handler scheduler.JobHandler[struct{}], opts ...scheduler.Option[struct{}]) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := noop.NewNoopStore(struct{}{})
+	s := scheduler.NewScheduler(store, workers, time.Millisecond, 30*time.Second, handler, discardLogger(), opts...)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	done := s.Run(ctx)
+	for store.Completed() < int64(b.N) {
+		time.Sleep(100 * time.Microsecond)
+	}
+	b.StopTimer()
+
+	cancel()
+	if err := <-done; err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkScheduler(b *testing.B) {
+	for _, workers := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			benchmarkThroughput(b, workers, noopHandler[struct{}])
+		})
+	}
+}
+
+func BenchmarkDispatchLatency(b *testing.B) {
+	tests := []struct {
+		name string
+		opts []scheduler.Option[struct{}]
+	}{
+		{name: "channel"},
+		{name: "inline", opts: []scheduler.Option[struct{}]{scheduler.WithInlineDispatch[struct{}]()}},
+	}
+
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			// The no-op store makes jobs due the moment it returns them, so the wait until the
+			// handler starts is the time spent dispatching
+			var total atomic.Int64
+			var handled atomic.Int64
+			handler := func(ctx context.Context, job scheduler.Job[struct{}]) error {
+				total.Add(int64(time.Since(job.ProcessAfter)))
+				handled.Add(1)
+				return nil
+			}
+
+			benchmarkThroughput(b, 1, handler, tt.opts...)
+			b.ReportMetric(float64(total.Load())/float64(handled.Load()), "ns/dispatch")
+		})
+	}
+}
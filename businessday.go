@@ -0,0 +1,64 @@
+package scheduler
+
+import "time"
+
+// HolidayCalendar is a set of dates that aren't business days, weekends never are
+type HolidayCalendar map[civilDate]struct{}
+
+// civilDate is a calendar date without a time or location
+type civilDate struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func dateOf(t time.Time) civilDate {
+	year, month, day := t.Date()
+	return civilDate{year: year, month: month, day: day}
+}
+
+// NewHolidayCalendar creates a calendar of the given dates, each taken in its own location
+func NewHolidayCalendar(dates ...time.Time) HolidayCalendar {
+	holidays := make(HolidayCalendar, len(dates))
+	for _, date := range dates {
+		holidays[dateOf(date)] = struct{}{}
+	}
+	return holidays
+}
+
+// IsBusinessDay reports whether the date of t, in t's location, is neither a weekend nor a holiday
+func (c HolidayCalendar) IsBusinessDay(t time.Time) bool {
+	if weekday := t.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		return false
+	}
+	_, holiday := c[dateOf(t)]
+	return !holiday
+}
+
+// NextBusinessDay returns t moved forward by n business days, skipping weekends and holidays and
+// keeping the time of day. A weekend or holiday t counts from the next business day, e.g. one business day after a
+// Saturday is the Monday. With n of zero t is moved to the next business day only if it isn't one.
+func NextBusinessDay(t time.Time, n int, holidays HolidayCalendar) time.Time {
+	if n <= 0 {
+		for !holidays.IsBusinessDay(t) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t
+	}
+
+	for ; n > 0; n-- {
+		t = t.AddDate(0, 0, 1)
+		for !holidays.IsBusinessDay(t) {
+			t = t.AddDate(0, 0, 1)
+		}
+	}
+
+	return t
+}
+
+// WithJobBusinessDayDelay schedules the job n business days after base, see NextBusinessDay
+func WithJobBusinessDayDelay[T any](base time.Time, n int, holidays HolidayCalendar) JobOption[T] {
+	return func(j *Job[T]) {
+		j.ProcessAfter = NextBusinessDay(base, n, holidays)
+	}
+}
@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextBusinessDay(t *testing.T) {
+	// 2024-03-04 is a Monday
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 15, 30, 0, 0, time.UTC) }
+	holidays := NewHolidayCalendar(time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name     string
+		base     time.Time
+		n        int
+		holidays HolidayCalendar
+		want     time.Time
+	}{
+		{name: "within the week", base: day(4), n: 3, want: day(7)},
+		{name: "over a weekend", base: day(7), n: 3, want: day(12)},
+		{name: "from a saturday", base: day(9), n: 1, want: day(11)},
+		{name: "holiday in the window", base: day(4), n: 3, holidays: holidays, want: day(8)},
+		{name: "holiday and weekend in the window", base: day(5), n: 3, holidays: holidays, want: day(11)},
+		{name: "zero keeps a business day", base: day(5), n: 0, holidays: holidays, want: day(5)},
+		{name: "zero moves off a holiday", base: day(6), n: 0, holidays: holidays, want: day(7)},
+		{name: "zero moves off a weekend", base: day(10), n: 0, want: day(11)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NextBusinessDay(tt.base, tt.n, tt.holidays)
+			if !got.Equal(tt.want) {
+				t.Fatalf("NextBusinessDay(%s, %d) = %s, want %s", tt.base.Format("Mon Jan 2"), tt.n, got.Format("Mon Jan 2 15:04"), tt.want.Format("Mon Jan 2 15:04"))
+			}
+
+			job := newJob("job-1", time.Time{}, "report", WithJobBusinessDayDelay[string](tt.base, tt.n, tt.holidays))
+			if !job.ProcessAfter.Equal(tt.want) {
+				t.Fatalf("WithJobBusinessDayDelay() scheduled the job at %s, want %s", job.ProcessAfter, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package scheduler
+
+import "errors"
+
+// CancelByKey cancels the pending jobs submitted with the idempotency key, returning how many were
+// cancelled. Jobs being processed aren't cancelled. The store must implement KeyCanceller.
+func (s *Scheduler[T]) CancelByKey(key string) (int, error) {
+	if key == "" {
+		return 0, &SchedulerError{Op: "cancel by key", Cause: errors.New("idempotency key cannot be empty")}
+	}
+
+	canceller, ok := s.store.(KeyCanceller)
+	if !ok {
+		return 0, &SchedulerError{Op: "cancel by key", Cause: ErrNotSupported}
+	}
+
+	cancelled, err := canceller.CancelByKey(key)
+	if err != nil {
+		return cancelled, &SchedulerError{Op: "cancel by key", Cause: err}
+	}
+
+	return cancelled, nil
+}
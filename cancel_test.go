@@ -0,0 +1,104 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+	"go-sched/storage/noop"
+)
+
+func TestCancelByKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		cancelKey     string
+		wantCancelled int
+		wantRun       []string // Keys of the jobs that still run
+	}{
+		{name: "cancels every job with the key", cancelKey: "reminder:order-42", wantCancelled: 2, wantRun: []string{"reminder:order-7"}},
+		{name: "unknown key", cancelKey: "reminder:order-1", wantRun: []string{"reminder:order-42", "reminder:order-42", "reminder:order-7"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var mu sync.Mutex
+			var ran []string
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				mu.Lock()
+				defer mu.Unlock()
+				ran = append(ran, job.IdempotencyKey)
+				return nil
+			}
+
+			store := storage.NewMemoryStore[string]()
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger())
+			var jobs []*scheduler.Job[string]
+			for _, key := range []string{"reminder:order-42", "reminder:order-42", "reminder:order-7"} {
+				job := s.NewJob(time.Now().Add(100*time.Millisecond), "reminder", scheduler.WithJobIdempotencyKey[string](key))
+				if err := s.SubmitJob(job); err != nil {
+					t.Fatal(err)
+				}
+				jobs = append(jobs, job)
+			}
+
+			cancelled, err := s.CancelByKey(tt.cancelKey)
+			if err != nil || cancelled != tt.wantCancelled {
+				t.Fatalf("CancelByKey(%q) = %d, %v, want %d", tt.cancelKey, cancelled, err, tt.wantCancelled)
+			}
+			s.Run(ctx)
+
+			for _, job := range jobs {
+				if job.IdempotencyKey != tt.cancelKey {
+					waitForStatus(t, store, job.Id, "completed", 5*time.Second)
+				}
+			}
+			// Give cancelled jobs the chance to run had they been left in the store
+			time.Sleep(200 * time.Millisecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(ran) != len(tt.wantRun) || store.Len() != len(tt.wantRun) {
+				t.Fatalf("ran %v with %d jobs stored, want %v", ran, store.Len(), tt.wantRun)
+			}
+			for _, key := range ran {
+				if key == tt.cancelKey {
+					t.Fatalf("job with cancelled key %q ran", key)
+				}
+			}
+		})
+	}
+}
+
+func TestCancelByKeyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		store   scheduler.JobStore[string]
+		key     string
+		wantErr error
+	}{
+		{name: "empty key", store: storage.NewMemoryStore[string](), key: ""},
+		{name: "store without keys", store: noop.NewNoopStore(""), key: "reminder:order-42", wantErr: scheduler.ErrNotSupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := scheduler.NewScheduler(tt.store, 1, time.Second, time.Minute, noopHandler[string], discardLogger())
+
+			_, err := s.CancelByKey(tt.key)
+			var schedErr *scheduler.SchedulerError
+			if !errors.As(err, &schedErr) || schedErr.Op != "cancel by key" {
+				t.Fatalf("CancelByKey(%q) error = %v, want a cancel by key SchedulerError", tt.key, err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CancelByKey(%q) error = %v, want %v", tt.key, err, tt.wantErr)
+			}
+		})
+	}
+}
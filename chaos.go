@@ -0,0 +1,56 @@
+//go:build chaos
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrChaos is the failure injected into fetches and handlers by WithChaosMode
+var ErrChaos = errors.New("chaos mode: injected failure")
+
+// chaosMode injects failures and latency to exercise retries, shutdown and visibility recovery
+type chaosMode struct {
+	failRate   float64
+	maxLatency time.Duration
+}
+
+// WithChaosMode makes fetches fail with ErrChaos with probability failRate after a random delay
+// of up to maxLatency, and handlers fail with ErrChaos with probability failRate without being
+// called, to test the scheduler's retries, graceful shutdown and visibility timeout recovery.
+// It is only compiled with the chaos build tag, e.g. go test -tags chaos ./...
+func WithChaosMode[T any](failRate float64, maxLatency time.Duration) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.chaos = &chaosMode{failRate: failRate, maxLatency: maxLatency}
+	}
+}
+
+// fetchFault delays the fetch and returns the failure to inject into it, if any
+func (c *chaosMode) fetchFault(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+
+	if c.maxLatency > 0 {
+		sleepContext(ctx, time.Duration(rand.Int64N(int64(c.maxLatency))))
+	}
+	return c.fault()
+}
+
+// handlerFault returns the failure to report instead of calling the handler, if any
+func (c *chaosMode) handlerFault() error {
+	if c == nil {
+		return nil
+	}
+	return c.fault()
+}
+
+func (c *chaosMode) fault() error {
+	if rand.Float64() < c.failRate {
+		return ErrChaos
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+//go:build chaos
+
+package scheduler_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestChaosMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		failRate    float64
+		wantDone    bool // Whether every job completes despite the injected failures
+		wantRetried bool // Whether some jobs needed more than one attempt
+	}{
+		{name: "no failures", wantDone: true},
+		{name: "retries recover from failures", failRate: 0.3, wantDone: true, wantRetried: true},
+		{name: "every fetch fails"},
+	}
+
+	const total = 30
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var handled atomic.Int64
+			handler := func(ctx context.Context, job scheduler.Job[int]) error {
+				handled.Add(1)
+				return nil
+			}
+
+			failRate := tt.failRate
+			if !tt.wantDone {
+				failRate = 1
+			}
+			store := storage.NewMemoryStore[int]()
+			s := scheduler.NewScheduler(store, 4, 10*time.Millisecond, time.Minute, handler, discardLogger(),
+				scheduler.WithChaosMode[int](failRate, 5*time.Millisecond),
+				scheduler.WithRetry[int](20, func(int) time.Duration { return time.Millisecond }),
+				scheduler.WithErrorInterval[int](10*time.Millisecond, 50*time.Millisecond),
+			)
+			var ids []string
+			for i := range total {
+				job := s.NewJob(time.Now(), i)
+				if err := s.SubmitJob(job); err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, job.Id)
+			}
+			done := s.Run(ctx)
+
+			if tt.wantDone {
+				retried := false
+				for _, id := range ids {
+					if job := waitForStatus(t, store, id, "completed", 10*time.Second); job.Attempts > 1 {
+						retried = true
+					}
+				}
+				if retried != tt.wantRetried {
+					t.Errorf("jobs retried = %v, want %v", retried, tt.wantRetried)
+				}
+				// Injected handler failures stand in for the handler, which runs once per job
+				if handled.Load() != total {
+					t.Errorf("handler ran %d times, want %d", handled.Load(), total)
+				}
+			} else {
+				time.Sleep(300 * time.Millisecond)
+				if handled.Load() != 0 {
+					t.Fatalf("handler ran %d times while every fetch failed", handled.Load())
+				}
+			}
+
+			// Shutdown stays graceful whatever was injected
+			cancel()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("scheduler didn't stop")
+			}
+		})
+	}
+}
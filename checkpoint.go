@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// checkpointKey is the context key under which workers store the checkpoint saver of the current job
+type checkpointKey struct{}
+
+type checkpointSaver func(ctx context.Context, data json.RawMessage) error
+
+// withCheckpoint returns a context through which the handler of job can save checkpoints
+func (s *Scheduler[T]) withCheckpoint(ctx context.Context, job *Job[T]) context.Context {
+	return context.WithValue(ctx, checkpointKey{}, checkpointSaver(func(ctx context.Context, data json.RawMessage) error {
+		defer s.lockJob(job)()
+
+		job.Checkpoint = data
+		return s.updateJob(ctx, job, "save checkpoint")
+	}))
+}
+
+// SaveCheckpoint persists v as the progress of the job being handled, a job re-dispatched after
+// a crash or retry carries it in Job.Checkpoint so the handler can resume instead of starting over.
+// ctx must be the context passed to the job handler; batch handlers can't save checkpoints.
+func SaveCheckpoint(ctx context.Context, v any) error {
+	save, ok := ctx.Value(checkpointKey{}).(checkpointSaver)
+	if !ok {
+		return errors.New("save checkpoint: context doesn't belong to a job handler")
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	return save(ctx, data)
+}
@@ -0,0 +1,90 @@
+package scheduler_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+// progress is the checkpoint of a job working through a list of items
+type progress struct {
+	Next int `json:"next"`
+}
+
+func TestCheckpointResumeAfterCrash(t *testing.T) {
+	tests := []struct {
+		name      string
+		crashAt   int // Items processed before the first run crashes, checkpointed after each one
+		wantStart int // Item the run after the crash starts at
+	}{
+		{name: "crash before any checkpoint", crashAt: 0, wantStart: 0},
+		{name: "resume mid-way", crashAt: 6, wantStart: 6},
+	}
+
+	const items = 10
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var mu sync.Mutex
+			var processed []int
+			crashed := make(chan struct{})
+			hang := make(chan struct{})
+			defer close(hang)
+
+			// The first instance's worker checkpoints its progress, then hangs as if its process died
+			crashing := func(ctx context.Context, job scheduler.Job[int]) error {
+				for i := 0; i < tt.crashAt; i++ {
+					if err := scheduler.SaveCheckpoint(ctx, progress{Next: i + 1}); err != nil {
+						t.Error(err)
+					}
+				}
+				close(crashed)
+				<-hang
+				return nil
+			}
+
+			// The second instance picks the job up once its visibility timeout expires
+			resuming := func(ctx context.Context, job scheduler.Job[int]) error {
+				var p progress
+				if job.Checkpoint != nil {
+					if err := json.Unmarshal(job.Checkpoint, &p); err != nil {
+						return err
+					}
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for i := p.Next; i < job.Payload; i++ {
+					processed = append(processed, i)
+				}
+				return nil
+			}
+
+			store := storage.NewMemoryStore[int]()
+			first := scheduler.NewScheduler(store, 1, 10*time.Millisecond, 200*time.Millisecond, crashing, discardLogger())
+			job := first.NewJob(time.Now(), items)
+			if err := first.SubmitJob(job); err != nil {
+				t.Fatal(err)
+			}
+			first.Run(ctx)
+			<-crashed
+
+			second := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, resuming, discardLogger())
+			second.Run(ctx)
+			waitForStatus(t, store, job.Id, "completed", 5*time.Second)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(processed) != items-tt.wantStart || processed[0] != tt.wantStart {
+				t.Fatalf("resumed run processed %v, want items %d to %d", processed, tt.wantStart, items-1)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (c circuitState) String() string {
+	switch c {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker pauses dispatch after a number of consecutive handler failures across jobs
+// Once the cooldown elapses a single trial job is let through; success closes the circuit, failure reopens it
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     circuitState
+	failures  int
+	openedAt  time.Time
+	trial     bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// acquire reports whether jobs may be dispatched and how many (0 means no limit)
+func (b *circuitBreaker) acquire(now time.Time) (limit int, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitClosed:
+		return 0, true
+	case circuitOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return 0, false
+		}
+		b.state = circuitHalfOpen
+		b.trial = false
+	}
+
+	// Half-open: let a single trial job through
+	if b.trial {
+		return 0, false
+	}
+	b.trial = true
+	return 1, true
+}
+
+// cancelTrial gives back a half-open trial slot that wasn't used for a job
+func (b *circuitBreaker) cancelTrial() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitHalfOpen {
+		b.trial = false
+	}
+}
+
+// record registers a handler result and returns the resulting state and whether it changed
+func (b *circuitBreaker) record(err error, now time.Time) (circuitState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	previous := b.state
+
+	if err == nil {
+		b.state = circuitClosed
+		b.failures = 0
+		b.trial = false
+		return b.state, previous != b.state
+	}
+
+	b.failures++
+	if b.state == circuitHalfOpen || (b.state == circuitClosed && b.failures >= b.threshold) {
+		b.state = circuitOpen
+		b.openedAt = now
+		b.trial = false
+	}
+
+	return b.state, previous != b.state
+}
+
+// current returns the breaker state
+func (b *circuitBreaker) current() circuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
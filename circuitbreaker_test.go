@@ -0,0 +1,123 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name          string
+		threshold     int
+		cooldown      time.Duration
+		failures      int // Handler calls failing before the handler recovers
+		wantRuns      int
+		wantCompleted int
+	}{
+		{name: "pauses dispatch after the threshold", threshold: 3, cooldown: time.Hour, failures: 10, wantRuns: 3},
+		{name: "trial job closes the circuit once the handler recovers", threshold: 2, cooldown: 100 * time.Millisecond, failures: 2, wantRuns: 10, wantCompleted: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var runs atomic.Int32
+			handler := func(ctx context.Context, job scheduler.Job[int]) error {
+				if int(runs.Add(1)) <= tt.failures {
+					return errors.New("downstream unavailable")
+				}
+				return nil
+			}
+
+			store := storage.NewMemoryStore[int]()
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger(),
+				scheduler.WithCircuitBreaker[int](tt.threshold, tt.cooldown),
+			)
+			for i := range 10 {
+				if err := s.SubmitJob(s.NewJob(time.Now(), i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s.Run(ctx)
+
+			deadline := time.Now().Add(3 * time.Second)
+			for int(runs.Load()) < tt.wantRuns && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			// Give a circuit that failed to open the chance to dispatch more jobs
+			time.Sleep(300 * time.Millisecond)
+
+			if got := int(runs.Load()); got != tt.wantRuns {
+				t.Fatalf("handler ran %d times, want %d", got, tt.wantRuns)
+			}
+			completed := 0
+			for _, job := range store.GetJobs() {
+				if job.Status == "completed" {
+					completed++
+				}
+			}
+			if completed != tt.wantCompleted {
+				t.Fatalf("%d jobs completed, want %d", completed, tt.wantCompleted)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerTrialOverBudget(t *testing.T) {
+	tests := []struct {
+		name      string
+		trialCost int // Cost of the job probing recovery, the budget has 10 left when it is fetched
+	}{
+		{name: "trial job within budget", trialCost: 5},
+		{name: "trial job over budget", trialCost: 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The first job uses up the budget and opens the circuit
+			handler := func(ctx context.Context, job scheduler.Job[int]) error {
+				if job.Payload == 0 {
+					return errors.New("downstream unavailable")
+				}
+				return nil
+			}
+			cost := func(job scheduler.Job[int]) int {
+				if job.Payload == 0 {
+					return 10
+				}
+				return tt.trialCost
+			}
+
+			store := storage.NewMemoryStore[int]()
+			s := scheduler.NewScheduler(store, 1, 200*time.Millisecond, time.Minute, handler, discardLogger(),
+				scheduler.WithCircuitBreaker[int](1, 100*time.Millisecond),
+				scheduler.WithCostBasedScheduling(cost, 10),
+			)
+			var ids []string
+			for i := range 2 {
+				job := s.NewJob(time.Now().Add(time.Duration(i)*time.Millisecond), i)
+				if err := s.SubmitJob(job); err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, job.Id)
+			}
+			time.Sleep(5 * time.Millisecond)
+			s.Run(ctx)
+
+			waitForStatus(t, store, ids[0], "failed", 2*time.Second)
+			// A trial job deferred by the budget must not keep the circuit from probing again
+			waitForStatus(t, store, ids[1], "completed", 2*time.Second)
+		})
+	}
+}
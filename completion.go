@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// completion is a handled job waiting for its result to be saved
+type completion[T any] struct {
+	job        *Job[T]
+	err        error
+	finishedAt time.Time
+}
+
+// completionQueue hands job results from workers to a goroutine that saves them, so a store that
+// is slow to save results shows up as a backlog the fetch loop can react to
+type completionQueue[T any] struct {
+	pending chan completion[T]
+	done    sync.WaitGroup
+	errs    []error // Results that couldn't be saved once shutdown began
+}
+
+// startCompletionQueue starts saving job results in the background if a completion queue is configured
+func (s *Scheduler[T]) startCompletionQueue(ctx context.Context) {
+	if s.resultQueueSize <= 0 {
+		return
+	}
+
+	q := &completionQueue[T]{pending: make(chan completion[T], s.resultQueueSize)}
+	s.completions.Store(q)
+
+	q.done.Add(1)
+	go func() {
+		defer q.done.Done()
+		for c := range q.pending {
+			if err := s.saveResult(ctx, c.job, c.err); err != nil && ctx.Err() != nil {
+				q.errs = append(q.errs, err)
+			}
+			s.completionLag.Store(int64(time.Since(c.finishedAt)))
+		}
+	}()
+}
+
+// stopCompletionQueue waits for the queued results to be saved, workers must have stopped
+// It returns the errors of results that couldn't be saved once shutdown began
+func (s *Scheduler[T]) stopCompletionQueue() error {
+	q := s.completions.Swap(nil)
+	if q == nil {
+		return nil
+	}
+
+	close(q.pending)
+	q.done.Wait()
+
+	return errors.Join(q.errs...)
+}
+
+// completionsBehind reports whether the completion queue is full, i.e. results are handled faster
+// than the store saves them
+func (s *Scheduler[T]) completionsBehind() bool {
+	q := s.completions.Load()
+	return q != nil && len(q.pending) >= cap(q.pending)
+}
+
+// queuedResults returns the number of job results waiting to be saved
+func (s *Scheduler[T]) queuedResults() int {
+	q := s.completions.Load()
+	if q == nil {
+		return 0
+	}
+	return len(q.pending)
+}
+
+// CompletionLag returns how long the most recently saved job result waited in the completion
+// queue between its handler returning and the store saving it, zero without WithCompletionQueue.
+// A lag that keeps growing means the store is the bottleneck.
+func (s *Scheduler[T]) CompletionLag() time.Duration {
+	return time.Duration(s.completionLag.Load())
+}
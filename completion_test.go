@@ -0,0 +1,82 @@
+package scheduler_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestCompletionQueueThrottlesFetch(t *testing.T) {
+	tests := []struct {
+		name      string
+		workers   int
+		queueSize int
+	}{
+		{name: "single result queued", workers: 1, queueSize: 1},
+		{name: "larger queue", workers: 2, queueSize: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			const total = 30
+			store := &hookStore[int]{MemoryStore: storage.NewMemoryStore[int]()}
+			for i := range total {
+				if err := store.AddJob(&scheduler.Job[int]{Id: fmt.Sprintf("job-%02d", i), Status: "pending", ProcessAfter: time.Now(), Payload: i}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Handling is instant while saving a result takes a while, results pile up unless fetching waits
+			var handled, saved, backlog atomic.Int64
+			store.onUpdate = func(job *scheduler.Job[int]) error {
+				if job.Status == "completed" {
+					time.Sleep(10 * time.Millisecond)
+					saved.Add(1)
+				}
+				return nil
+			}
+			store.onFetch = func() error {
+				waiting := handled.Load() - saved.Load()
+				for {
+					peak := backlog.Load()
+					if waiting <= peak || backlog.CompareAndSwap(peak, waiting) {
+						return nil
+					}
+				}
+			}
+			handler := func(ctx context.Context, job scheduler.Job[int]) error {
+				handled.Add(1)
+				return nil
+			}
+
+			s := scheduler.NewScheduler(store, tt.workers, time.Millisecond, time.Minute, handler, discardLogger(),
+				scheduler.WithCompletionQueue[int](tt.queueSize))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			errs := s.Run(ctx)
+			for i := range total {
+				waitForStatus(t, store.MemoryStore, fmt.Sprintf("job-%02d", i), "completed", 5*time.Second)
+			}
+			if lag := s.CompletionLag(); lag <= 0 {
+				t.Errorf("CompletionLag() = %s, want the time results waited", lag)
+			}
+			cancel()
+			if err := <-errs; err != nil {
+				t.Fatal(err)
+			}
+
+			// Fetching pauses while the queue is full, so a fetch sees less than a full queue plus the
+			// result being saved and one per worker waiting to be queued
+			if limit := int64(tt.queueSize + tt.workers); backlog.Load() > limit {
+				t.Fatalf("fetched with %d results waiting to be saved, want at most %d", backlog.Load(), limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package scheduler
+
+import "time"
+
+// costBudget limits the total cost of jobs dispatched per interval
+// Unused budget rolls over to the next interval up to twice the per-interval budget
+type costBudget[T any] struct {
+	costFn      func(job Job[T]) int
+	perInterval int
+	interval    time.Duration
+	available   int
+	refilledAt  time.Time
+}
+
+func newCostBudget[T any](costFn func(job Job[T]) int, perInterval int, interval time.Duration) *costBudget[T] {
+	return &costBudget[T]{
+		costFn:      costFn,
+		perInterval: perInterval,
+		interval:    interval,
+		available:   perInterval,
+		refilledAt:  time.Now(),
+	}
+}
+
+// take consumes cost from the budget, returning false if the budget can't cover it
+// A job costing more than the maximum budget is allowed once the budget is full so it can't starve
+func (b *costBudget[T]) take(cost int, now time.Time) bool {
+	b.refill(now)
+
+	limit := 2 * b.perInterval
+	if cost > b.available && !(cost > limit && b.available >= limit) {
+		return false
+	}
+
+	b.available -= cost
+	return true
+}
+
+func (b *costBudget[T]) refill(now time.Time) {
+	if b.interval <= 0 {
+		b.available = 2 * b.perInterval
+		return
+	}
+
+	elapsed := int(now.Sub(b.refilledAt) / b.interval)
+	if elapsed <= 0 {
+		return
+	}
+
+	b.available = min(b.available+elapsed*b.perInterval, 2*b.perInterval)
+	b.refilledAt = b.refilledAt.Add(time.Duration(elapsed) * b.interval)
+}
@@ -0,0 +1,36 @@
+package scheduler
+
+import "context"
+
+// DeliveryMode decides whether a job whose worker crashed mid-processing is delivered again
+type DeliveryMode int
+
+const (
+	// AtLeastOnce makes a job visible again once its visibility timeout lapses, so a job whose
+	// worker crashed is redelivered and handlers may see the same job more than once
+	AtLeastOnce DeliveryMode = iota
+	// AtMostOnce moves a job to the "processing" status before it is handed to a handler, so it
+	// is never fetched again; a job whose worker crashed stays in "processing" instead of being
+	// redelivered. Failed attempts are still retried according to WithRetry.
+	AtMostOnce
+)
+
+func (m DeliveryMode) String() string {
+	switch m {
+	case AtMostOnce:
+		return "at-most-once"
+	default:
+		return "at-least-once"
+	}
+}
+
+// deliveryModeKey is the context key under which the scheduler stores its delivery mode
+type deliveryModeKey struct{}
+
+// DeliveryModeFromContext returns the delivery mode of the scheduler that invoked the handler,
+// so idempotency-sensitive handlers can tell whether they might see a job twice. ctx must be
+// derived from the context passed to the handler, AtLeastOnce is returned otherwise.
+func DeliveryModeFromContext(ctx context.Context) DeliveryMode {
+	mode, _ := ctx.Value(deliveryModeKey{}).(DeliveryMode)
+	return mode
+}
@@ -0,0 +1,78 @@
+package scheduler_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestDeliveryModeAfterCrash(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          scheduler.DeliveryMode
+		wantRedeliver bool
+		wantStatus    string // Status of the job while the crashed worker holds it
+	}{
+		{name: "at least once redelivers", mode: scheduler.AtLeastOnce, wantRedeliver: true, wantStatus: "pending"},
+		{name: "at most once doesn't", mode: scheduler.AtMostOnce, wantStatus: "processing"},
+	}
+
+	const visibilityTimeout = 200 * time.Millisecond
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The first instance's worker hangs as if its process died, the second one completes jobs
+			delivered := make(chan scheduler.DeliveryMode, 2)
+			hang := make(chan struct{})
+			defer close(hang)
+			crashing := func(ctx context.Context, job scheduler.Job[string]) error {
+				delivered <- scheduler.DeliveryModeFromContext(ctx)
+				<-hang
+				return nil
+			}
+			redelivered := make(chan struct{}, 1)
+			completing := func(ctx context.Context, job scheduler.Job[string]) error {
+				redelivered <- struct{}{}
+				return nil
+			}
+
+			store := storage.NewMemoryStore[string]()
+			opts := []scheduler.Option[string]{scheduler.WithDeliveryMode[string](tt.mode)}
+			first := scheduler.NewScheduler(store, 1, 10*time.Millisecond, visibilityTimeout, crashing, discardLogger(), opts...)
+			job := first.NewJob(time.Now(), "charge card")
+			if err := first.SubmitJob(job); err != nil {
+				t.Fatal(err)
+			}
+			first.Run(ctx)
+
+			if mode := <-delivered; mode != tt.mode {
+				t.Fatalf("handler saw delivery mode %s, want %s", mode, tt.mode)
+			}
+			if status := store.GetJobs()[job.Id].Status; status != tt.wantStatus {
+				t.Fatalf("job being handled has status %q, want %q", status, tt.wantStatus)
+			}
+
+			second := scheduler.NewScheduler(store, 1, 10*time.Millisecond, visibilityTimeout, completing, discardLogger(), opts...)
+			second.Run(ctx)
+
+			select {
+			case <-redelivered:
+				if !tt.wantRedeliver {
+					t.Fatal("job was redelivered after the crash")
+				}
+			case <-time.After(3 * visibilityTimeout):
+				if tt.wantRedeliver {
+					t.Fatal("job wasn't redelivered after the crash")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Snapshot is the scheduler's configuration and live state, as dumped by Dump
+type Snapshot struct {
+	InstanceID        string        `json:"instanceId,omitempty"`
+	Workers           int           `json:"workers"`
+	Interval          string        `json:"interval"`
+	VisibilityTimeout string        `json:"visibilityTimeout"`
+	BatchSize         int           `json:"batchSize,omitempty"`
+	MaxAttempts       int           `json:"maxAttempts"`
+	FetchStrategy     string        `json:"fetchStrategy"`
+	DeliveryMode      string        `json:"deliveryMode"`
+	LeaderLockTTL     string        `json:"leaderLockTtl,omitempty"`
+	CircuitBreaker    string        `json:"circuitBreaker,omitempty"` // Breaker state, if configured
+	RecurringJobs     int           `json:"recurringJobs"`
+	PausedTypes       []string      `json:"pausedTypes,omitempty"`
+	InFlight          []InFlightJob `json:"inFlight"`
+	QueuedResults     int           `json:"queuedResults,omitempty"` // Results waiting in the completion queue
+	CompletionLag     string        `json:"completionLag,omitempty"` // Set with WithCompletionQueue
+	InvisibleJobs     *int          `json:"invisibleJobs,omitempty"` // Set if the store implements InvisibleCounter
+	StatsError        string        `json:"statsError,omitempty"`
+}
+
+// InFlightJob is a job currently being processed by a worker
+type InFlightJob struct {
+	ID        string    `json:"id"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// Snapshot captures the scheduler's configuration and live state, it is safe to call while the scheduler runs
+func (s *Scheduler[T]) Snapshot() Snapshot {
+	snapshot := Snapshot{
+		InstanceID:        s.instanceID,
+		Workers:           s.workers(),
+		Interval:          s.pollInterval().String(),
+		VisibilityTimeout: s.visibilityTimeout.String(),
+		MaxAttempts:       s.maxAttempts,
+		FetchStrategy:     fmt.Sprintf("%T", s.fetchStrategy),
+		DeliveryMode:      s.deliveryMode.String(),
+	}
+
+	if s.batchHandler != nil {
+		snapshot.BatchSize = s.batchSize
+	}
+	if s.leaderTTL > 0 {
+		snapshot.LeaderLockTTL = s.leaderTTL.String()
+	}
+	if s.resultQueueSize > 0 {
+		snapshot.QueuedResults = s.queuedResults()
+		snapshot.CompletionLag = s.CompletionLag().String()
+	}
+	if s.breaker != nil {
+		snapshot.CircuitBreaker = s.breaker.current().String()
+	}
+
+	snapshot.PausedTypes = s.pausedTypeList()
+
+	s.cronMu.Lock()
+	snapshot.RecurringJobs = len(s.crons)
+	s.cronMu.Unlock()
+
+	s.inFlightMu.Lock()
+	snapshot.InFlight = make([]InFlightJob, 0, len(s.inFlight))
+	for id, state := range s.inFlight {
+		snapshot.InFlight = append(snapshot.InFlight, InFlightJob{ID: id, StartedAt: state.startedAt})
+	}
+	s.inFlightMu.Unlock()
+
+	sort.Slice(snapshot.InFlight, func(i, j int) bool {
+		return snapshot.InFlight[i].StartedAt.Before(snapshot.InFlight[j].StartedAt)
+	})
+
+	if _, ok := s.store.(InvisibleCounter); ok {
+		stats, err := s.Stats()
+		if err != nil {
+			snapshot.StatsError = err.Error()
+		} else {
+			snapshot.InvisibleJobs = &stats.InvisibleJobs
+		}
+	}
+
+	return snapshot
+}
+
+// Dump returns the scheduler snapshot as indented JSON, e.g. for attaching to a support ticket
+func (s *Scheduler[T]) Dump() ([]byte, error) {
+	return json.MarshalIndent(s.Snapshot(), "", "  ")
+}
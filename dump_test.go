@@ -0,0 +1,80 @@
+package scheduler_test
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestDump(t *testing.T) {
+	tests := []struct {
+		name         string
+		workers      int
+		jobs         int
+		wantInFlight int
+	}{
+		{name: "every job in flight", workers: 3, jobs: 2, wantInFlight: 2},
+		{name: "more jobs than workers", workers: 2, jobs: 5, wantInFlight: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Handlers hold their jobs until the test ends, so the dump sees them in flight
+			started := make(chan string, tt.jobs)
+			release := make(chan struct{})
+			defer close(release)
+			handler := func(ctx context.Context, job scheduler.Job[int]) error {
+				started <- job.Id
+				<-release
+				return nil
+			}
+
+			store := storage.NewMemoryStore[int]()
+			s := scheduler.NewScheduler(store, tt.workers, 10*time.Millisecond, time.Minute, handler, discardLogger())
+			for i := range tt.jobs {
+				if err := s.SubmitJob(s.NewJob(time.Now(), i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s.Run(ctx)
+
+			var wantIDs []string
+			for range tt.wantInFlight {
+				wantIDs = append(wantIDs, <-started)
+			}
+
+			data, err := s.Dump()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var snapshot scheduler.Snapshot
+			if err := json.Unmarshal(data, &snapshot); err != nil {
+				t.Fatalf("dump isn't valid JSON: %v\n%s", err, data)
+			}
+
+			if snapshot.Workers != tt.workers {
+				t.Errorf("dumped %d workers, want %d", snapshot.Workers, tt.workers)
+			}
+			var gotIDs []string
+			for _, job := range snapshot.InFlight {
+				gotIDs = append(gotIDs, job.ID)
+			}
+			slices.Sort(gotIDs)
+			slices.Sort(wantIDs)
+			if !slices.Equal(gotIDs, wantIDs) {
+				t.Errorf("dumped in-flight jobs %v, want %v", gotIDs, wantIDs)
+			}
+			if snapshot.InvisibleJobs == nil || *snapshot.InvisibleJobs < tt.wantInFlight {
+				t.Errorf("dumped invisible jobs %v, want at least %d", snapshot.InvisibleJobs, tt.wantInFlight)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// Encrypter encrypts and decrypts serialized job payloads before they are persisted
+type Encrypter interface {
+	// Encrypt returns the ciphertext for the given serialized payload
+	Encrypt(plaintext []byte) ([]byte, error)
+
+	// Decrypt returns the serialized payload for the given ciphertext
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// AESGCMEncrypter is an Encrypter using AES-GCM with a caller-supplied key
+// The random nonce is prepended to every ciphertext
+type AESGCMEncrypter struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMEncrypter creates an AES-GCM encrypter, key must be 16, 24 or 32 bytes long
+func NewAESGCMEncrypter(key []byte) (*AESGCMEncrypter, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AESGCMEncrypter{aead: aead}, nil
+}
+
+// Encrypt seals the plaintext with a fresh random nonce
+func (e *AESGCMEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return e.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt opens a ciphertext produced by Encrypt
+func (e *AESGCMEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	nonceSize := e.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, sealed := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	return e.aead.Open(nil, nonce, sealed, nil)
+}
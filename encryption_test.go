@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAESGCMEncrypter(t *testing.T) {
+	tests := []struct {
+		name      string
+		keySize   int
+		plaintext []byte
+		wantErr   bool
+	}{
+		{name: "AES-128", keySize: 16, plaintext: []byte(`{"to":"a@example.com"}`)},
+		{name: "AES-192", keySize: 24, plaintext: []byte(`{"to":"a@example.com"}`)},
+		{name: "AES-256", keySize: 32, plaintext: []byte(`{"to":"a@example.com"}`)},
+		{name: "empty plaintext", keySize: 32, plaintext: []byte{}},
+		{name: "invalid key size", keySize: 20, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := NewAESGCMEncrypter(bytes.Repeat([]byte{1}, tt.keySize))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewAESGCMEncrypter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			ciphertext, err := enc.Encrypt(tt.plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+			if len(tt.plaintext) > 0 && bytes.Contains(ciphertext, tt.plaintext) {
+				t.Fatal("ciphertext contains the plaintext")
+			}
+
+			again, _ := enc.Encrypt(tt.plaintext)
+			if bytes.Equal(ciphertext, again) {
+				t.Fatal("Encrypt() reused a nonce")
+			}
+
+			plaintext, err := enc.Decrypt(ciphertext)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			if !bytes.Equal(plaintext, tt.plaintext) {
+				t.Fatalf("Decrypt() = %q, want %q", plaintext, tt.plaintext)
+			}
+
+			ciphertext[len(ciphertext)-1] ^= 0xff
+			if _, err := enc.Decrypt(ciphertext); err == nil {
+				t.Fatal("Decrypt() accepted a tampered ciphertext")
+			}
+			if _, err := enc.Decrypt(ciphertext[:4]); err == nil {
+				t.Fatal("Decrypt() accepted a truncated ciphertext")
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrPayloadTooLarge is returned when a submitted job payload exceeds the configured size limit
+var ErrPayloadTooLarge = errors.New("job payload too large")
+
+// ErrDuplicateJob is returned when a job with the same dedup key was added within the dedup window
+var ErrDuplicateJob = errors.New("duplicate job")
+
+// ErrNotSupported is returned when a feature requires a store capability the configured store doesn't have
+var ErrNotSupported = errors.New("not supported by store")
+
+// ErrCronNotFound is returned when cancelling a recurring job that isn't registered with the scheduler
+var ErrCronNotFound = errors.New("recurring job not found")
+
+// ErrNoHandler is returned when a TypedRegistry has no handler for a job's payload type, or the
+// func of a job scheduled with ScheduleFunc isn't registered
+var ErrNoHandler = errors.New("no handler registered")
+
+// ErrJobExpired is returned when a job is submitted that would be older than the max job age by
+// the time it is due
+var ErrJobExpired = errors.New("job expired")
+
+// ErrInvalidInterval is returned by SetInterval for an interval that isn't positive
+var ErrInvalidInterval = errors.New("interval must be positive")
+
+// ErrConcurrentModification is returned by stores with optimistic locking when a job is updated
+// from a stale copy, i.e. it was updated by someone else since it was read
+var ErrConcurrentModification = errors.New("job was modified concurrently")
+
+// SchedulerError describes a failed scheduler operation, Op names the operation (e.g. "submit job")
+// and JobID the job it concerned, if any. It unwraps to Cause, so errors.Is still matches the
+// sentinel errors above.
+type SchedulerError struct {
+	Op    string
+	JobID string
+	Cause error
+}
+
+func (e *SchedulerError) Error() string {
+	if e.JobID != "" {
+		return fmt.Sprintf("%s %s: %v", e.Op, e.JobID, e.Cause)
+	}
+	return fmt.Sprintf("%s: %v", e.Op, e.Cause)
+}
+
+func (e *SchedulerError) Unwrap() error {
+	return e.Cause
+}
+
+// AsSchedulerError returns the first SchedulerError in err's chain
+func AsSchedulerError(err error) (*SchedulerError, bool) {
+	var schedErr *SchedulerError
+	if errors.As(err, &schedErr) {
+		return schedErr, true
+	}
+	return nil, false
+}
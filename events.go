@@ -0,0 +1,15 @@
+package scheduler
+
+// Job lifecycle events, logged as the "event" attribute of the scheduler's lifecycle log lines so
+// log-based alerts can key off a stable value rather than the message text
+const (
+	EventJobDispatched = "job.dispatched" // Made invisible and handed to a worker
+	EventJobStarted    = "job.started"    // Handler about to run
+	EventJobCompleted  = "job.completed"  // Handler succeeded, the job is done
+	EventJobRepeated   = "job.repeated"   // Run of a repeating job succeeded, the next run is scheduled
+	EventJobRetried    = "job.retried"    // Handler failed, the job is scheduled for another attempt
+	EventJobFailed     = "job.failed"     // Handler failed for good
+	EventJobDeferred   = "job.deferred"   // Fetched while its type is paused, put back for a polling interval
+	EventJobReleased   = "job.released"   // Made visible again without being processed
+	EventJobExpired    = "job.expired"    // Exceeded the max job age and wasn't processed
+)
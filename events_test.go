@@ -0,0 +1,106 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+// eventRecorder is a slog handler keeping the event attribute of every record, in order
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *eventRecorder) Enabled(context.Context, slog.Level) bool { return true }
+func (r *eventRecorder) WithAttrs([]slog.Attr) slog.Handler       { return r }
+func (r *eventRecorder) WithGroup(string) slog.Handler            { return r }
+
+func (r *eventRecorder) Handle(_ context.Context, record slog.Record) error {
+	record.Attrs(func(attr slog.Attr) bool {
+		if attr.Key != "event" {
+			return true
+		}
+		r.mu.Lock()
+		r.events = append(r.events, attr.Value.String())
+		r.mu.Unlock()
+		return false
+	})
+	return nil
+}
+
+func (r *eventRecorder) recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.events)
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		handlerErr error
+		opts       []scheduler.Option[string]
+		createdAgo time.Duration
+		wantEvents []string
+	}{
+		{
+			name:       "completed",
+			wantEvents: []string{scheduler.EventJobDispatched, scheduler.EventJobStarted, scheduler.EventJobCompleted},
+		},
+		{
+			name:       "retried",
+			handlerErr: errors.New("boom"),
+			opts:       []scheduler.Option[string]{scheduler.WithRetry[string](2, func(int) time.Duration { return time.Hour })},
+			wantEvents: []string{scheduler.EventJobDispatched, scheduler.EventJobStarted, scheduler.EventJobRetried},
+		},
+		{
+			name:       "failed",
+			handlerErr: errors.New("boom"),
+			wantEvents: []string{scheduler.EventJobDispatched, scheduler.EventJobStarted, scheduler.EventJobFailed},
+		},
+		{
+			name:       "expired",
+			opts:       []scheduler.Option[string]{scheduler.WithMaxJobAge[string](time.Hour)},
+			createdAgo: 2 * time.Hour,
+			wantEvents: []string{scheduler.EventJobExpired},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				return tt.handlerErr
+			}
+			recorder := &eventRecorder{}
+			store := storage.NewMemoryStore[string]()
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, slog.New(recorder), tt.opts...)
+
+			job := s.NewJob(time.Now(), "report")
+			job.CreatedAt = time.Now().Add(-tt.createdAgo)
+			if err := store.AddJob(job); err != nil {
+				t.Fatal(err)
+			}
+			s.Run(ctx)
+
+			// Wait for the expected events, then a little longer for any that shouldn't follow
+			deadline := time.Now().Add(5 * time.Second)
+			for len(recorder.recorded()) < len(tt.wantEvents) && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			time.Sleep(50 * time.Millisecond)
+			if got := recorder.recorded(); !slices.Equal(got, tt.wantEvents) {
+				t.Fatalf("logged events %v, want %v", got, tt.wantEvents)
+			}
+		})
+	}
+}
@@ -167,6 +167,9 @@ func main() {
 	cancel()
 
 	// Wait for graceful shutdown
-	<-done
+	if err := <-done; err != nil {
+		log.Error("scheduler stopped with error", "error", err)
+		os.Exit(1)
+	}
 	log.Info("scheduler stopped gracefully")
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage/noop"
+)
+
+func main() {
+	workerCount := flag.Int("workers", 100, "number of worker goroutines")
+	duration := flag.Duration("duration", 10*time.Second, "how long to run the load test")
+	interval := flag.Duration("interval", time.Millisecond, "scheduler pause while all workers are busy")
+	report := flag.Duration("report", time.Second, "interval between throughput reports")
+	inline := flag.Bool("inline", false, "handle jobs in the fetch loop, requires -workers 1")
+	flag.Parse()
+
+	// Per-job logs would dominate the measurement, only warnings and errors are printed
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	// The no-op store always has work available and discards writes, so throughput
+	// reflects scheduler overhead only
+	store := noop.NewNoopStore(struct{}{})
+
+	jobHandler := func(ctx context.Context, job scheduler.Job[struct{}]) error {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+
+	var opts []scheduler.Option[struct{}]
+	if *inline {
+		opts = append(opts, scheduler.WithInlineDispatch[struct{}]())
+	}
+
+	s := scheduler.NewScheduler(store, *workerCount, *interval, 30*time.Second, jobHandler, log, opts...)
+
+	start := time.Now()
+	done := s.Run(ctx)
+
+	fmt.Printf("running load test: workers=%d inline=%t interval=%s duration=%s\n", *workerCount, *inline, *interval, *duration)
+
+	ticker := time.NewTicker(*report)
+	defer ticker.Stop()
+
+	last, lastAt := int64(0), start
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "scheduler stopped with error: %v\n", err)
+				os.Exit(1)
+			}
+			elapsed := time.Since(start)
+			completed := store.Completed()
+			fmt.Printf("total: %d jobs in %s, %.0f jobs/sec\n", completed, elapsed.Round(time.Millisecond), float64(completed)/elapsed.Seconds())
+			return
+
+		case now := <-ticker.C:
+			completed := store.Completed()
+			fmt.Printf("%6.1fs  %10.0f jobs/sec\n", now.Sub(start).Seconds(), float64(completed-last)/now.Sub(lastAt).Seconds())
+			last, lastAt = completed, now
+		}
+	}
+}
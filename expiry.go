@@ -0,0 +1,21 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// jobExpired reports whether the job is older than the max job age at t
+func (s *Scheduler[T]) jobExpired(job *Job[T], t time.Time) bool {
+	return s.maxJobAge > 0 && job.Interval == 0 && t.Sub(job.CreatedAt) > s.maxJobAge
+}
+
+// expireJob saves a fetched job that is too old to run as expired without calling the handler
+func (s *Scheduler[T]) expireJob(ctx context.Context, job *Job[T]) {
+	s.log.Info("job exceeded max job age, expiring it", "event", EventJobExpired, "job-id", job.Id, "created-at", job.CreatedAt, "max-job-age", s.maxJobAge)
+	job.MakeExpired()
+	if err := s.updateJob(ctx, job, "expire job"); err == nil {
+		s.notifyWaiter(job)
+	}
+	s.funcRunFinished(job)
+}
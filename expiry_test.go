@@ -0,0 +1,77 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestMaxJobAge(t *testing.T) {
+	tests := []struct {
+		name       string
+		createdAgo time.Duration
+		wantStatus string
+		wantRuns   int64
+	}{
+		{name: "created 25 hours ago", createdAgo: 25 * time.Hour, wantStatus: "expired"},
+		{name: "created an hour ago", createdAgo: time.Hour, wantStatus: "completed", wantRuns: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var handled atomic.Int64
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				handled.Add(1)
+				return nil
+			}
+
+			// Added to the store directly, SubmitJob would reject the old job
+			store := storage.NewMemoryStore[string]()
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger(),
+				scheduler.WithMaxJobAge[string](24*time.Hour))
+			job := s.NewJob(time.Now(), "reminder")
+			job.CreatedAt = time.Now().Add(-tt.createdAgo)
+			if err := store.AddJob(job); err != nil {
+				t.Fatal(err)
+			}
+			s.Run(ctx)
+
+			got := waitForStatus(t, store, job.Id, tt.wantStatus, 5*time.Second)
+			if handled.Load() != tt.wantRuns {
+				t.Fatalf("handler ran %d times for a %s job, want %d", handled.Load(), got.Status, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestSubmitJobPastMaxAge(t *testing.T) {
+	tests := []struct {
+		name    string
+		due     time.Duration // Due time relative to now
+		wantErr error
+	}{
+		{name: "due past the max age", due: 25 * time.Hour, wantErr: scheduler.ErrJobExpired},
+		{name: "due within the max age", due: 23 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore[string]()
+			s := scheduler.NewScheduler(store, 1, time.Second, time.Minute, noopHandler[string], discardLogger(),
+				scheduler.WithMaxJobAge[string](24*time.Hour))
+
+			err := s.SubmitJob(s.NewJob(time.Now().Add(tt.due), "reminder"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SubmitJob() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FetchExplanation reports which fetch predicates a job fails, as a debugging aid for operators
+// wondering why a job isn't picked up
+type FetchExplanation struct {
+	JobID     string
+	Status    string        // Current job status
+	NotDue    bool          // ProcessAfter hasn't passed yet
+	DueIn     time.Duration // Time until ProcessAfter passes, if NotDue
+	Invisible bool          // Fetched by a worker and hidden until its visibility timeout expires
+	VisibleIn time.Duration // Time until the job becomes visible again, if Invisible
+}
+
+// ExplainFetch checks job against the predicates stores use in FetchPendingJobs at now
+func ExplainFetch[T any](job *Job[T], now time.Time) FetchExplanation {
+	e := FetchExplanation{
+		JobID:  job.Id,
+		Status: job.Status,
+	}
+
+	if !job.ProcessAfter.Before(now) {
+		e.NotDue = true
+		e.DueIn = job.ProcessAfter.Sub(now)
+	}
+
+	if job.VisibleAfter != nil && !job.VisibleAfter.Before(now) {
+		e.Invisible = true
+		e.VisibleIn = job.VisibleAfter.Sub(now)
+	}
+
+	return e
+}
+
+// WrongStatus returns true if the job isn't pending, e.g. it already completed or failed
+func (e FetchExplanation) WrongStatus() bool {
+	return e.Status != "pending"
+}
+
+// Fetchable returns true if the job passes every fetch predicate
+func (e FetchExplanation) Fetchable() bool {
+	return !e.WrongStatus() && !e.NotDue && !e.Invisible
+}
+
+// Reasons lists the failed predicates in human readable form
+func (e FetchExplanation) Reasons() []string {
+	reasons := make([]string, 0)
+	if e.WrongStatus() {
+		reasons = append(reasons, fmt.Sprintf("status is %q, not pending", e.Status))
+	}
+	if e.NotDue {
+		reasons = append(reasons, fmt.Sprintf("not due for %s", e.DueIn.Round(time.Second)))
+	}
+	if e.Invisible {
+		reasons = append(reasons, fmt.Sprintf("invisible for %s", e.VisibleIn.Round(time.Second)))
+	}
+	return reasons
+}
+
+func (e FetchExplanation) String() string {
+	if e.Fetchable() {
+		return fmt.Sprintf("job %s is fetchable", e.JobID)
+	}
+	return fmt.Sprintf("job %s is not fetchable: %s", e.JobID, strings.Join(e.Reasons(), ", "))
+}
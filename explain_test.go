@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExplainFetch(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	hiddenUntil := now.Add(30 * time.Second)
+	expiredVisibility := now.Add(-time.Second)
+
+	tests := []struct {
+		name        string
+		job         Job[string]
+		wantReasons []string
+	}{
+		{
+			name: "fetchable",
+			job:  Job[string]{Id: "job-1", Status: "pending", ProcessAfter: now.Add(-time.Minute), VisibleAfter: &expiredVisibility},
+		},
+		{
+			name:        "wrong status",
+			job:         Job[string]{Id: "job-1", Status: "completed", ProcessAfter: now.Add(-time.Minute)},
+			wantReasons: []string{`status is "completed", not pending`},
+		},
+		{
+			name:        "not yet due",
+			job:         Job[string]{Id: "job-1", Status: "pending", ProcessAfter: now.Add(time.Hour)},
+			wantReasons: []string{"not due for 1h0m0s"},
+		},
+		{
+			name:        "invisible",
+			job:         Job[string]{Id: "job-1", Status: "pending", ProcessAfter: now.Add(-time.Minute), VisibleAfter: &hiddenUntil},
+			wantReasons: []string{"invisible for 30s"},
+		},
+		{
+			name:        "every predicate failing",
+			job:         Job[string]{Id: "job-1", Status: "failed", ProcessAfter: now.Add(time.Minute), VisibleAfter: &hiddenUntil},
+			wantReasons: []string{`status is "failed", not pending`, "not due for 1m0s", "invisible for 30s"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := ExplainFetch(&tt.job, now)
+
+			if got := e.Reasons(); !slices.Equal(got, tt.wantReasons) && len(got)+len(tt.wantReasons) > 0 {
+				t.Fatalf("Reasons() = %q, want %q", got, tt.wantReasons)
+			}
+			if fetchable := len(tt.wantReasons) == 0; e.Fetchable() != fetchable {
+				t.Fatalf("Fetchable() = %v, want %v (%s)", e.Fetchable(), fetchable, e)
+			}
+		})
+	}
+}
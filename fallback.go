@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// FallbackPolicy decides which jobs are written to the fallback store
+type FallbackPolicy int
+
+const (
+	// WriteFallbackOnly writes a job to the fallback store only when adding it to the primary
+	// store fails
+	WriteFallbackOnly FallbackPolicy = iota
+	// WriteThrough also keeps a copy of every job added to the primary store in the fallback
+	// store, with status "synced" so it is never migrated back
+	WriteThrough
+)
+
+func (p FallbackPolicy) String() string {
+	switch p {
+	case WriteThrough:
+		return "write-through"
+	default:
+		return "write-fallback-only"
+	}
+}
+
+// fallbackSynced is the status of fallback jobs that are also in the primary store
+const fallbackSynced = "synced"
+
+// addWithFallback adds the job with add and writes it to the fallback store according to the
+// fallback policy, a job the fallback store took isn't reported as failed
+func (s *Scheduler[T]) addWithFallback(job *Job[T], add func() error) error {
+	err := add()
+	if s.fallback == nil || errors.Is(err, ErrDuplicateJob) {
+		return err
+	}
+
+	if err == nil {
+		if s.fallbackPolicy == WriteThrough {
+			synced := *job
+			synced.Status = fallbackSynced
+			if err := s.fallback.AddJob(&synced); err != nil {
+				s.log.Warn("failed to copy job to fallback store", "job-id", job.Id, "error", err)
+			}
+		}
+		return nil
+	}
+
+	s.log.Warn("failed to add job to store, adding it to fallback store", "job-id", job.Id, "error", err)
+	if fallbackErr := s.fallback.AddJob(job); fallbackErr != nil {
+		return errors.Join(err, fmt.Errorf("fallback store: %w", fallbackErr))
+	}
+	return nil
+}
+
+// MigrateFromFallback adds the pending jobs of the fallback store to the primary store and marks
+// them "synced" in the fallback store, so they are migrated only once. Run calls it on startup;
+// call it again once the primary store has recovered. The fallback store must implement
+// JobIterator. Jobs that couldn't be migrated stay pending in the fallback store and their errors
+// are returned.
+func (s *Scheduler[T]) MigrateFromFallback(ctx context.Context) error {
+	if s.fallback == nil {
+		return nil
+	}
+
+	iterator, ok := s.fallback.(JobIterator[T])
+	if !ok {
+		return &SchedulerError{Op: "migrate from fallback", Cause: fmt.Errorf("iterate jobs: %w", ErrNotSupported)}
+	}
+
+	// Collect first, stores may not allow updates while iterating
+	var pending []*Job[T]
+	err := iterator.IterateJobs(ctx, func(job *Job[T]) bool {
+		if job.Status == "pending" {
+			migrated := *job
+			pending = append(pending, &migrated)
+		}
+		return true
+	})
+	if err != nil {
+		return &SchedulerError{Op: "migrate from fallback", Cause: err}
+	}
+
+	var errs []error
+	migrated := 0
+	for _, job := range pending {
+		if err := s.store.AddJob(job); err != nil {
+			errs = append(errs, &SchedulerError{Op: "migrate from fallback", JobID: job.Id, Cause: err})
+			continue
+		}
+		migrated++
+
+		// A job left pending here is migrated again, adding a duplicate to the primary store
+		job.Status = fallbackSynced
+		if err := s.fallback.UpdateJob(job); err != nil {
+			errs = append(errs, &SchedulerError{Op: "mark job synced in fallback", JobID: job.Id, Cause: err})
+		}
+	}
+
+	if migrated > 0 {
+		s.log.Info("migrated jobs from fallback store", "migrated-jobs", migrated)
+	}
+
+	return errors.Join(errs...)
+}
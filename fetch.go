@@ -0,0 +1,107 @@
+package scheduler
+
+import "context"
+
+// FetchStrategy decides how many jobs the scheduler fetches on each pass of its fetch loop
+// It is only called from the fetch loop, so implementations don't need to be safe for concurrent use.
+type FetchStrategy interface {
+	// ShouldFetch returns how many jobs to fetch given the number of jobs queued for workers,
+	// the number of jobs workers can take at once (worker count times batch size for batch
+	// handlers) and the number of jobs being processed. Zero skips fetching for an interval.
+	ShouldFetch(queueDepth, workerCount, activeJobs int) (fetchCount int)
+}
+
+// demandDriven fills the dispatch queue up to one job per worker slot
+type demandDriven struct{}
+
+// DemandDriven fetches as many jobs as there are free worker slots in the queue, the default
+func DemandDriven() FetchStrategy {
+	return demandDriven{}
+}
+
+func (demandDriven) ShouldFetch(queueDepth, workerCount, activeJobs int) int {
+	return max(0, workerCount-queueDepth)
+}
+
+// prefetchFixed keeps n jobs queued beyond what idle workers can take
+type prefetchFixed struct {
+	n int
+}
+
+// PrefetchFixed keeps n jobs queued ahead of the workers, so a worker finishing a job finds the
+// next one waiting instead of waiting for a fetch. Prefetched jobs are invisible while queued,
+// keep n small enough for them to start well within the visibility timeout.
+func PrefetchFixed(n int) FetchStrategy {
+	return prefetchFixed{n: max(n, 0)}
+}
+
+func (p prefetchFixed) ShouldFetch(queueDepth, workerCount, activeJobs int) int {
+	return max(0, workerCount-activeJobs+p.n-queueDepth)
+}
+
+// adaptiveGrowAfter is how many consecutive passes finding the queue full make PrefetchAdaptive
+// double its depth
+const adaptiveGrowAfter = 3
+
+// prefetchAdaptive grows how far ahead it prefetches while workers can't keep up with the queue
+type prefetchAdaptive struct {
+	depth, max int
+	full       int // Consecutive passes that found the queue full
+}
+
+// PrefetchAdaptive prefetches like PrefetchFixed but sizes the prefetch itself: the depth starts
+// at one job and doubles, up to maxDepth, whenever the queue has been found full for several
+// passes in a row. It drops back to one job once a pass finds the queue empty and a worker idle.
+func PrefetchAdaptive(maxDepth int) FetchStrategy {
+	maxDepth = max(maxDepth, 0)
+	return &prefetchAdaptive{depth: min(1, maxDepth), max: maxDepth}
+}
+
+func (p *prefetchAdaptive) ShouldFetch(queueDepth, workerCount, activeJobs int) int {
+	if queueDepth >= workerCount {
+		if p.full++; p.full >= adaptiveGrowAfter {
+			p.depth = min(max(p.depth*2, 1), p.max)
+			p.full = 0
+		}
+	} else {
+		p.full = 0
+		if queueDepth == 0 && activeJobs < workerCount {
+			p.depth = min(1, p.max)
+		}
+	}
+
+	return max(0, workerCount-activeJobs+p.depth-queueDepth)
+}
+
+// FetchLimiter bounds how many fetches run against a store at once across every scheduler using
+// it, protecting a store shared by many schedulers from a burst of fetch queries, e.g. when they
+// all recover at the same time. A nil FetchLimiter doesn't limit fetches.
+type FetchLimiter struct {
+	slots chan struct{}
+}
+
+// NewFetchLimiter creates a limiter allowing at most maxConcurrentFetches fetches at once
+func NewFetchLimiter(maxConcurrentFetches int) *FetchLimiter {
+	return &FetchLimiter{slots: make(chan struct{}, max(maxConcurrentFetches, 1))}
+}
+
+// acquire waits for a fetch slot, returning false if ctx is done first
+func (l *FetchLimiter) acquire(ctx context.Context) bool {
+	if l == nil {
+		return true
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release gives back a slot taken by acquire
+func (l *FetchLimiter) release() {
+	if l != nil {
+		<-l.slots
+	}
+}
@@ -0,0 +1,67 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestFetchLimiter(t *testing.T) {
+	const fetchers = 8
+
+	tests := []struct {
+		name  string
+		limit int // No limiter when 0
+	}{
+		{name: "single fetch at a time", limit: 1},
+		{name: "low cap", limit: 3},
+		{name: "no limiter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(context.Background())
+
+			// Every fetch takes a while, so fetches from different schedulers overlap
+			var running, peak atomic.Int64
+			store := &hookStore[string]{MemoryStore: storage.NewMemoryStore[string]()}
+			store.onFetch = func() error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+				time.Sleep(5 * time.Millisecond)
+				return nil
+			}
+
+			var opts []scheduler.Option[string]
+			if tt.limit > 0 {
+				opts = append(opts, scheduler.WithFetchLimiter[string](scheduler.NewFetchLimiter(tt.limit)))
+			}
+			var stopped []<-chan error
+			for range fetchers {
+				s := scheduler.NewScheduler(store, 1, time.Millisecond, time.Minute, noopHandler[string], discardLogger(), opts...)
+				stopped = append(stopped, s.Run(ctx))
+			}
+			time.Sleep(200 * time.Millisecond)
+			cancel()
+			for _, errs := range stopped {
+				<-errs
+			}
+
+			// The fetchers keep the limiter saturated without ever going past it
+			got := int(peak.Load())
+			if tt.limit > 0 && got != tt.limit {
+				t.Fatalf("at most %d fetches ran at once, want %d", got, tt.limit)
+			}
+			if tt.limit == 0 && got <= 3 {
+				t.Fatalf("at most %d fetches ran at once without a limiter, want more than 3", got)
+			}
+		})
+	}
+}
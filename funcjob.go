@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// funcIDKey is the metadata key holding the id of the func run by a job scheduled with ScheduleFunc
+const funcIDKey = "func-id"
+
+// ScheduleFunc submits a job that runs fn at or after at instead of the job handler, and returns
+// the job's id. The job carries a zero payload and the id of fn, which is kept in this scheduler
+// instance only: closures can't be saved to a database, so use it with the memory store. A job
+// whose func isn't registered, e.g. one fetched after a restart or by another instance, fails
+// with ErrNoHandler. fn is retried, paused and expired like any other job.
+func (s *Scheduler[T]) ScheduleFunc(at time.Time, fn func(ctx context.Context) error) (string, error) {
+	var payload T
+	job := s.NewJob(at, payload)
+	setMetadata(job, funcIDKey, job.Id)
+
+	s.funcsMu.Lock()
+	s.funcs[job.Id] = fn
+	s.funcsMu.Unlock()
+
+	if err := s.SubmitJob(job); err != nil {
+		s.funcsMu.Lock()
+		delete(s.funcs, job.Id)
+		s.funcsMu.Unlock()
+		return "", err
+	}
+
+	return job.Id, nil
+}
+
+// routeFuncs returns a handler running the func of jobs scheduled with ScheduleFunc and passing
+// other jobs to next
+func (s *Scheduler[T]) routeFuncs(next JobHandler[T]) JobHandler[T] {
+	return func(ctx context.Context, job Job[T]) error {
+		funcID, ok := job.Metadata[funcIDKey]
+		if !ok {
+			return next(ctx, job)
+		}
+
+		s.funcsMu.Lock()
+		fn, ok := s.funcs[funcID]
+		s.funcsMu.Unlock()
+
+		if !ok {
+			return fmt.Errorf("%w: func %s", ErrNoHandler, funcID)
+		}
+
+		return fn(ctx)
+	}
+}
+
+// funcRunFinished forgets the func of a job scheduled with ScheduleFunc once the job won't run again
+func (s *Scheduler[T]) funcRunFinished(job *Job[T]) {
+	if job.Status == "pending" {
+		return
+	}
+
+	s.funcsMu.Lock()
+	defer s.funcsMu.Unlock()
+
+	delete(s.funcs, job.Metadata[funcIDKey])
+}
@@ -0,0 +1,66 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestScheduleFunc(t *testing.T) {
+	tests := []struct {
+		name       string
+		delay      time.Duration
+		fnErr      error
+		wantStatus string
+	}{
+		{name: "runs at its time", delay: 200 * time.Millisecond, wantStatus: "completed"},
+		{name: "due right away", wantStatus: "completed"},
+		{name: "failing func", delay: 100 * time.Millisecond, fnErr: errors.New("boom"), wantStatus: "failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Jobs without a func go to the handler, which shouldn't see the func's job
+			var handled atomic.Int64
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				handled.Add(1)
+				return nil
+			}
+			store := storage.NewMemoryStore[string]()
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger())
+			s.Run(ctx)
+
+			ran := make(chan time.Time, 1)
+			at := time.Now().Add(tt.delay)
+			id, err := s.ScheduleFunc(at, func(ctx context.Context) error {
+				ran <- time.Now()
+				return tt.fnErr
+			})
+			if err != nil {
+				t.Fatalf("ScheduleFunc() error = %v", err)
+			}
+
+			select {
+			case ranAt := <-ran:
+				if ranAt.Before(at) || ranAt.After(at.Add(200*time.Millisecond)) {
+					t.Fatalf("func ran %s after its time, want within 200ms", ranAt.Sub(at))
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("func didn't run")
+			}
+			waitForStatus(t, store, id, tt.wantStatus, 5*time.Second)
+			if handled.Load() != 0 {
+				t.Fatalf("handler ran %d times, want the func's job routed to the func", handled.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package scheduler_test
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+// discardLogger returns a logger dropping all records, keeping test output readable
+func discardLogger() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// waitForStatus polls store until the job with the given id has status, failing the test after timeout
+func waitForStatus[T any](t *testing.T, store *storage.MemoryStore[T], id, status string, timeout time.Duration) *scheduler.Job[T] {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		job, ok := store.GetJobs()[id]
+		if ok && job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			if !ok {
+				t.Fatalf("job %s not found after %s", id, timeout)
+			}
+			t.Fatalf("job %s has status %q after %s, want %q", id, job.Status, timeout, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// hookStore wraps a MemoryStore, calling the hooks that are set before the store's own methods
+// and failing the call if the hook returns an error
+type hookStore[T any] struct {
+	*storage.MemoryStore[T]
+	onFetch  func() error
+	onUpdate func(job *scheduler.Job[T]) error
+}
+
+func (s *hookStore[T]) FetchPendingJobs(after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	if s.onFetch != nil {
+		if err := s.onFetch(); err != nil {
+			return nil, err
+		}
+	}
+	return s.MemoryStore.FetchPendingJobs(after, limit, visibilityTimeout)
+}
+
+func (s *hookStore[T]) UpdateJob(job *scheduler.Job[T]) error {
+	if s.onUpdate != nil {
+		if err := s.onUpdate(job); err != nil {
+			return err
+		}
+	}
+	return s.MemoryStore.UpdateJob(job)
+}
@@ -0,0 +1,102 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func noopBatchHandler(ctx context.Context, jobs []scheduler.Job[string]) []error {
+	return make([]error, len(jobs))
+}
+
+func TestWorkerHooks(t *testing.T) {
+	tests := []struct {
+		name       string
+		workers    int
+		opts       []scheduler.Option[string]
+		failStart  map[int]bool // Workers whose start hook fails
+		wantStarts map[int]int
+		wantStops  map[int]int
+	}{
+		{
+			name:       "every worker",
+			workers:    3,
+			wantStarts: map[int]int{0: 1, 1: 1, 2: 1},
+			wantStops:  map[int]int{0: 1, 1: 1, 2: 1},
+		},
+		{
+			name:       "failed start isn't stopped",
+			workers:    3,
+			failStart:  map[int]bool{1: true},
+			wantStarts: map[int]int{0: 1, 1: 1, 2: 1},
+			wantStops:  map[int]int{0: 1, 2: 1},
+		},
+		{
+			name:       "batch workers",
+			workers:    2,
+			opts:       []scheduler.Option[string]{scheduler.WithBatchHandler[string](noopBatchHandler, 2, 10*time.Millisecond)},
+			wantStarts: map[int]int{0: 1, 1: 1},
+			wantStops:  map[int]int{0: 1, 1: 1},
+		},
+		{
+			name:       "inline dispatch",
+			workers:    1,
+			opts:       []scheduler.Option[string]{scheduler.WithInlineDispatch[string]()},
+			wantStarts: map[int]int{0: 1},
+			wantStops:  map[int]int{0: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var mu sync.Mutex
+			starts, stops := map[int]int{}, map[int]int{}
+			opts := append([]scheduler.Option[string]{
+				scheduler.WithOnWorkerStart[string](func(ctx context.Context, workerID int) error {
+					mu.Lock()
+					defer mu.Unlock()
+					starts[workerID]++
+					if tt.failStart[workerID] {
+						return errors.New("no connection available")
+					}
+					return nil
+				}),
+				scheduler.WithOnWorkerStop[string](func(workerID int) {
+					mu.Lock()
+					defer mu.Unlock()
+					stops[workerID]++
+				}),
+			}, tt.opts...)
+
+			store := storage.NewMemoryStore[string]()
+			s := scheduler.NewScheduler(store, tt.workers, 10*time.Millisecond, time.Minute, noopHandler[string], discardLogger(), opts...)
+			job := s.NewJob(time.Now(), "work")
+			if err := s.SubmitJob(job); err != nil {
+				t.Fatal(err)
+			}
+			done := s.Run(ctx)
+
+			waitForStatus(t, store, job.Id, "completed", 5*time.Second)
+			cancel()
+			if err := <-done; err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !maps.Equal(starts, tt.wantStarts) || !maps.Equal(stops, tt.wantStops) {
+				t.Fatalf("starts = %v, stops = %v, want %v and %v", starts, stops, tt.wantStarts, tt.wantStops)
+			}
+		})
+	}
+}
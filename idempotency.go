@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ProcessedSet records the keys of jobs that were processed successfully
+type ProcessedSet interface {
+	// Contains reports whether key was recorded and hasn't expired
+	Contains(key string) bool
+
+	// Add records key as processed
+	Add(key string)
+}
+
+// MemoryProcessedSet is an in-process ProcessedSet whose keys expire after a ttl, once it holds
+// maxSize keys the oldest are evicted first
+type MemoryProcessedSet struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxSize int
+	keys    map[string]*list.Element
+	order   *list.List // Keys in insertion order, oldest first
+}
+
+type processedKey struct {
+	key       string
+	expiresAt time.Time
+}
+
+// NewMemoryProcessedSet creates a processed set keeping keys for ttl and at most maxSize keys
+func NewMemoryProcessedSet(ttl time.Duration, maxSize int) *MemoryProcessedSet {
+	return &MemoryProcessedSet{
+		ttl:     ttl,
+		maxSize: max(maxSize, 1),
+		keys:    make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Contains reports whether key was recorded and hasn't expired
+func (s *MemoryProcessedSet) Contains(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireLocked(time.Now())
+	_, ok := s.keys[key]
+	return ok
+}
+
+// Add records key as processed, evicting the oldest keys if the set is full
+func (s *MemoryProcessedSet) Add(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if element, ok := s.keys[key]; ok {
+		s.order.Remove(element)
+	}
+	s.keys[key] = s.order.PushBack(processedKey{key: key, expiresAt: now.Add(s.ttl)})
+
+	s.expireLocked(now)
+	for s.order.Len() > s.maxSize {
+		s.removeLocked(s.order.Front())
+	}
+}
+
+// expireLocked drops expired keys, which are always at the front as every key has the same ttl
+func (s *MemoryProcessedSet) expireLocked(now time.Time) {
+	for element := s.order.Front(); element != nil && !now.Before(element.Value.(processedKey).expiresAt); element = s.order.Front() {
+		s.removeLocked(element)
+	}
+}
+
+func (s *MemoryProcessedSet) removeLocked(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.keys, element.Value.(processedKey).key)
+}
+
+// IdempotencyMiddleware skips the handler for jobs whose key is already in set, so a job
+// redelivered after its worker crashed past the handler is completed without running again.
+// keyFn derives the key, nil uses the job id, suffixed with the remaining runs for repeating
+// jobs so every run is processed once. Keys are only recorded when the handler succeeds.
+func IdempotencyMiddleware[T any](set ProcessedSet, keyFn func(job Job[T]) string) JobMiddleware[T] {
+	if keyFn == nil {
+		keyFn = defaultIdempotencyKey[T]
+	}
+
+	return func(next JobHandler[T]) JobHandler[T] {
+		return func(ctx context.Context, job Job[T]) error {
+			key := keyFn(job)
+			if set.Contains(key) {
+				return nil
+			}
+
+			if err := next(ctx, job); err != nil {
+				return err
+			}
+
+			set.Add(key)
+			return nil
+		}
+	}
+}
+
+func defaultIdempotencyKey[T any](job Job[T]) string {
+	if job.Interval > 0 {
+		return fmt.Sprintf("%s/%d", job.Id, job.RemainingRuns)
+	}
+	return job.Id
+}
@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	byIdempotencyKey := func(job Job[string]) string { return job.IdempotencyKey }
+
+	tests := []struct {
+		name       string
+		keyFn      func(job Job[string]) string
+		deliveries []Job[string]
+		failFirst  bool // The first handler call fails
+		wantRuns   int
+	}{
+		{
+			name:       "same job delivered twice",
+			deliveries: []Job[string]{{Id: "job-1"}, {Id: "job-1"}},
+			wantRuns:   1,
+		},
+		{
+			name:       "failed run isn't recorded",
+			deliveries: []Job[string]{{Id: "job-1"}, {Id: "job-1"}},
+			failFirst:  true,
+			wantRuns:   2,
+		},
+		{
+			name:       "different jobs",
+			deliveries: []Job[string]{{Id: "job-1"}, {Id: "job-2"}},
+			wantRuns:   2,
+		},
+		{
+			name:       "every run of a repeating job",
+			deliveries: []Job[string]{{Id: "job-1", Interval: time.Hour, RemainingRuns: 3}, {Id: "job-1", Interval: time.Hour, RemainingRuns: 2}, {Id: "job-1", Interval: time.Hour, RemainingRuns: 2}},
+			wantRuns:   2,
+		},
+		{
+			name:       "custom key across job ids",
+			keyFn:      byIdempotencyKey,
+			deliveries: []Job[string]{{Id: "job-1", IdempotencyKey: "order-7"}, {Id: "job-2", IdempotencyKey: "order-7"}},
+			wantRuns:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := 0
+			handler := func(ctx context.Context, job Job[string]) error {
+				runs++
+				if tt.failFirst && runs == 1 {
+					return errors.New("charge failed")
+				}
+				return nil
+			}
+
+			wrapped := IdempotencyMiddleware(NewMemoryProcessedSet(time.Hour, 100), tt.keyFn)(handler)
+			for _, job := range tt.deliveries {
+				wrapped(context.Background(), job)
+			}
+
+			if runs != tt.wantRuns {
+				t.Fatalf("handler ran %d times, want %d", runs, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestMemoryProcessedSet(t *testing.T) {
+	t.Run("keys expire after the ttl", func(t *testing.T) {
+		set := NewMemoryProcessedSet(50*time.Millisecond, 10)
+		set.Add("job-1")
+		if !set.Contains("job-1") {
+			t.Fatal("key missing right after Add")
+		}
+		time.Sleep(60 * time.Millisecond)
+		if set.Contains("job-1") {
+			t.Fatal("key kept past its ttl")
+		}
+	})
+
+	t.Run("oldest keys evicted past the size", func(t *testing.T) {
+		set := NewMemoryProcessedSet(time.Hour, 2)
+		for _, key := range []string{"job-1", "job-2", "job-3"} {
+			set.Add(key)
+		}
+		if set.Contains("job-1") || !set.Contains("job-2") || !set.Contains("job-3") {
+			t.Fatal("set didn't evict only the oldest key")
+		}
+	})
+}
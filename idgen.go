@@ -0,0 +1,32 @@
+package scheduler
+
+import (
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	"github.com/segmentio/ksuid"
+)
+
+// JobIDGenerator returns a new unique job id, it must be safe for concurrent use
+type JobIDGenerator func() string
+
+// UUIDv4Generator generates random UUID v4 ids, the default
+func UUIDv4Generator() JobIDGenerator {
+	return func() string {
+		return uuid.New().String()
+	}
+}
+
+// ULIDGenerator generates ULIDs, which sort lexicographically by creation time and increase
+// monotonically within the same millisecond
+func ULIDGenerator() JobIDGenerator {
+	return func() string {
+		return ulid.Make().String()
+	}
+}
+
+// KSUIDGenerator generates KSUIDs, which sort lexicographically by creation time with second precision
+func KSUIDGenerator() JobIDGenerator {
+	return func() string {
+		return ksuid.New().String()
+	}
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+func TestULIDGeneratorMonotonic(t *testing.T) {
+	gen := ULIDGenerator()
+
+	// Enough ids for many of them to share a millisecond
+	const n = 10000
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = gen()
+	}
+
+	sameMs := 0
+	for i := 1; i < n; i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("id %d %s doesn't sort after %s", i, ids[i], ids[i-1])
+		}
+		if ulid.MustParse(ids[i]).Time() == ulid.MustParse(ids[i-1]).Time() {
+			sameMs++
+		}
+	}
+	if sameMs == 0 {
+		t.Fatal("no ids were generated within the same millisecond")
+	}
+}
+
+func TestJobIDGenerators(t *testing.T) {
+	tests := []struct {
+		name    string
+		gen     JobIDGenerator
+		wantLen int
+	}{
+		{name: "uuid v4", gen: UUIDv4Generator(), wantLen: 36},
+		{name: "ulid", gen: ULIDGenerator(), wantLen: 26},
+		{name: "ksuid", gen: KSUIDGenerator(), wantLen: 27},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scheduler[string]{idGenerator: tt.gen}
+
+			// Generators are called concurrently by SubmitJob callers
+			var mu sync.Mutex
+			seen := map[string]bool{}
+			var wg sync.WaitGroup
+			for range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range 100 {
+						id := s.NewJob(time.Now(), "payload").Id
+						mu.Lock()
+						seen[id] = true
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			if len(seen) != 800 {
+				t.Fatalf("%d unique ids out of 800", len(seen))
+			}
+			for id := range seen {
+				if len(id) != tt.wantLen {
+					t.Fatalf("id %q has length %d, want %d", id, len(id), tt.wantLen)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package scheduler
 
 import (
+	"context"
+	"encoding/json"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,22 +11,99 @@ import (
 
 // Job represents a scheduled job with a typed payload
 type Job[T any] struct {
-	Id           string     `json:"id"`
-	Status       string     `json:"status"`                 // "pending" or "completed"
-	ProcessAfter time.Time  `json:"processAfter"`           // When job should be processed
-	VisibleAfter *time.Time `json:"visibleAfter,omitempty"` // When job becomes visible again (visibility timeout)
-	ProcessedAt  *time.Time `json:"processedAt,omitempty"`  // When job was completed
-	Payload      T          `json:"payload"`
+	Id             string          `json:"id"`
+	Status         string          `json:"status"`                   // "pending", "processing", "completed", "failed" or "expired"
+	Type           string          `json:"type,omitempty"`           // Groups jobs for per-type pausing and failure tracking
+	CreatedAt      time.Time       `json:"createdAt"`                // When job was created
+	ProcessAfter   time.Time       `json:"processAfter"`             // When job should be processed
+	VisibleAfter   *time.Time      `json:"visibleAfter,omitempty"`   // When job becomes visible again (visibility timeout)
+	ProcessedAt    *time.Time      `json:"processedAt,omitempty"`    // When job was completed
+	CallbackURL    string          `json:"callbackUrl,omitempty"`    // Receives a POST with the job result once it completes or fails
+	IdempotencyKey string          `json:"idempotencyKey,omitempty"` // Caller-chosen key to cancel the job by without knowing its id
+	TenantID       string          `json:"tenantId,omitempty"`       // Set by tenant-scoped stores
+	Attempts       int             `json:"attempts"`                 // Number of times the job was dispatched to a handler
+	ProcessedBy    string          `json:"processedBy,omitempty"`    // Instance id of the scheduler that last dispatched the job
+	Priority       int             `json:"priority"`                 // Higher priority jobs are fetched first
+	Interval       time.Duration   `json:"interval,omitempty"`       // Time between runs of a repeating job
+	RemainingRuns  int             `json:"remainingRuns,omitempty"`  // Runs left for a repeating job, including the current one
+	Sequence       int64           `json:"sequence,omitempty"`       // Assigned by the store on insert, breaks ProcessAfter ties in FIFO order
+	Checkpoint     json.RawMessage `json:"checkpoint,omitempty"`     // Progress saved by the handler with SaveCheckpoint
+	Version        int64           `json:"version,omitempty"`        // Bumped on every update by stores with optimistic locking
+	TerminalReason TerminalReason  `json:"terminalReason,omitempty"` // Why a failed job ended, empty otherwise
+	SealedPayload  []byte          `json:"sealedPayload,omitempty"`  // Payload encrypted by the scheduler's SecretManager, set instead of Payload
+	Metadata       Metadata        `json:"metadata,omitempty"`       // Context values carried from submission to the handler by context propagators
+	Payload        T               `json:"payload"`
 }
 
-func NewJob[T any](processAfter time.Time, payload T) *Job[T] {
-	id := uuid.New().String()
-	return &Job[T]{
+// JobOption sets optional job fields at creation
+type JobOption[T any] func(*Job[T])
+
+// WithJobPriority sets the job priority, higher priority jobs are fetched first
+func WithJobPriority[T any](priority int) JobOption[T] {
+	return func(j *Job[T]) {
+		j.Priority = priority
+	}
+}
+
+// WithJobType sets the job type, jobs of a type can be paused and resumed together
+func WithJobType[T any](jobType string) JobOption[T] {
+	return func(j *Job[T]) {
+		j.Type = jobType
+	}
+}
+
+// WithJobCallbackURL sets the URL that receives the job result once it completes or fails
+func WithJobCallbackURL[T any](url string) JobOption[T] {
+	return func(j *Job[T]) {
+		j.CallbackURL = url
+	}
+}
+
+// WithJobIdempotencyKey sets a key of the caller's choosing, e.g. "reminder:order-42", that
+// CancelByKey can cancel the job by. Keys don't have to be unique.
+func WithJobIdempotencyKey[T any](key string) JobOption[T] {
+	return func(j *Job[T]) {
+		j.IdempotencyKey = key
+	}
+}
+
+// WithJobRepeat makes the job run runs times in total, interval apart. Each successful run
+// reschedules the job until the runs are used up, a failed run ends the repetition.
+func WithJobRepeat[T any](interval time.Duration, runs int) JobOption[T] {
+	return func(j *Job[T]) {
+		j.Interval = interval
+		j.RemainingRuns = runs
+	}
+}
+
+// WithJobVisibleAfter holds the job invisible until visibleAfter without changing when it is due,
+// e.g. for a cool-down after creation. The job isn't fetched before either time has passed and
+// counts as invisible until then.
+func WithJobVisibleAfter[T any](visibleAfter time.Time) JobOption[T] {
+	return func(j *Job[T]) {
+		j.VisibleAfter = &visibleAfter
+	}
+}
+
+// NewJob creates a pending job with a random UUID v4 id
+func NewJob[T any](processAfter time.Time, payload T, opts ...JobOption[T]) *Job[T] {
+	return newJob(uuid.New().String(), processAfter, payload, opts...)
+}
+
+func newJob[T any](id string, processAfter time.Time, payload T, opts ...JobOption[T]) *Job[T] {
+	job := &Job[T]{
 		Id:           id,
 		Status:       "pending",
+		CreatedAt:    time.Now(),
 		ProcessAfter: processAfter,
 		Payload:      payload,
 	}
+
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	return job
 }
 
 // IsVisible returns true if the job is currently visible (can be picked up by workers)
@@ -48,29 +128,195 @@ func (j *Job[T]) MakeVisible() {
 	j.VisibleAfter = nil
 }
 
-// MakeFailed marks the job as failed and makes it visible again
+// MakeProcessing marks the job as handed to a handler so it is no longer fetched, even once its
+// visibility timeout lapses
+func (j *Job[T]) MakeProcessing() {
+	j.Status = "processing"
+}
+
+// MakeFailed marks the job as failed and makes it visible again, see MakeFailedWithReason
 func (j *Job[T]) MakeFailed() {
 	j.Status = "failed"
 	j.MakeVisible()
 }
 
+// MakeFailedWithReason marks the job as failed for reason and makes it visible again
+func (j *Job[T]) MakeFailedWithReason(reason TerminalReason) {
+	j.MakeFailed()
+	j.TerminalReason = reason
+}
+
+// MakeExpired marks a job that waited too long to run as expired and makes it visible again
+func (j *Job[T]) MakeExpired() {
+	j.Status = "expired"
+	j.MakeVisible()
+}
+
+// MakeRetry returns a failed job to pending so it is processed again after processAfter
+func (j *Job[T]) MakeRetry(processAfter time.Time) {
+	j.Status = "pending"
+	j.TerminalReason = ""
+	j.ProcessAfter = processAfter
+	j.MakeVisible()
+}
+
 // MakeCompleted marks the job as completed and makes it visible again
 func (j *Job[T]) MakeCompleted() {
 	j.Status = "completed"
+	j.TerminalReason = ""
 	now := time.Now()
 	j.ProcessedAt = &now
 	j.MakeVisible()
 }
 
+// Repeats returns true if the job has runs left after the current one
+func (j *Job[T]) Repeats() bool {
+	return j.Interval > 0 && j.RemainingRuns > 1
+}
+
+// MakeRepeat returns a repeating job to pending for its next run, skipping the given number of
+// missed runs. The attempt count and checkpoint are reset so every run starts afresh.
+func (j *Job[T]) MakeRepeat(skipped int) {
+	j.Status = "pending"
+	j.RemainingRuns -= 1 + skipped
+	j.ProcessAfter = j.ProcessAfter.Add(time.Duration(1+skipped) * j.Interval)
+	j.Attempts = 0
+	j.Checkpoint = nil
+	j.MakeVisible()
+}
+
+// EffectivePriority returns the job priority raised by one for every agingInterval the job has
+// waited past its ProcessAfter, so long-waiting low priority jobs eventually outrank fresh ones
+func (j *Job[T]) EffectivePriority(now time.Time, agingInterval time.Duration) int {
+	if agingInterval <= 0 || !now.After(j.ProcessAfter) {
+		return j.Priority
+	}
+	return j.Priority + int(now.Sub(j.ProcessAfter)/agingInterval)
+}
+
 // JobStore defines the interface for job persistence
 type JobStore[T any] interface {
 	// FetchPendingJobs retrieves pending jobs that are ready to be processed
 	// Jobs returned will have their visibility timeout set
 	FetchPendingJobs(after time.Time, limit int, visibilityTimeout time.Duration) ([]*Job[T], error)
 
-	// UpdateJob updates an existing job's status and processing timestamp
+	// UpdateJob updates an existing job's status, schedule, attempts, processing instance and processing timestamp
+	// Stores with optimistic locking return ErrConcurrentModification if job.Version is stale
 	UpdateJob(job *Job[T]) error
 
 	// AddJob adds a new job to the store
 	AddJob(job *Job[T]) error
 }
+
+// JobIterator is implemented by stores that can stream jobs without loading them all into memory
+type JobIterator[T any] interface {
+	// IterateJobs calls fn for each job in the store until fn returns false
+	IterateJobs(ctx context.Context, fn func(*Job[T]) bool) error
+}
+
+// DedupStore is implemented by stores that can reject duplicate jobs within a time window
+type DedupStore[T any] interface {
+	// AddUniqueJob adds the job unless a job with the same dedup key was added within window,
+	// in which case ErrDuplicateJob is returned
+	AddUniqueJob(job *Job[T], key string, window time.Duration) error
+}
+
+// StatusTransitioner is implemented by stores that can change a job status atomically
+type StatusTransitioner interface {
+	// TransitionStatus sets the job status to `to` only if it is currently `from`,
+	// returning whether the status was changed. A job being processed, i.e. marked processing
+	// or pending with a visibility timeout that hasn't lapsed, isn't transitioned, as its
+	// worker owns the status until it saves the result.
+	TransitionStatus(id, from, to string) (bool, error)
+}
+
+// LeaderLocker is implemented by stores that can hold a lease shared by all scheduler instances,
+// so that only one instance schedules at a time. Each store instance acts as its own lock owner.
+type LeaderLocker interface {
+	// TryAcquireLock takes the lock for ttl if it is free or expired, returning whether it is held
+	TryAcquireLock(ctx context.Context, ttl time.Duration) (bool, error)
+
+	// RenewLock extends a held lock by ttl, returning false if the lock was lost to another owner
+	RenewLock(ctx context.Context, ttl time.Duration) (bool, error)
+
+	// ReleaseLock gives up the lock if it is held, letting another instance take over immediately
+	ReleaseLock(ctx context.Context) error
+}
+
+// FetchExplainer is implemented by stores that can report why a job isn't being fetched
+type FetchExplainer interface {
+	// ExplainFetch loads the job and reports which fetch predicates it fails right now
+	ExplainFetch(id string) (FetchExplanation, error)
+}
+
+// InvisibleCounter is implemented by stores that can count jobs hidden by a visibility timeout
+type InvisibleCounter interface {
+	// CountInvisible returns the number of pending jobs whose visibility timeout hasn't expired,
+	// i.e. jobs being processed or left behind by a crashed worker
+	CountInvisible() (int, error)
+}
+
+// LeaseRecoverer is implemented by stores that can make jobs with a lapsed visibility timeout
+// visible again using the database's clock rather than the caller's
+type LeaseRecoverer interface {
+	// RecoverExpiredLeases clears the visibility timeout of pending jobs whose timeout has lapsed
+	// by the database's clock, returning the number of jobs recovered
+	RecoverExpiredLeases() (int, error)
+}
+
+// CompletedFetcher is implemented by stores that can list completed jobs for reporting
+type CompletedFetcher[T any] interface {
+	// FetchCompleted returns up to limit jobs (0 for no limit) completed within [from, to),
+	// ordered by completion time
+	FetchCompleted(from, to time.Time, limit int) ([]*Job[T], error)
+}
+
+// TypeFilter restricts a fetch to jobs of the listed types, or with Exclude to jobs of any other
+// type, including untyped jobs
+type TypeFilter struct {
+	Types   []string
+	Exclude bool
+}
+
+// Matches reports whether a job of jobType passes the filter
+func (f TypeFilter) Matches(jobType string) bool {
+	return slices.Contains(f.Types, jobType) != f.Exclude
+}
+
+// TypeFetcher is implemented by stores that can fetch jobs of some types only, as needed by
+// per-type worker pools
+type TypeFetcher[T any] interface {
+	// FetchPendingJobsByType is FetchPendingJobs restricted to jobs whose Type passes filter
+	FetchPendingJobsByType(filter TypeFilter, after time.Time, limit int, visibilityTimeout time.Duration) ([]*Job[T], error)
+}
+
+// JobClaimer is implemented by stores that can claim jobs one at a time, for consumers that need
+// strict ordering
+type JobClaimer[T any] interface {
+	// ClaimNext atomically makes the next due, visible pending job in fetch order, highest priority
+	// first then earliest due, invisible for visibilityTimeout and returns it, or false if no job
+	// is due. Concurrent claimers never get the same job.
+	ClaimNext(visibilityTimeout time.Duration) (*Job[T], bool, error)
+}
+
+// KeyCanceller is implemented by stores that can cancel jobs by idempotency key
+type KeyCanceller interface {
+	// CancelByKey deletes the pending jobs with the idempotency key that aren't being processed,
+	// returning how many were deleted
+	CancelByKey(key string) (int, error)
+}
+
+// ScheduleShifter is implemented by stores that can move pending jobs due in a time window, e.g.
+// out of a planned maintenance window
+type ScheduleShifter interface {
+	// ShiftSchedule adds by to the ProcessAfter of pending jobs due within [from, to), returning
+	// the number of jobs moved
+	ShiftSchedule(from, to time.Time, by time.Duration) (int, error)
+}
+
+// TieBreaker is implemented by stores that can order pending jobs equally due and of equal
+// priority by a TieBreak other than FIFO
+type TieBreaker interface {
+	// SetTieBreak makes later fetches order ties by tb
+	SetTieBreak(tb TieBreak)
+}
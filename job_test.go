@@ -0,0 +1,32 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEffectivePriority(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		priority int
+		waited   time.Duration // How long past its ProcessAfter the job has waited
+		aging    time.Duration
+		want     int
+	}{
+		{name: "aging disabled", priority: 1, waited: time.Hour, want: 1},
+		{name: "not due yet", priority: 1, waited: -time.Hour, aging: time.Minute, want: 1},
+		{name: "less than one interval", priority: 1, waited: 59 * time.Second, aging: time.Minute, want: 1},
+		{name: "several intervals", priority: 1, waited: 10 * time.Minute, aging: time.Minute, want: 11},
+		{name: "negative priority ages too", priority: -3, waited: 5 * time.Minute, aging: time.Minute, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &Job[string]{Priority: tt.priority, ProcessAfter: now.Add(-tt.waited)}
+			if got := job.EffectivePriority(now, tt.aging); got != tt.want {
+				t.Fatalf("EffectivePriority() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"sort"
+)
+
+// OnConsecutiveFailureFn is called when count handler calls in a row for jobs of jobType have failed,
+// lastErr is the error of the latest failure
+type OnConsecutiveFailureFn func(jobType string, count int, lastErr error)
+
+// PauseType stops dispatching jobs of jobType until ResumeType is called. Paused jobs are still
+// fetched but put back for another polling interval without being attempted, so a large backlog
+// of a paused type takes fetch slots from other types.
+func (s *Scheduler[T]) PauseType(jobType string) {
+	s.typesMu.Lock()
+	defer s.typesMu.Unlock()
+
+	s.pausedTypes[jobType] = true
+	s.log.Info("job type paused", "job-type", jobType)
+}
+
+// ResumeType resumes dispatching jobs of jobType and clears its failure history
+func (s *Scheduler[T]) ResumeType(jobType string) {
+	s.typesMu.Lock()
+	defer s.typesMu.Unlock()
+
+	delete(s.pausedTypes, jobType)
+	delete(s.typeResults, jobType)
+	s.log.Info("job type resumed", "job-type", jobType)
+}
+
+// TypePaused reports whether jobs of jobType are paused
+func (s *Scheduler[T]) TypePaused(jobType string) bool {
+	s.typesMu.Lock()
+	defer s.typesMu.Unlock()
+
+	return s.pausedTypes[jobType]
+}
+
+// pausedTypeList returns the paused job types in order
+func (s *Scheduler[T]) pausedTypeList() []string {
+	s.typesMu.Lock()
+	defer s.typesMu.Unlock()
+
+	types := make([]string, 0, len(s.pausedTypes))
+	for jobType := range s.pausedTypes {
+		types = append(types, jobType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// deferPausedJob puts a fetched job of a paused type back for another polling interval
+func (s *Scheduler[T]) deferPausedJob(ctx context.Context, job *Job[T]) {
+	job.MakeInvisible(s.pollInterval())
+	s.updateJob(ctx, job, "defer job of paused type")
+}
+
+// recordTypeResult tracks the handler result of a typed job and pauses the type once its failure
+// rate over the last autoPauseWindow results exceeds autoPauseRate
+func (s *Scheduler[T]) recordTypeResult(jobType string, err error) {
+	if s.autoPauseWindow <= 0 || jobType == "" {
+		return
+	}
+
+	s.typesMu.Lock()
+	results := append(s.typeResults[jobType], err != nil)
+	if len(results) > s.autoPauseWindow {
+		results = results[1:]
+	}
+	s.typeResults[jobType] = results
+
+	if len(results) < s.autoPauseWindow || s.pausedTypes[jobType] {
+		s.typesMu.Unlock()
+		return
+	}
+
+	failures := 0
+	for _, failed := range results {
+		if failed {
+			failures++
+		}
+	}
+	rate := float64(failures) / float64(len(results))
+	if rate <= s.autoPauseRate {
+		s.typesMu.Unlock()
+		return
+	}
+
+	s.pausedTypes[jobType] = true
+	delete(s.typeResults, jobType)
+	s.typesMu.Unlock()
+
+	s.log.Warn("job type failure rate exceeded threshold, pausing type", "job-type", jobType, "failure-rate", rate, "threshold", s.autoPauseRate)
+	if s.onTypePaused != nil {
+		s.onTypePaused(jobType, rate)
+	}
+}
+
+// recordConsecutiveFailure counts handler failures in a row per job type, untyped jobs share the
+// empty type, and calls the consecutive failure hook once the count reaches the threshold
+func (s *Scheduler[T]) recordConsecutiveFailure(jobType string, err error) {
+	if s.maxFailureStreak <= 0 || s.onFailureStreak == nil {
+		return
+	}
+
+	s.typesMu.Lock()
+	if err == nil {
+		delete(s.failureStreaks, jobType)
+		s.typesMu.Unlock()
+		return
+	}
+	s.failureStreaks[jobType]++
+	count := s.failureStreaks[jobType]
+	s.typesMu.Unlock()
+
+	if count == s.maxFailureStreak {
+		s.log.Warn("job type reached max consecutive failures", "job-type", jobType, "count", count, "error", err)
+		s.onFailureStreak(jobType, count, err)
+	}
+}
@@ -0,0 +1,164 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestAutoPauseTypeOnFailureRate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The webhook downstream is broken until it is fixed, emails keep working
+	var fixed atomic.Bool
+	var webhookFailures atomic.Int32
+	handler := func(ctx context.Context, job scheduler.Job[int]) error {
+		if job.Type == "webhook" && !fixed.Load() {
+			webhookFailures.Add(1)
+			return errors.New("downstream returned 503")
+		}
+		return nil
+	}
+
+	var mu sync.Mutex
+	paused := map[string]float64{}
+	store := storage.NewMemoryStore[int]()
+	s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger(),
+		scheduler.WithAutoPauseTypeOnFailureRate[int](0.5, 5),
+		scheduler.WithOnTypePaused[int](func(jobType string, failureRate float64) {
+			mu.Lock()
+			defer mu.Unlock()
+			paused[jobType] = failureRate
+		}),
+	)
+
+	var webhooks, emails []*scheduler.Job[int]
+	for i := range 10 {
+		webhook := s.NewJob(time.Now(), i, scheduler.WithJobType[int]("webhook"))
+		webhooks = append(webhooks, webhook)
+		email := s.NewJob(time.Now(), i, scheduler.WithJobType[int]("email"))
+		emails = append(emails, email)
+	}
+	for _, job := range append(webhooks, emails...) {
+		if err := s.SubmitJob(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Run(ctx)
+
+	// Emails are still processed once webhooks are paused
+	for _, job := range emails {
+		waitForStatus(t, store, job.Id, "completed", 5*time.Second)
+	}
+	if !s.TypePaused("webhook") || s.TypePaused("email") {
+		t.Fatalf("paused webhook = %v, email = %v, want only webhook paused", s.TypePaused("webhook"), s.TypePaused("email"))
+	}
+	mu.Lock()
+	if len(paused) != 1 || paused["webhook"] != 1 {
+		t.Fatalf("OnTypePaused calls = %v, want webhook at a failure rate of 1", paused)
+	}
+	mu.Unlock()
+
+	// A job already queued when the type paused may still run, the rest wait
+	failures := webhookFailures.Load()
+	if failures < 5 || failures > 6 {
+		t.Fatalf("%d webhook jobs failed before the pause, want the window of 5", failures)
+	}
+
+	fixed.Store(true)
+	s.ResumeType("webhook")
+	for _, job := range webhooks[failures:] {
+		waitForStatus(t, store, job.Id, "completed", 5*time.Second)
+	}
+}
+
+func TestMaxConsecutiveFailures(t *testing.T) {
+	type run struct {
+		jobType string
+		fail    bool
+	}
+	repeat := func(n int, r run) []run {
+		runs := make([]run, n)
+		for i := range runs {
+			runs[i] = r
+		}
+		return runs
+	}
+	emailFails := run{jobType: "email", fail: true}
+
+	tests := []struct {
+		name      string
+		runs      []run
+		wantCalls []int // Counts fn is called with, all for email
+	}{
+		{name: "15 failures in a row", runs: repeat(15, emailFails), wantCalls: []int{10}},
+		{name: "9 failures in a row", runs: repeat(9, emailFails)},
+		{name: "success resets the count", runs: slices.Concat(repeat(9, emailFails), []run{{jobType: "email"}}, repeat(9, emailFails))},
+		{name: "other types don't reset the count", runs: slices.Concat(repeat(5, emailFails), []run{{jobType: "sms"}}, repeat(5, emailFails)), wantCalls: []int{10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handler := func(ctx context.Context, job scheduler.Job[int]) error {
+				if tt.runs[job.Payload].fail {
+					return fmt.Errorf("smtp unavailable (run %d)", job.Payload)
+				}
+				return nil
+			}
+
+			var mu sync.Mutex
+			var calls []int
+			var lastErr error
+			store := storage.NewMemoryStore[int]()
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger(),
+				scheduler.WithMaxConsecutiveFailures[int](10, func(jobType string, count int, err error) {
+					mu.Lock()
+					defer mu.Unlock()
+					if jobType != "email" {
+						t.Errorf("fn called for %s", jobType)
+					}
+					calls = append(calls, count)
+					lastErr = err
+				}),
+			)
+
+			var last *scheduler.Job[int]
+			for i, r := range tt.runs {
+				last = s.NewJob(time.Now(), i, scheduler.WithJobType[int](r.jobType))
+				if err := s.SubmitJob(last); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s.Run(ctx)
+
+			wantStatus := "completed"
+			if tt.runs[len(tt.runs)-1].fail {
+				wantStatus = "failed"
+			}
+			waitForStatus(t, store, last.Id, wantStatus, 5*time.Second)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Fatalf("fn called with counts %v, want %v", calls, tt.wantCalls)
+			}
+			// The tenth failure in a row triggers the call, and its error is passed on
+			if len(calls) > 0 && !strings.Contains(lastErr.Error(), "smtp unavailable") {
+				t.Fatalf("fn got error %v, want the last handler error", lastErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// leaderRenewFactor is how many times the lock is renewed per ttl, leaving room for a failed renewal
+const leaderRenewFactor = 3
+
+// leaderLock keeps track of whether this scheduler instance holds the store's leader lock
+type leaderLock struct {
+	locker LeaderLocker
+	ttl    time.Duration
+	held   atomic.Bool
+}
+
+// isLeader reports whether the lock was held at the last acquire or renew attempt
+func (l *leaderLock) isLeader() bool {
+	return l.held.Load()
+}
+
+// runLeaderLock acquires and renews the lock until ctx is done, then releases it
+func (s *Scheduler[T]) runLeaderLock(ctx context.Context, l *leaderLock) {
+	ticker := time.NewTicker(l.ttl / leaderRenewFactor)
+	defer ticker.Stop()
+
+	for {
+		var held bool
+		var err error
+		if l.isLeader() {
+			held, err = l.locker.RenewLock(ctx, l.ttl)
+		} else {
+			held, err = l.locker.TryAcquireLock(ctx, l.ttl)
+		}
+		if err != nil {
+			s.log.Error("failed to acquire or renew leader lock", "error", err)
+			held = false
+		}
+
+		if held != l.isLeader() {
+			if held {
+				s.log.Info("acquired leader lock, scheduling jobs")
+			} else {
+				s.log.Info("lost leader lock, pausing fetching")
+			}
+		}
+		l.held.Store(held)
+
+		select {
+		case <-ctx.Done():
+			if l.isLeader() {
+				l.held.Store(false)
+				if err := l.locker.ReleaseLock(context.WithoutCancel(ctx)); err != nil {
+					s.log.Error("failed to release leader lock", "error", err)
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+// sharedLease is a leader lease shared by the lockStores of several scheduler instances
+type sharedLease struct {
+	mu        sync.Mutex
+	owner     string
+	expiresAt time.Time
+}
+
+// lockStore is one instance's view of a shared MemoryStore and lease
+type lockStore[T any] struct {
+	*storage.MemoryStore[T]
+	lease *sharedLease
+	owner string
+}
+
+func (s *lockStore[T]) TryAcquireLock(ctx context.Context, ttl time.Duration) (bool, error) {
+	s.lease.mu.Lock()
+	defer s.lease.mu.Unlock()
+
+	now := time.Now()
+	if s.lease.owner != "" && s.lease.owner != s.owner && now.Before(s.lease.expiresAt) {
+		return false, nil
+	}
+	s.lease.owner, s.lease.expiresAt = s.owner, now.Add(ttl)
+	return true, nil
+}
+
+func (s *lockStore[T]) RenewLock(ctx context.Context, ttl time.Duration) (bool, error) {
+	s.lease.mu.Lock()
+	defer s.lease.mu.Unlock()
+
+	if s.lease.owner != s.owner {
+		return false, nil
+	}
+	s.lease.expiresAt = time.Now().Add(ttl)
+	return true, nil
+}
+
+func (s *lockStore[T]) ReleaseLock(ctx context.Context) error {
+	s.lease.mu.Lock()
+	defer s.lease.mu.Unlock()
+
+	if s.lease.owner == s.owner {
+		s.lease.owner = ""
+	}
+	return nil
+}
+
+func TestLeaderLock(t *testing.T) {
+	tests := []struct {
+		name       string
+		stopLeader bool // Stop the leader after its jobs, another batch is then submitted
+	}{
+		{name: "only the leader processes"},
+		{name: "follower takes over once the leader stops", stopLeader: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := storage.NewMemoryStore[int]()
+			lease := &sharedLease{}
+
+			var mu sync.Mutex
+			processedBy := map[int]string{}
+
+			instances := []string{"instance-a", "instance-b"}
+			cancels := map[string]context.CancelFunc{}
+			dones := map[string]<-chan error{}
+			var submitter *scheduler.Scheduler[int]
+			for _, name := range instances {
+				handler := func(ctx context.Context, job scheduler.Job[int]) error {
+					mu.Lock()
+					defer mu.Unlock()
+					processedBy[job.Payload] = name
+					return nil
+				}
+
+				store := &lockStore[int]{MemoryStore: memory, lease: lease, owner: name}
+				s := scheduler.NewScheduler(store, 2, 10*time.Millisecond, time.Minute, handler, discardLogger(),
+					scheduler.WithLeaderLock[int](300*time.Millisecond),
+				)
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				cancels[name], dones[name] = cancel, s.Run(ctx)
+				submitter = s
+			}
+
+			submit := func(from, to int) []*scheduler.Job[int] {
+				var jobs []*scheduler.Job[int]
+				for i := from; i < to; i++ {
+					job := submitter.NewJob(time.Now(), i)
+					if err := submitter.SubmitJob(job); err != nil {
+						t.Fatal(err)
+					}
+					jobs = append(jobs, job)
+				}
+				return jobs
+			}
+
+			for _, job := range submit(0, 10) {
+				waitForStatus(t, memory, job.Id, "completed", 5*time.Second)
+			}
+
+			mu.Lock()
+			leader := processedBy[0]
+			mu.Unlock()
+
+			if tt.stopLeader {
+				cancels[leader]()
+				if err := <-dones[leader]; err != nil {
+					t.Fatalf("leader stopped with error: %v", err)
+				}
+				for _, job := range submit(10, 20) {
+					waitForStatus(t, memory, job.Id, "completed", 5*time.Second)
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for payload, by := range processedBy {
+				want := leader
+				if payload >= 10 {
+					want = instances[0]
+					if leader == want {
+						want = instances[1]
+					}
+				}
+				if by != want {
+					t.Errorf("job %d processed by %s, want %s", payload, by, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLeaderLockNotSupported(t *testing.T) {
+	s := scheduler.NewScheduler(storage.NewMemoryStore[int](), 1, time.Second, time.Minute, noopHandler[int], discardLogger(),
+		scheduler.WithLeaderLock[int](time.Second),
+	)
+
+	select {
+	case err := <-s.Run(context.Background()):
+		if !errors.Is(err, scheduler.ErrNotSupported) {
+			t.Fatalf("Run() error = %v, want ErrNotSupported", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler kept running without a lock")
+	}
+}
@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"sort"
+	"sync"
+)
+
+// MetricsCollector receives measurements taken by the stores, e.g. to export them to Prometheus
+type MetricsCollector interface {
+	// ObservePayloadBytes records the size of a job payload as JSON, the job_payload_bytes
+	// histogram. Stores call it once for every job they add, so keep it cheap.
+	ObservePayloadBytes(jobType string, size int)
+}
+
+// DefaultPayloadBuckets are the upper bounds in bytes of the PayloadMetrics histogram buckets
+var DefaultPayloadBuckets = []int{256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20}
+
+// PayloadMetrics is a MetricsCollector keeping the job_payload_bytes histogram in memory, to spot
+// the jobs bloating storage. Pass it to the stores and to WithPayloadMetrics for Stats to report
+// the average payload size.
+type PayloadMetrics struct {
+	mu      sync.Mutex
+	buckets []int
+	counts  []uint64 // Per bucket, the last one counts payloads above every bucket
+	count   uint64
+	sum     uint64
+}
+
+// PayloadHistogram is a snapshot of the job_payload_bytes histogram
+type PayloadHistogram struct {
+	Buckets []int    // Upper bounds in bytes, ascending
+	Counts  []uint64 // Payloads per bucket, with one more entry for payloads above the last bound
+	Count   uint64   // Payloads observed
+	Sum     uint64   // Bytes observed
+}
+
+// NewPayloadMetrics creates a PayloadMetrics with the given bucket upper bounds in bytes,
+// DefaultPayloadBuckets if none are given
+func NewPayloadMetrics(buckets ...int) *PayloadMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultPayloadBuckets
+	}
+	buckets = append([]int(nil), buckets...)
+	sort.Ints(buckets)
+
+	return &PayloadMetrics{buckets: buckets, counts: make([]uint64, len(buckets)+1)}
+}
+
+func (m *PayloadMetrics) ObservePayloadBytes(jobType string, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counts[sort.SearchInts(m.buckets, size)]++
+	m.count++
+	m.sum += uint64(max(size, 0))
+}
+
+// Histogram returns a snapshot of the observed payload sizes
+func (m *PayloadMetrics) Histogram() PayloadHistogram {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return PayloadHistogram{
+		Buckets: append([]int(nil), m.buckets...),
+		Counts:  append([]uint64(nil), m.counts...),
+		Count:   m.count,
+		Sum:     m.sum,
+	}
+}
+
+// Average returns the running average payload size in bytes, 0 before any payload is observed
+func (m *PayloadMetrics) Average() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.count == 0 {
+		return 0
+	}
+	return float64(m.sum) / float64(m.count)
+}
@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPayloadMetrics(t *testing.T) {
+	tests := []struct {
+		name        string
+		buckets     []int
+		sizes       []int
+		wantCounts  []uint64
+		wantAverage float64
+	}{
+		{name: "nothing observed", buckets: []int{100, 1000}, wantCounts: []uint64{0, 0, 0}},
+		{
+			name:        "sizes spread over the buckets",
+			buckets:     []int{100, 1000},
+			sizes:       []int{10, 100, 101, 1000, 5000},
+			wantCounts:  []uint64{2, 2, 1},
+			wantAverage: 1242.2,
+		},
+		{
+			name:        "unsorted buckets",
+			buckets:     []int{1000, 100},
+			sizes:       []int{50, 500},
+			wantCounts:  []uint64{1, 1, 0},
+			wantAverage: 275,
+		},
+		{
+			name:        "default buckets",
+			sizes:       []int{200, 2 << 20},
+			wantCounts:  []uint64{1, 0, 0, 0, 0, 0, 0, 1},
+			wantAverage: (200 + 2<<20) / 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewPayloadMetrics(tt.buckets...)
+			for _, size := range tt.sizes {
+				m.ObservePayloadBytes("report", size)
+			}
+
+			h := m.Histogram()
+			if !slices.Equal(h.Counts, tt.wantCounts) {
+				t.Fatalf("histogram counts = %v over buckets %v, want %v", h.Counts, h.Buckets, tt.wantCounts)
+			}
+			if !slices.IsSorted(h.Buckets) {
+				t.Fatalf("histogram buckets %v aren't ascending", h.Buckets)
+			}
+			var sum uint64
+			for _, size := range tt.sizes {
+				sum += uint64(size)
+			}
+			if h.Count != uint64(len(tt.sizes)) || h.Sum != sum {
+				t.Fatalf("histogram count %d and sum %d, want %d and %d", h.Count, h.Sum, len(tt.sizes), sum)
+			}
+			if got := m.Average(); got != tt.wantAverage {
+				t.Fatalf("Average() = %v, want %v", got, tt.wantAverage)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// JobMiddleware wraps a JobHandler to add behaviour around job execution
+type JobMiddleware[T any] func(next JobHandler[T]) JobHandler[T]
+
+// chain wraps the handler so the first middleware is the outermost
+func chain[T any](handler JobHandler[T], middlewares []JobMiddleware[T]) JobHandler[T] {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// RetryMiddleware retries a failing handler in-process up to maxAttempts times, sleeping
+// backoffFn(attempt) between attempts, and returns the last error once attempts run out.
+// All attempts happen within a single dispatch, so the job stays invisible throughout;
+// keep the total retry time below the visibility timeout. This is independent of the
+// store-level retries configured with WithRetry. A nil backoffFn retries right away.
+func RetryMiddleware[T any](maxAttempts int, backoffFn func(attempt int) time.Duration) JobMiddleware[T] {
+	if backoffFn == nil {
+		backoffFn = func(int) time.Duration { return 0 }
+	}
+
+	return func(next JobHandler[T]) JobHandler[T] {
+		return func(ctx context.Context, job Job[T]) error {
+			var err error
+			for attempt := 1; ; attempt++ {
+				if err = next(ctx, job); err == nil || attempt >= maxAttempts {
+					return err
+				}
+
+				timer := time.NewTimer(backoffFn(attempt))
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return err
+				}
+			}
+		}
+	}
+}
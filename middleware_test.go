@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryMiddleware(t *testing.T) {
+	errHandler := errors.New("handler failed")
+
+	tests := []struct {
+		name         string
+		maxAttempts  int
+		backoffFn    func(attempt int) time.Duration
+		failures     int // Attempts failing before the handler succeeds
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "succeeds first time", maxAttempts: 3, failures: 0, wantAttempts: 1},
+		{name: "succeeds on a retry", maxAttempts: 3, failures: 2, wantAttempts: 3},
+		{name: "final error after exhaustion", maxAttempts: 3, failures: 5, wantAttempts: 3, wantErr: true},
+		{name: "single attempt", maxAttempts: 1, failures: 5, wantAttempts: 1, wantErr: true},
+		{name: "nil backoff retries right away", maxAttempts: 2, backoffFn: nil, failures: 1, wantAttempts: 2},
+		{name: "backoff between attempts", maxAttempts: 2, backoffFn: func(int) time.Duration { return time.Millisecond }, failures: 1, wantAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			handler := RetryMiddleware[string](tt.maxAttempts, tt.backoffFn)(func(ctx context.Context, job Job[string]) error {
+				attempts++
+				if attempts <= tt.failures {
+					return errHandler
+				}
+				return nil
+			})
+
+			err := handler(context.Background(), Job[string]{Id: "job-1"})
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			if tt.wantErr != errors.Is(err, errHandler) {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryMiddlewareStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	handler := RetryMiddleware[string](5, func(int) time.Duration { return time.Hour })(func(ctx context.Context, job Job[string]) error {
+		attempts++
+		cancel()
+		return errors.New("handler failed")
+	})
+
+	if err := handler(ctx, Job[string]{Id: "job-1"}); err == nil || attempts != 1 {
+		t.Fatalf("handler() = %v after %d attempts, want the first error", err, attempts)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !chaos
+
+package scheduler
+
+import "context"
+
+// chaosMode is empty without the chaos build tag, WithChaosMode isn't available and nothing is injected
+type chaosMode struct{}
+
+func (c *chaosMode) fetchFault(ctx context.Context) error { return nil }
+
+func (c *chaosMode) handlerFault() error { return nil }
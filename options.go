@@ -0,0 +1,396 @@
+package scheduler
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Option configures optional Scheduler behaviour
+type Option[T any] func(*Scheduler[T])
+
+// WithJobSizeLimit rejects jobs whose JSON-encoded payload exceeds maxBytes on submission
+func WithJobSizeLimit[T any](maxBytes int) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.maxPayloadBytes = maxBytes
+	}
+}
+
+// WithErrorInterval sets the pause after a failed fetch, the pause doubles on every
+// consecutive failure up to maxInterval and resets once a fetch succeeds. A fetch only fails
+// after a few quick retries.
+func WithErrorInterval[T any](interval, maxInterval time.Duration) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.errorInterval = interval
+		s.maxErrorInterval = max(interval, maxInterval)
+	}
+}
+
+// WithWebhookClient sets the HTTP client used to deliver job callbacks to CallbackURL
+func WithWebhookClient[T any](client *http.Client) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.httpClient = client
+	}
+}
+
+// WithCostBasedScheduling limits the total cost of jobs dispatched per polling interval.
+// costFn returns the cost of a job, dispatch stops for the interval once budgetPerInterval
+// is used up and the remaining fetched jobs are made visible again. Unused budget rolls
+// over to the next interval up to 2 * budgetPerInterval.
+func WithCostBasedScheduling[T any](costFn func(job Job[T]) int, budgetPerInterval int) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.costBudget = newCostBudget(costFn, budgetPerInterval, s.pollInterval())
+	}
+}
+
+// WithCircuitBreaker pauses dispatch for cooldown after threshold consecutive handler failures
+// across all jobs. After the cooldown a single job is dispatched to probe recovery: success
+// resumes normal dispatch, failure pauses it for another cooldown.
+func WithCircuitBreaker[T any](threshold int, cooldown time.Duration) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.breaker = newCircuitBreaker(threshold, cooldown)
+	}
+}
+
+// WithBatchHandler processes jobs in batches of up to batchSize with a single handler call
+// instead of the per-job handler. A worker waits at most maxWait for a batch to fill before
+// handing over what it has.
+func WithBatchHandler[T any](handler BatchJobHandler[T], batchSize int, maxWait time.Duration) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.batchHandler = handler
+		s.batchSize = max(batchSize, 1)
+		s.batchMaxWait = maxWait
+	}
+}
+
+// WithRetry reschedules failed jobs until they have been attempted maxAttempts times.
+// backoff returns the delay before the next attempt given the number of attempts so far,
+// a nil backoff retries on the next fetch.
+func WithRetry[T any](maxAttempts int, backoff func(attempt int) time.Duration) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.maxAttempts = max(maxAttempts, 1)
+		s.retryBackoff = backoff
+	}
+}
+
+// WithRetrySchedule retries failed jobs on a fixed schedule instead of a computed backoff,
+// schedule[i] being the delay before retry i+1, e.g. 1m, 5m, 30m, 2h. Once the schedule is
+// exhausted the job is marked failed. It replaces any WithRetry setting, whichever comes last wins.
+func WithRetrySchedule[T any](schedule []time.Duration) Option[T] {
+	schedule = append([]time.Duration(nil), schedule...)
+	return func(s *Scheduler[T]) {
+		s.maxAttempts = len(schedule) + 1
+		s.retryBackoff = func(attempt int) time.Duration {
+			return schedule[min(attempt, len(schedule))-1]
+		}
+	}
+}
+
+// WithRetryBudget caps how many failed jobs are rescheduled per minute across all jobs.
+// Once the budget is used up further retries are pushed out until it refills, smoothing
+// the load on a recovering downstream.
+func WithRetryBudget[T any](retriesPerMinute int) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.retryBudget = newRetryBudget(retriesPerMinute)
+	}
+}
+
+// WithDedupWindow makes SubmitJob reject a job with ErrDuplicateJob when a job with the same
+// key, as returned by keyFn, was submitted within d. Requires a store implementing DedupStore.
+func WithDedupWindow[T any](d time.Duration, keyFn DedupKeyFn[T]) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.dedupWindow = d
+		s.dedupKeyFn = keyFn
+	}
+}
+
+// WithMiddlewareChain wraps the job handler with the given middlewares, the first one
+// being the outermost. Middlewares don't apply to a batch handler.
+func WithMiddlewareChain[T any](middlewares ...JobMiddleware[T]) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.middlewares = append(s.middlewares, middlewares...)
+	}
+}
+
+// WithStartupDelay delays the first fetch after Run, giving cold connection pools time to warm up
+func WithStartupDelay[T any](d time.Duration) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.startupDelay = d
+	}
+}
+
+// WithWorkerStagger starts workers one at a time, d apart, to avoid a connection stampede on startup
+func WithWorkerStagger[T any](d time.Duration) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.workerStagger = d
+	}
+}
+
+// WithErrorClassifier consults classifier for every handler error to decide whether the job
+// is retried and with what delay, e.g. a long delay for rate limiting and no retry for bad
+// input. Retries still require WithRetry and count towards its attempt limit.
+func WithErrorClassifier[T any](classifier ErrorClassifier) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.errorClassifier = classifier
+	}
+}
+
+// WithLeaderLock makes the scheduler fetch jobs only while it holds the store's leader lock,
+// so a single instance schedules at a time even if several are running. The lock is held for
+// ttl and renewed well before it expires; another instance takes over once it lapses. The
+// store must implement LeaderLocker, otherwise Run stops with an error wrapping ErrNotSupported.
+func WithLeaderLock[T any](ttl time.Duration) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.leaderTTL = ttl
+	}
+}
+
+// WithMissedRunPolicy sets how repeating jobs that fell behind schedule, e.g. after downtime,
+// are handled (default: MissedRunsCatchUp)
+func WithMissedRunPolicy[T any](policy MissedRunPolicy) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.missedRunPolicy = policy
+	}
+}
+
+// WithOnWorkerStart calls fn in every worker goroutine before it takes its first job, e.g. to
+// acquire a connection. A worker whose start hook returns an error exits without processing jobs.
+func WithOnWorkerStart[T any](fn func(ctx context.Context, workerID int) error) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.onWorkerStart = fn
+	}
+}
+
+// WithOnWorkerStop calls fn when a worker goroutine exits after a successful start, e.g. to
+// release what the start hook acquired
+func WithOnWorkerStop[T any](fn func(workerID int)) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.onWorkerStop = fn
+	}
+}
+
+// WithJobIDGenerator sets how Scheduler.NewJob and recurring job runs generate job ids, e.g.
+// ULIDGenerator for ids that sort by creation time (default: UUIDv4Generator)
+func WithJobIDGenerator[T any](fn JobIDGenerator) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.idGenerator = fn
+	}
+}
+
+// WithMaxIdleTime makes the scheduler shut itself down gracefully, as if its context was
+// cancelled, once no jobs have been fetched for d. Useful for batch runs that drain the
+// queue and exit.
+func WithMaxIdleTime[T any](d time.Duration) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.maxIdleTime = d
+	}
+}
+
+// WithFetchStrategy sets how many jobs are fetched per pass, e.g. PrefetchFixed(n) to keep jobs
+// queued ahead of the workers (default: DemandDriven)
+func WithFetchStrategy[T any](strategy FetchStrategy) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.fetchStrategy = strategy
+	}
+}
+
+// WithDeliveryMode sets whether jobs interrupted by a crashed worker are redelivered
+// (default: AtLeastOnce), handlers can read the mode with DeliveryModeFromContext
+func WithDeliveryMode[T any](mode DeliveryMode) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.deliveryMode = mode
+	}
+}
+
+// WithLeaseRecovery sweeps the store every interval for jobs whose visibility timeout has lapsed
+// by the database's clock and makes them visible again, so recovery of jobs left behind by
+// crashed workers doesn't depend on the scheduler host's clock. The store must implement
+// LeaseRecoverer, otherwise Run stops with an error wrapping ErrNotSupported.
+func WithLeaseRecovery[T any](interval time.Duration) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.leaseRecovery = interval
+	}
+}
+
+// WithAutoPauseTypeOnFailureRate pauses a job type once more than threshold (0 to 1) of its last
+// window handler results were failures, stopping a failing type from burning through retries.
+// Untyped jobs aren't tracked; ResumeType re-enables a paused type.
+func WithAutoPauseTypeOnFailureRate[T any](threshold float64, window int) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.autoPauseRate = threshold
+		s.autoPauseWindow = window
+	}
+}
+
+// WithOnTypePaused calls fn when a job type is paused automatically because of its failure rate,
+// e.g. to raise an alert
+func WithOnTypePaused[T any](fn func(jobType string, failureRate float64)) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.onTypePaused = fn
+	}
+}
+
+// WithMaxConsecutiveFailures calls fn once n handler calls in a row have failed for jobs of the
+// same type, a hint of a systemic problem such as a downstream outage. The count resets when a
+// job of that type succeeds, untyped jobs are counted together.
+func WithMaxConsecutiveFailures[T any](n int, fn OnConsecutiveFailureFn) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.maxFailureStreak = n
+		s.onFailureStreak = fn
+	}
+}
+
+// WithVisibilityRefreshInterval extends the visibility timeout of every job being handled each
+// interval, so handlers running longer than the visibility timeout keep their jobs hidden from
+// other instances. interval should be below half the visibility timeout and is capped there.
+func WithVisibilityRefreshInterval[T any](interval time.Duration) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.visibilityRefresh = interval
+	}
+}
+
+// WithDispatchFilter skips fetched jobs for which fn returns false, e.g. jobs for another region
+// or behind a disabled feature flag. Skipped jobs are made visible again right away without being
+// attempted, so another instance can pick them up. Unlike the filters stores apply in their fetch
+// query, such as tenant scoping, fn runs in the scheduler after the fetch, so skipped jobs still
+// take fetch slots; prefer a store-side filter for jobs this instance never handles.
+func WithDispatchFilter[T any](fn func(job *Job[T]) bool) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.dispatchFilter = fn
+	}
+}
+
+// WithInlineDispatch handles jobs directly in the fetch loop instead of handing them to a worker
+// goroutine over a channel, trimming dispatch latency for single-worker, latency-sensitive setups.
+// It only applies with a worker count of 1 and no batch handler; the next fetch waits for the
+// handler to return.
+func WithInlineDispatch[T any]() Option[T] {
+	return func(s *Scheduler[T]) {
+		s.inline = true
+	}
+}
+
+// WithAdaptiveWorkerPool replaces the fixed worker count with a pool of minWorkers to maxWorkers.
+// Every polling interval the scheduler samples demand as jobs being processed plus jobs queued per
+// worker; above scaleUpAt for 3 samples in a row the pool grows by half, up to maxWorkers, and
+// below scaleDownAt for 5 samples it shrinks back to minWorkers, letting workers finish their
+// current job. Batch handlers keep the fixed worker count.
+func WithAdaptiveWorkerPool[T any](minWorkers, maxWorkers int, scaleUpAt, scaleDownAt float64) Option[T] {
+	return func(s *Scheduler[T]) {
+		minWorkers = max(minWorkers, 1)
+		s.pool = &workerPool{
+			min:         minWorkers,
+			max:         max(maxWorkers, minWorkers),
+			scaleUpAt:   scaleUpAt,
+			scaleDownAt: scaleDownAt,
+		}
+		s.pool.size.Store(int64(minWorkers))
+	}
+}
+
+// WithCompletionQueue saves job results from a background goroutine fed through a queue of size
+// results, so workers move on to the next job without waiting for the store. When the queue is
+// full workers block and the fetch loop stops fetching until it drains, keeping the number of
+// jobs in flight bounded when the store is the bottleneck. CompletionLag reports how far behind
+// saving results is.
+func WithCompletionQueue[T any](size int) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.resultQueueSize = size
+	}
+}
+
+// WithSecretManager encrypts job payloads with sm when they are submitted and decrypts them in
+// the worker right before the handler runs, so payloads stay encrypted in the store. Stores keep
+// the ciphertext in the job's SealedPayload.
+func WithSecretManager[T any](sm SecretManager) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.secretManager = sm
+	}
+}
+
+// WithInstanceID sets the id recorded in ProcessedBy of every job this scheduler dispatches, telling
+// which node of a fleet processed a job (default: the host name)
+func WithInstanceID[T any](id string) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.instanceID = id
+	}
+}
+
+// WithFetchLimiter makes the scheduler take a slot from limiter for every fetch, pass the same
+// limiter to every scheduler sharing a store to cap the fetches running against it at once
+func WithFetchLimiter[T any](limiter *FetchLimiter) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.fetchLimiter = limiter
+	}
+}
+
+// WithPool dedicates workers workers to jobs of jobType, fetched by their own loop, so a burst of
+// heavy jobs of one type can't starve jobs of other types. The shared workers no longer handle
+// jobType. Pools share the store, leader lock, completion queue and shutdown with the rest of the
+// scheduler and handle jobs one at a time with the job handler; the circuit breaker, cost budget
+// and adaptive pool only apply to the shared workers. The store must implement TypeFetcher.
+func WithPool[T any](jobType string, workers int) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.typePools = append(s.typePools, &typePool{jobType: jobType, workers: max(workers, 1)})
+	}
+}
+
+// WithContextPropagator carries context values from SubmitJobContext to the handler through the
+// job's Metadata, e.g. TraceContextPropagator or SlogContextPropagator. It can be given more than
+// once, propagators run in the order given. Batch handlers receive the jobs' Metadata as is.
+func WithContextPropagator[T any](prop ContextPropagator[T]) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.propagators = append(s.propagators, prop)
+	}
+}
+
+// WithMaxJobAge expires jobs that are still pending d after they were created instead of running
+// them: the job is saved with status "expired" without calling the handler, and SubmitJob rejects
+// jobs due past that age with ErrJobExpired. Repeating jobs keep their creation time across runs
+// and never expire.
+func WithMaxJobAge[T any](d time.Duration) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.maxJobAge = d
+	}
+}
+
+// WithFallbackStore writes jobs that can't be added to the store to fallback instead, e.g. a
+// MemoryStore, so submitting doesn't fail while the store is down. With WriteThrough fallback also
+// keeps a copy of every job. Fallback jobs are moved back by MigrateFromFallback when Run starts.
+// The scheduler only fetches from its store.
+func WithFallbackStore[T any](fallback JobStore[T], policy FallbackPolicy) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.fallback = fallback
+		s.fallbackPolicy = policy
+	}
+}
+
+// WithDynamicInterval replaces the polling interval of the fetch loop with fn, called after every
+// fetch cycle and while every worker is busy to decide how long to sleep before the next cycle,
+// e.g. no sleep while fetches come back full and a long one while they come back empty. Without
+// it the loop fetches again right away after a cycle that returned jobs and sleeps the polling
+// interval otherwise. Returning 0 while QueuedJobs+ActiveJobs >= Workers makes the loop spin.
+// fn runs on the fetch loop and must not block; per-type pools, error backoff and the other
+// pauses keep the polling interval.
+func WithDynamicInterval[T any](fn DynamicIntervalFn) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.dynamicInterval = fn
+	}
+}
+
+// WithPayloadMetrics reports the running average payload size kept by metrics in Stats, pass the
+// same PayloadMetrics to the store's WithMetricsCollector option to record the sizes
+func WithPayloadMetrics[T any](metrics *PayloadMetrics) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.payloadMetrics = metrics
+	}
+}
+
+// WithTieBreak orders pending jobs that are equally due and of equal priority by tb instead of
+// FIFO, e.g. TieBreakRandom to spread load. Run sets it on the store when it starts; stores
+// shared by several schedulers use the tie break set last. The store must implement TieBreaker.
+func WithTieBreak[T any](tb TieBreak) Option[T] {
+	return func(s *Scheduler[T]) {
+		s.tieBreak = tb
+	}
+}
@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// poolScaleUpSamples is how many samples in a row must be above the scale up threshold
+	poolScaleUpSamples = 3
+	// poolScaleDownSamples is how many samples in a row must be below the scale down threshold
+	poolScaleDownSamples = 5
+)
+
+// workerPool grows and shrinks the number of workers with demand, it is sampled from the fetch loop
+type workerPool struct {
+	min, max    int
+	scaleUpAt   float64
+	scaleDownAt float64
+	size        atomic.Int64    // Current number of workers, read by the fetch strategy and snapshots
+	quits       []chan struct{} // Stops each running worker, newest last
+	nextID      int
+	above       int // Consecutive samples above scaleUpAt
+	below       int // Consecutive samples below scaleDownAt
+}
+
+// adaptivePool returns the worker pool if one is configured and usable, batch workers keep a fixed count
+func (s *Scheduler[T]) adaptivePool() *workerPool {
+	if s.batchHandler != nil {
+		return nil
+	}
+	return s.pool
+}
+
+// startWorkerPool starts the pool's minimum number of workers
+func (s *Scheduler[T]) startWorkerPool(ctx context.Context, p *workerPool, jobs chan *Job[T], workers *errgroup.Group) {
+	p.quits, p.nextID, p.above, p.below = nil, 0, 0, 0
+	s.resizeWorkerPool(ctx, p, p.min, jobs, workers)
+}
+
+// sampleWorkerPool measures demand relative to the current workers, (jobs being processed plus
+// jobs queued) per worker, and scales the pool once demand stays past a threshold
+func (s *Scheduler[T]) sampleWorkerPool(ctx context.Context, p *workerPool, jobs chan *Job[T], workers *errgroup.Group) {
+	size := len(p.quits)
+	utilization := float64(s.sharedActiveJobs()+len(jobs)) / float64(size)
+
+	switch {
+	case utilization > p.scaleUpAt:
+		p.above++
+		p.below = 0
+	case utilization < p.scaleDownAt:
+		p.below++
+		p.above = 0
+	default:
+		p.above, p.below = 0, 0
+	}
+
+	if p.above >= poolScaleUpSamples && size < p.max {
+		target := min(p.max, int(math.Ceil(float64(size)*1.5)))
+		s.log.Info("scaling worker pool up", "workers", size, "target", target, "utilization", utilization)
+		s.resizeWorkerPool(ctx, p, target, jobs, workers)
+		p.above = 0
+	} else if p.below >= poolScaleDownSamples && size > p.min {
+		s.log.Info("scaling worker pool down", "workers", size, "target", p.min, "utilization", utilization)
+		s.resizeWorkerPool(ctx, p, p.min, jobs, workers)
+		p.below = 0
+	}
+}
+
+// resizeWorkerPool starts or stops workers until the pool has target workers, stopped workers
+// finish the job they're processing first
+func (s *Scheduler[T]) resizeWorkerPool(ctx context.Context, p *workerPool, target int, jobs chan *Job[T], workers *errgroup.Group) {
+	for len(p.quits) < target {
+		quit := make(chan struct{})
+		p.quits = append(p.quits, quit)
+
+		id := p.nextID
+		workers.Go(func() error {
+			return s.worker(ctx, id, jobs, quit)
+		})
+		p.nextID++
+	}
+
+	for len(p.quits) > target {
+		last := len(p.quits) - 1
+		close(p.quits[last])
+		p.quits = p.quits[:last]
+	}
+
+	p.size.Store(int64(len(p.quits)))
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func TestWorkerPoolScaling(t *testing.T) {
+	tests := []struct {
+		name  string
+		start int // Workers running before sampling, the pool minimum when 0
+		// Jobs being processed at each sample and the pool size after it
+		depths    []int
+		wantSizes []int
+	}{
+		{
+			name:      "scales up after three busy samples",
+			depths:    []int{4, 4, 4},
+			wantSizes: []int{2, 2, 3},
+		},
+		{
+			name:      "a normal sample resets the busy streak",
+			depths:    []int{4, 4, 1, 4, 4},
+			wantSizes: []int{2, 2, 2, 2, 2},
+		},
+		{
+			name:      "grows by half up to the maximum",
+			depths:    []int{100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100},
+			wantSizes: []int{2, 2, 3, 3, 3, 5, 5, 5, 8, 8, 8, 10, 10, 10, 10},
+		},
+		{
+			name:      "scales down to the minimum after five idle samples",
+			start:     8,
+			depths:    []int{0, 0, 0, 0, 0, 0},
+			wantSizes: []int{8, 8, 8, 8, 2, 2},
+		},
+		{
+			name:      "a busy sample resets the idle streak",
+			start:     8,
+			depths:    []int{0, 0, 0, 0, 4, 0, 0, 0, 0},
+			wantSizes: []int{8, 8, 8, 8, 8, 8, 8, 8, 8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scheduler[string]{log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+			WithAdaptiveWorkerPool[string](2, 10, 0.8, 0.2)(s)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			jobs := make(chan *Job[string])
+			var workers errgroup.Group
+			defer func() {
+				cancel()
+				close(jobs)
+				if err := workers.Wait(); err != nil {
+					t.Error(err)
+				}
+			}()
+
+			s.startWorkerPool(ctx, s.pool, jobs, &workers)
+			if tt.start > 0 {
+				s.resizeWorkerPool(ctx, s.pool, tt.start, jobs, &workers)
+			}
+
+			var sizes []int
+			for _, depth := range tt.depths {
+				s.activeJobs.Store(int64(depth))
+				s.sampleWorkerPool(ctx, s.pool, jobs, &workers)
+				sizes = append(sizes, int(s.pool.size.Load()))
+			}
+			if !slices.Equal(sizes, tt.wantSizes) {
+				t.Fatalf("pool sizes = %v, want %v", sizes, tt.wantSizes)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+)
+
+// Metadata holds string values carried with a job, e.g. context values propagated from the
+// code submitting the job to its handler
+type Metadata map[string]string
+
+// ContextPropagator carries values from the context a job is submitted with to the context its
+// handler runs with, through the job's Metadata
+type ContextPropagator[T any] interface {
+	// Inject stores values of ctx into job.Metadata before the job is added to the store
+	Inject(ctx context.Context, job *Job[T])
+
+	// Extract returns ctx carrying the values stored in job.Metadata by Inject
+	Extract(ctx context.Context, job Job[T]) context.Context
+}
+
+// injectContext stores the context values of every propagator into the job's metadata
+func (s *Scheduler[T]) injectContext(ctx context.Context, job *Job[T]) {
+	for _, prop := range s.propagators {
+		prop.Inject(ctx, job)
+	}
+}
+
+// extractContext returns the handler context carrying the job's propagated values
+func (s *Scheduler[T]) extractContext(ctx context.Context, job Job[T]) context.Context {
+	for _, prop := range s.propagators {
+		ctx = prop.Extract(ctx, job)
+	}
+	return ctx
+}
+
+// setMetadata sets key in the job's metadata, skipping empty values
+func setMetadata[T any](job *Job[T], key, value string) {
+	if value == "" {
+		return
+	}
+	if job.Metadata == nil {
+		job.Metadata = make(Metadata)
+	}
+	job.Metadata[key] = value
+}
+
+// TraceContext is a W3C trace context, the traceparent and tracestate headers of the request
+// that submitted a job
+type TraceContext struct {
+	TraceParent string
+	TraceState  string
+}
+
+type traceContextKey struct{}
+
+// WithTraceContext returns ctx carrying tc, for TraceContextPropagator to propagate
+func WithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceContextFrom returns the trace context carried by ctx, if any
+func TraceContextFrom(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok && tc.TraceParent != ""
+}
+
+// TraceContextPropagator propagates the W3C trace context set with WithTraceContext, stored in
+// the job's metadata under the "traceparent" and "tracestate" keys
+type TraceContextPropagator[T any] struct{}
+
+func (TraceContextPropagator[T]) Inject(ctx context.Context, job *Job[T]) {
+	if tc, ok := TraceContextFrom(ctx); ok {
+		setMetadata(job, "traceparent", tc.TraceParent)
+		setMetadata(job, "tracestate", tc.TraceState)
+	}
+}
+
+func (TraceContextPropagator[T]) Extract(ctx context.Context, job Job[T]) context.Context {
+	// Only well-formed version-traceid-parentid-flags headers are propagated
+	parent := job.Metadata["traceparent"]
+	if len(strings.Split(parent, "-")) != 4 {
+		return ctx
+	}
+	return WithTraceContext(ctx, TraceContext{TraceParent: parent, TraceState: job.Metadata["tracestate"]})
+}
+
+type (
+	requestIDKey struct{}
+	userIDKey    struct{}
+)
+
+// WithRequestID returns ctx carrying the id of the request being served
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the request id carried by ctx, empty if none
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithUserID returns ctx carrying the id of the user the request is made for
+func WithUserID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, id)
+}
+
+// UserIDFrom returns the user id carried by ctx, empty if none
+func UserIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(userIDKey{}).(string)
+	return id
+}
+
+// SlogContextPropagator propagates the request and user ids set with WithRequestID and
+// WithUserID, stored in the job's metadata under the "request-id" and "user-id" keys. Handlers
+// read them back with RequestIDFrom and UserIDFrom, e.g. to add them to their slog records.
+type SlogContextPropagator[T any] struct{}
+
+func (SlogContextPropagator[T]) Inject(ctx context.Context, job *Job[T]) {
+	setMetadata(job, "request-id", RequestIDFrom(ctx))
+	setMetadata(job, "user-id", UserIDFrom(ctx))
+}
+
+func (SlogContextPropagator[T]) Extract(ctx context.Context, job Job[T]) context.Context {
+	if id := job.Metadata["request-id"]; id != "" {
+		ctx = WithRequestID(ctx, id)
+	}
+	if id := job.Metadata["user-id"]; id != "" {
+		ctx = WithUserID(ctx, id)
+	}
+	return ctx
+}
@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+)
+
+// TerminalReason tells why a job ended without completing
+type TerminalReason string
+
+const (
+	// ReasonHandlerError means the handler failed and the job wasn't configured for retries
+	ReasonHandlerError TerminalReason = "handler_error"
+	// ReasonRetriesExhausted means the handler failed on the last attempt allowed by WithRetry
+	ReasonRetriesExhausted TerminalReason = "retries_exhausted"
+	// ReasonNonRetryable means the error classifier marked the handler error as permanent
+	ReasonNonRetryable TerminalReason = "non_retryable"
+	// ReasonTimeout means the handler gave up on a context deadline
+	ReasonTimeout TerminalReason = "timeout"
+	// ReasonCancelled means the handler stopped because its context was cancelled, e.g. on shutdown
+	ReasonCancelled TerminalReason = "cancelled"
+)
+
+// FailureCounter is implemented by stores that can count failed jobs by terminal reason
+type FailureCounter interface {
+	// CountFailedByReason returns the number of failed jobs per terminal reason, jobs that failed
+	// before reasons were recorded are counted under the empty reason
+	CountFailedByReason() (map[TerminalReason]int, error)
+}
+
+// terminalReason returns why a job whose handler failed with err is failed for good
+func (s *Scheduler[T]) terminalReason(job *Job[T], err error, decision RetryDecision) TerminalReason {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ReasonTimeout
+	case errors.Is(err, context.Canceled):
+		return ReasonCancelled
+	case !decision.Retry:
+		return ReasonNonRetryable
+	case s.maxAttempts > 1 && job.Attempts >= s.maxAttempts:
+		return ReasonRetriesExhausted
+	default:
+		return ReasonHandlerError
+	}
+}
@@ -0,0 +1,68 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestTerminalReasons(t *testing.T) {
+	errDownstream := errors.New("downstream failed")
+	permanent := scheduler.WithErrorClassifier[string](func(err error) scheduler.RetryDecision { return scheduler.RetryDecision{} })
+
+	tests := []struct {
+		name       string
+		opts       []scheduler.Option[string]
+		err        error
+		age        time.Duration // How long ago the job was created
+		wantStatus string
+		wantReason scheduler.TerminalReason
+	}{
+		{name: "handler error", err: errDownstream, wantStatus: "failed", wantReason: scheduler.ReasonHandlerError},
+		{name: "retries exhausted", opts: []scheduler.Option[string]{scheduler.WithRetry[string](2, nil)}, err: errDownstream, wantStatus: "failed", wantReason: scheduler.ReasonRetriesExhausted},
+		{name: "non retryable", opts: []scheduler.Option[string]{scheduler.WithRetry[string](3, nil), permanent}, err: errDownstream, wantStatus: "failed", wantReason: scheduler.ReasonNonRetryable},
+		{name: "timeout", err: fmt.Errorf("call downstream: %w", context.DeadlineExceeded), wantStatus: "failed", wantReason: scheduler.ReasonTimeout},
+		{name: "cancelled", err: fmt.Errorf("call downstream: %w", context.Canceled), wantStatus: "failed", wantReason: scheduler.ReasonCancelled},
+		{name: "expired", opts: []scheduler.Option[string]{scheduler.WithMaxJobAge[string](time.Hour)}, age: 2 * time.Hour, wantStatus: "expired"},
+		{name: "completed", wantStatus: "completed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				return tt.err
+			}
+
+			store := storage.NewMemoryStore[string]()
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger(), tt.opts...)
+			// Added to the store directly, as a job that aged while it waited would be
+			job := s.NewJob(time.Now(), "work")
+			job.CreatedAt = job.CreatedAt.Add(-tt.age)
+			if err := store.AddJob(job); err != nil {
+				t.Fatal(err)
+			}
+			s.Run(ctx)
+
+			got := waitForStatus(t, store, job.Id, tt.wantStatus, 5*time.Second)
+			if got.TerminalReason != tt.wantReason {
+				t.Fatalf("terminal reason = %q, want %q", got.TerminalReason, tt.wantReason)
+			}
+
+			counts, err := store.CountFailedByReason()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantStatus == "failed" && counts[tt.wantReason] != 1 {
+				t.Fatalf("failed jobs by reason = %v, want one %s", counts, tt.wantReason)
+			}
+		})
+	}
+}
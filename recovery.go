@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// startLeaseRecovery starts sweeping the store for lapsed visibility timeouts if configured
+func (s *Scheduler[T]) startLeaseRecovery(ctx context.Context) error {
+	if s.leaseRecovery <= 0 {
+		return nil
+	}
+
+	recoverer, ok := s.store.(LeaseRecoverer)
+	if !ok {
+		return &SchedulerError{Op: "lease recovery", Cause: ErrNotSupported}
+	}
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		s.runLeaseRecovery(ctx, recoverer)
+	}()
+
+	return nil
+}
+
+// runLeaseRecovery recovers lapsed leases every interval until ctx is done
+func (s *Scheduler[T]) runLeaseRecovery(ctx context.Context, recoverer LeaseRecoverer) {
+	ticker := time.NewTicker(s.leaseRecovery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		recovered, err := recoverer.RecoverExpiredLeases()
+		if err != nil {
+			s.log.Error("failed to recover expired leases", "error", &SchedulerError{Op: "lease recovery", Cause: err})
+			continue
+		}
+		if recovered > 0 {
+			s.log.Info("recovered jobs with expired leases", "recovered-jobs", recovered)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+// recoveringStore counts lease recovery sweeps over a MemoryStore
+type recoveringStore[T any] struct {
+	*storage.MemoryStore[T]
+	sweeps atomic.Int32
+}
+
+func (s *recoveringStore[T]) RecoverExpiredLeases() (int, error) {
+	s.sweeps.Add(1)
+	return 0, nil
+}
+
+func TestLeaseRecovery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	store := &recoveringStore[string]{MemoryStore: storage.NewMemoryStore[string]()}
+	s := scheduler.NewScheduler(store, 1, time.Second, time.Minute, noopHandler[string], discardLogger(),
+		scheduler.WithLeaseRecovery[string](20*time.Millisecond),
+	)
+	done := s.Run(ctx)
+
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Sweeps stop with the scheduler
+	sweeps := store.sweeps.Load()
+	if sweeps < 3 {
+		t.Fatalf("store swept %d times, want periodic sweeps", sweeps)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if after := store.sweeps.Load(); after != sweeps {
+		t.Fatalf("store swept %d more times after shutdown", after-sweeps)
+	}
+}
+
+func TestLeaseRecoveryNotSupported(t *testing.T) {
+	s := scheduler.NewScheduler(storage.NewMemoryStore[string](), 1, time.Second, time.Minute, noopHandler[string], discardLogger(),
+		scheduler.WithLeaseRecovery[string](time.Second),
+	)
+
+	select {
+	case err := <-s.Run(context.Background()):
+		if !errors.Is(err, scheduler.ErrNotSupported) {
+			t.Fatalf("Run() error = %v, want ErrNotSupported", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler kept running without lease recovery support")
+	}
+}
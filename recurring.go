@@ -0,0 +1,191 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+	"github.com/google/uuid"
+)
+
+// RecurringJob describes a job that is scheduled again on a cron expression after every successful run
+type RecurringJob[T any] struct {
+	Expr    string
+	Payload T
+
+	opts     []JobOption[T]
+	schedule *cronSchedule
+	err      error // Parse error, returned by ScheduleCron
+}
+
+// NewCron creates a recurring job from a standard 5-field cron expression, e.g. "*/15 * * * *"
+// opts are applied to every run. An invalid expression is reported by ScheduleCron.
+func NewCron[T any](expr string, payload T, opts ...JobOption[T]) *RecurringJob[T] {
+	schedule, err := parseCron(expr)
+	return &RecurringJob[T]{
+		Expr:     expr,
+		Payload:  payload,
+		opts:     opts,
+		schedule: schedule,
+		err:      err,
+	}
+}
+
+// newRun creates the run of the recurring job due at processAfter with an id from newID
+func (r *RecurringJob[T]) newRun(newID JobIDGenerator, processAfter time.Time) *Job[T] {
+	return newJob(newID(), processAfter, r.Payload, r.opts...)
+}
+
+type cronRegistration[T any] struct {
+	job       *RecurringJob[T]
+	completed chan struct{} // Signalled when the current run completes
+	stop      chan struct{} // Closed by CancelCron
+}
+
+// ScheduleCron submits the first run of the recurring job and returns the cron id used to cancel it
+// Each following run is submitted once the previous one completes successfully; a run that fails
+// ends the schedule. Registrations live in this scheduler instance only and stop when ctx is done,
+// so runs completed by other scheduler instances are not followed up.
+func (s *Scheduler[T]) ScheduleCron(ctx context.Context, job *RecurringJob[T]) (string, error) {
+	if job.err != nil {
+		return "", &SchedulerError{Op: "schedule cron", Cause: job.err}
+	}
+
+	first := job.schedule.next(time.Now())
+	if first.IsZero() {
+		return "", &SchedulerError{Op: "schedule cron", Cause: fmt.Errorf("cron expression %q never fires", job.Expr)}
+	}
+
+	cronID := uuid.New().String()
+	reg := &cronRegistration[T]{
+		job:       job,
+		completed: make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+	}
+	run := job.newRun(s.idGenerator, first)
+
+	// Register before submitting so a fast completion isn't missed
+	s.cronMu.Lock()
+	s.crons[cronID] = reg
+	s.cronRuns[run.Id] = cronID
+	s.cronMu.Unlock()
+
+	if err := s.SubmitJob(run); err != nil {
+		s.unregisterCron(cronID)
+		return "", err
+	}
+
+	go s.runCron(ctx, cronID, reg)
+
+	s.log.Info("scheduled recurring job", "cron-id", cronID, "expr", job.Expr, "next-run", first)
+	return cronID, nil
+}
+
+// CancelCron removes the recurring job registration, a run already in the store still executes once
+func (s *Scheduler[T]) CancelCron(ctx context.Context, cronID string) error {
+	if err := ctx.Err(); err != nil {
+		return &SchedulerError{Op: "cancel cron", JobID: cronID, Cause: err}
+	}
+
+	if !s.unregisterCron(cronID) {
+		return &SchedulerError{Op: "cancel cron", JobID: cronID, Cause: ErrCronNotFound}
+	}
+
+	s.log.Info("cancelled recurring job", "cron-id", cronID)
+	return nil
+}
+
+// unregisterCron removes the registration and stops its goroutine, returning false if it wasn't registered
+func (s *Scheduler[T]) unregisterCron(cronID string) bool {
+	s.cronMu.Lock()
+	defer s.cronMu.Unlock()
+
+	reg, ok := s.crons[cronID]
+	if !ok {
+		return false
+	}
+
+	delete(s.crons, cronID)
+	for runID, id := range s.cronRuns {
+		if id == cronID {
+			delete(s.cronRuns, runID)
+		}
+	}
+	close(reg.stop)
+
+	return true
+}
+
+// cronRunFinished follows up a run of a recurring job, a completed run schedules the next one,
+// a failed run ends the schedule and a run that is retried is left alone
+func (s *Scheduler[T]) cronRunFinished(job *Job[T]) {
+	if job.Status == "pending" {
+		return
+	}
+
+	s.cronMu.Lock()
+	cronID, ok := s.cronRuns[job.Id]
+	if !ok {
+		s.cronMu.Unlock()
+		return
+	}
+	delete(s.cronRuns, job.Id)
+
+	if job.Status == "completed" {
+		select {
+		case s.crons[cronID].completed <- struct{}{}:
+		default:
+		}
+		s.cronMu.Unlock()
+		return
+	}
+	s.cronMu.Unlock()
+
+	s.log.Info("recurring job run failed, ending schedule", "cron-id", cronID, "job-id", job.Id)
+	s.unregisterCron(cronID)
+}
+
+// runCron submits the next run of a recurring job after each successful completion
+func (s *Scheduler[T]) runCron(ctx context.Context, cronID string, reg *cronRegistration[T]) {
+	for {
+		select {
+		case <-ctx.Done():
+			s.unregisterCron(cronID)
+			return
+		case <-reg.stop:
+			return
+		case <-reg.completed:
+		}
+
+		next := reg.job.schedule.next(time.Now())
+		if next.IsZero() {
+			s.log.Info("recurring job has no further runs", "cron-id", cronID)
+			s.unregisterCron(cronID)
+			return
+		}
+
+		run := reg.job.newRun(s.idGenerator, next)
+
+		s.cronMu.Lock()
+		if _, ok := s.crons[cronID]; !ok {
+			s.cronMu.Unlock()
+			return
+		}
+		s.cronRuns[run.Id] = cronID
+		s.cronMu.Unlock()
+
+		_, err := backoff.Retry(ctx, func() (any, error) {
+			return nil, s.SubmitJob(run)
+		}, backoff.WithNotify(func(err error, d time.Duration) {
+			s.log.Error("failed to submit recurring job run, retrying...", "cron-id", cronID, "error", err, "duration", d)
+		}))
+		if err != nil {
+			s.log.Error("failed to submit recurring job run after retries", "cron-id", cronID, "error", err)
+			s.unregisterCron(cronID)
+			return
+		}
+
+		s.log.Debug("scheduled next recurring job run", "cron-id", cronID, "job-id", run.Id, "next-run", next)
+	}
+}
@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// startVisibilityRefresh extends the visibility timeout of jobs being handled every refresh
+// interval, so long-running handlers don't have their jobs fetched by another instance. The
+// returned function stops the refresh and waits for an extension in progress to finish.
+func (s *Scheduler[T]) startVisibilityRefresh(ctx context.Context, jobs ...*Job[T]) (stop func()) {
+	if s.visibilityRefresh <= 0 {
+		return func() {}
+	}
+
+	// Refresh at least twice per timeout so a single failed extension doesn't expose the job
+	interval := min(s.visibilityRefresh, s.visibilityTimeout/2)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			for _, job := range jobs {
+				unlock := s.lockJob(job)
+				job.MakeInvisible(s.visibilityTimeout)
+				s.updateJob(ctx, job, "extend visibility timeout")
+				unlock()
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,56 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestVisibilityRefresh(t *testing.T) {
+	tests := []struct {
+		name         string
+		refresh      time.Duration
+		wantRequeued bool
+	}{
+		{name: "refreshed job stays invisible", refresh: 500 * time.Millisecond},
+		{name: "unrefreshed job is fetched again", wantRequeued: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var runs atomic.Int32
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				runs.Add(1)
+				time.Sleep(3 * time.Second)
+				return nil
+			}
+
+			store := storage.NewMemoryStore[string]()
+			s := scheduler.NewScheduler(store, 2, 50*time.Millisecond, 2*time.Second, handler, discardLogger(),
+				scheduler.WithVisibilityRefreshInterval[string](tt.refresh),
+			)
+			job := s.NewJob(time.Now(), "payload")
+			if err := s.SubmitJob(job); err != nil {
+				t.Fatal(err)
+			}
+			s.Run(ctx)
+
+			time.Sleep(3500 * time.Millisecond)
+			if requeued := runs.Load() > 1; requeued != tt.wantRequeued {
+				t.Fatalf("handler ran %d times, want requeued = %v", runs.Load(), tt.wantRequeued)
+			}
+			if !tt.wantRequeued {
+				waitForStatus(t, store, job.Id, "completed", time.Second)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// TypedRegistry routes Job[any] jobs to handlers registered for the concrete type of their payload,
+// so one queue can carry many small job types. Stores must keep the payload's concrete type, which
+// the memory store does; stores that decode payloads into `any` yield maps and need a string key instead.
+type TypedRegistry struct {
+	mu       sync.RWMutex
+	handlers map[reflect.Type]JobHandler[any]
+}
+
+// NewTypedRegistry creates an empty registry
+func NewTypedRegistry() *TypedRegistry {
+	return &TypedRegistry{handlers: make(map[reflect.Type]JobHandler[any])}
+}
+
+// RegisterTyped registers handler for jobs whose payload is a P, replacing any previous handler for P
+func RegisterTyped[P any](r *TypedRegistry, handler func(ctx context.Context, job Job[any], payload P) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[reflect.TypeFor[P]()] = func(ctx context.Context, job Job[any]) error {
+		return handler(ctx, job, job.Payload.(P))
+	}
+}
+
+// Handler returns a JobHandler that dispatches each job to the handler registered for its payload
+// type, failing the job with ErrNoHandler if there is none
+func (r *TypedRegistry) Handler() JobHandler[any] {
+	return func(ctx context.Context, job Job[any]) error {
+		payloadType := reflect.TypeOf(job.Payload)
+
+		r.mu.RLock()
+		handler, ok := r.handlers[payloadType]
+		r.mu.RUnlock()
+
+		if !ok {
+			return fmt.Errorf("%w: payload type %v", ErrNoHandler, payloadType)
+		}
+
+		return handler(ctx, job)
+	}
+}
@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type sendEmail struct{ To string }
+
+type resizeImage struct{ Width, Height int }
+
+func TestTypedRegistryRouting(t *testing.T) {
+	var routed string // What the last routed job did
+	r := NewTypedRegistry()
+	RegisterTyped(r, func(ctx context.Context, job Job[any], payload sendEmail) error {
+		routed = "email to " + payload.To
+		return nil
+	})
+	RegisterTyped(r, func(ctx context.Context, job Job[any], payload resizeImage) error {
+		routed = "resize"
+		if payload.Width <= 0 {
+			return errors.New("invalid width")
+		}
+		return nil
+	})
+
+	tests := []struct {
+		name       string
+		payload    any
+		wantRouted string
+		wantErr    bool
+		wantNoFunc bool // ErrNoHandler expected
+	}{
+		{name: "first type", payload: sendEmail{To: "a@example.com"}, wantRouted: "email to a@example.com"},
+		{name: "second type", payload: resizeImage{Width: 100, Height: 50}, wantRouted: "resize"},
+		{name: "handler error returned", payload: resizeImage{}, wantRouted: "resize", wantErr: true},
+		{name: "pointer to a registered type", payload: &sendEmail{To: "b@example.com"}, wantErr: true, wantNoFunc: true},
+		{name: "unregistered type", payload: 42, wantErr: true, wantNoFunc: true},
+		{name: "no payload", wantErr: true, wantNoFunc: true},
+	}
+
+	handler := r.Handler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routed = ""
+
+			err := handler(context.Background(), Job[any]{Id: "job-1", Payload: tt.payload})
+			if (err != nil) != tt.wantErr || errors.Is(err, ErrNoHandler) != tt.wantNoFunc {
+				t.Fatalf("handler error = %v, want error %v (no handler %v)", err, tt.wantErr, tt.wantNoFunc)
+			}
+
+			if routed != tt.wantRouted {
+				t.Fatalf("routed to %q, want %q", routed, tt.wantRouted)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package scheduler
+
+import "time"
+
+// MissedRunPolicy decides what happens to runs of a repeating job that fell behind schedule
+type MissedRunPolicy int
+
+const (
+	// MissedRunsCatchUp runs every missed run back to back until the job is on schedule again
+	MissedRunsCatchUp MissedRunPolicy = iota
+	// MissedRunsSkip drops missed runs and schedules the next run in the future, dropped runs
+	// count towards the job's total so the schedule still ends on time
+	MissedRunsSkip
+)
+
+// missedRuns returns how many runs of a repeating job to skip after the current one, always
+// leaving at least one run
+func (s *Scheduler[T]) missedRuns(job *Job[T], now time.Time) int {
+	if s.missedRunPolicy != MissedRunsSkip {
+		return 0
+	}
+
+	behind := int(now.Sub(job.ProcessAfter) / job.Interval)
+	return max(0, min(behind, job.RemainingRuns-2))
+}
@@ -0,0 +1,58 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestRepeatingJobRuns(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   scheduler.MissedRunPolicy
+		behind   time.Duration // How long ago the first run was due
+		interval time.Duration
+		runs     int
+		wantRuns int32
+	}{
+		{name: "on schedule", interval: 20 * time.Millisecond, runs: 5, wantRuns: 5},
+		{name: "single run", interval: 20 * time.Millisecond, runs: 1, wantRuns: 1},
+		{name: "behind, catching up", policy: scheduler.MissedRunsCatchUp, behind: 10 * time.Hour, interval: time.Hour, runs: 5, wantRuns: 5},
+		// The first run is made, missed runs are dropped up to the last one which is still overdue
+		{name: "behind, skipping missed runs", policy: scheduler.MissedRunsSkip, behind: 10 * time.Hour, interval: time.Hour, runs: 5, wantRuns: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var runs atomic.Int32
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				runs.Add(1)
+				return nil
+			}
+
+			store := storage.NewMemoryStore[string]()
+			s := scheduler.NewScheduler(store, 1, 5*time.Millisecond, time.Minute, handler, discardLogger(),
+				scheduler.WithMissedRunPolicy[string](tt.policy),
+			)
+			job := s.NewJob(time.Now().Add(-tt.behind), "report", scheduler.WithJobRepeat[string](tt.interval, tt.runs))
+			if err := s.SubmitJob(job); err != nil {
+				t.Fatal(err)
+			}
+			s.Run(ctx)
+
+			waitForStatus(t, store, job.Id, "completed", 5*time.Second)
+			if n := runs.Load(); n != tt.wantRuns {
+				t.Fatalf("job ran %d times, want %d", n, tt.wantRuns)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// RetryDecision tells the scheduler how to handle a failed job
+type RetryDecision struct {
+	Retry bool          // Reschedule the job, still bounded by the WithRetry attempt limit
+	Delay time.Duration // Replaces the retry backoff when positive, e.g. for rate limited downstreams
+}
+
+// ErrorClassifier maps a handler error to a retry decision
+type ErrorClassifier func(err error) RetryDecision
+
+// retryBudget is a token bucket limiting how many failed jobs are rescheduled per minute across all jobs
+// When the bucket is empty a retry is pushed out until a token becomes available
+type retryBudget struct {
+	mu         sync.Mutex
+	perMinute  float64
+	tokens     float64
+	refilledAt time.Time
+}
+
+func newRetryBudget(retriesPerMinute int) *retryBudget {
+	return &retryBudget{
+		perMinute:  float64(retriesPerMinute),
+		tokens:     float64(retriesPerMinute),
+		refilledAt: time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the retry has to wait for it
+func (b *retryBudget) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.perMinute <= 0 {
+		return 0
+	}
+
+	elapsed := now.Sub(b.refilledAt).Minutes()
+	if elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed*b.perMinute, b.perMinute)
+		b.refilledAt = now
+	}
+
+	// Tokens go negative while retries are queued up waiting for the bucket to refill
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.perMinute * float64(time.Minute))
+}
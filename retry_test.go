@@ -0,0 +1,222 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestRetryBudget(t *testing.T) {
+	tests := []struct {
+		name             string
+		jobs             int
+		retriesPerMin    int
+		wantImmediate    int           // Retries let through right away
+		wantRetrySpacing time.Duration // Spacing of the retries pushed out until the budget refills
+	}{
+		{name: "budget of 5 per minute", jobs: 20, retriesPerMin: 5, wantImmediate: 5, wantRetrySpacing: 12 * time.Second},
+		{name: "budget covering every retry", jobs: 10, retriesPerMin: 60, wantImmediate: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handler := func(ctx context.Context, job scheduler.Job[int]) error {
+				return errors.New("downstream unavailable")
+			}
+
+			store := storage.NewMemoryStore[int]()
+			s := scheduler.NewScheduler(store, 4, 10*time.Millisecond, time.Minute, handler, discardLogger(),
+				scheduler.WithRetry[int](2, nil),
+				scheduler.WithRetryBudget[int](tt.retriesPerMin),
+			)
+			for i := range tt.jobs {
+				if err := s.SubmitJob(s.NewJob(time.Now(), i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			start := time.Now()
+			s.Run(ctx)
+
+			// Jobs retried right away fail for good on their second attempt, the others wait
+			deadline := time.Now().Add(5 * time.Second)
+			var failed int
+			var pushedOut []time.Time
+			for {
+				failed, pushedOut = 0, pushedOut[:0]
+				for _, job := range store.GetJobs() {
+					switch {
+					case job.Status == "failed":
+						failed++
+					case job.Status == "pending" && job.Attempts == 1 && job.ProcessAfter.After(start.Add(time.Second)):
+						pushedOut = append(pushedOut, job.ProcessAfter)
+					}
+				}
+				if failed+len(pushedOut) == tt.jobs || time.Now().After(deadline) {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			if failed != tt.wantImmediate || len(pushedOut) != tt.jobs-tt.wantImmediate {
+				t.Fatalf("%d jobs retried right away and %d pushed out, want %d and %d", failed, len(pushedOut), tt.wantImmediate, tt.jobs-tt.wantImmediate)
+			}
+
+			sort.Slice(pushedOut, func(i, j int) bool { return pushedOut[i].Before(pushedOut[j]) })
+			for i := 1; i < len(pushedOut); i++ {
+				if gap := pushedOut[i].Sub(pushedOut[i-1]); gap < tt.wantRetrySpacing-time.Second {
+					t.Fatalf("retries %d and %d are %s apart, want %s", i-1, i, gap, tt.wantRetrySpacing)
+				}
+			}
+		})
+	}
+}
+
+// httpError is a handler error carrying the downstream response status
+type httpError struct{ status int }
+
+func (e *httpError) Error() string { return http.StatusText(e.status) }
+
+func TestErrorClassifier(t *testing.T) {
+	classifier := func(err error) scheduler.RetryDecision {
+		var httpErr *httpError
+		if !errors.As(err, &httpErr) {
+			return scheduler.RetryDecision{Retry: true}
+		}
+		switch {
+		case httpErr.status == http.StatusTooManyRequests:
+			return scheduler.RetryDecision{Retry: true, Delay: time.Hour}
+		case httpErr.status >= 400 && httpErr.status < 500:
+			return scheduler.RetryDecision{}
+		}
+		return scheduler.RetryDecision{Retry: true}
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus string
+		wantReason scheduler.TerminalReason
+		wantDelay  time.Duration // Delay until the retry, when retried
+	}{
+		{name: "rate limited retries after a long delay", err: &httpError{status: http.StatusTooManyRequests}, wantStatus: "pending", wantDelay: time.Hour},
+		{name: "bad request fails right away", err: &httpError{status: http.StatusBadRequest}, wantStatus: "failed", wantReason: scheduler.ReasonNonRetryable},
+		{name: "server error uses the backoff", err: &httpError{status: http.StatusBadGateway}, wantStatus: "pending", wantDelay: time.Minute},
+		{name: "unclassified error uses the backoff", err: errors.New("connection reset"), wantStatus: "pending", wantDelay: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				return tt.err
+			}
+
+			store := storage.NewMemoryStore[string]()
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger(),
+				scheduler.WithRetry[string](3, func(int) time.Duration { return time.Minute }),
+				scheduler.WithErrorClassifier[string](classifier),
+			)
+			job := s.NewJob(time.Now(), "request")
+			if err := s.SubmitJob(job); err != nil {
+				t.Fatal(err)
+			}
+			s.Run(ctx)
+
+			// The first attempt is over once the job is visible again or has failed
+			deadline := time.Now().Add(5 * time.Second)
+			var got *scheduler.Job[string]
+			for {
+				got = store.GetJobs()[job.Id]
+				if got.Attempts == 1 && (got.Status == "failed" || got.IsVisible()) {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("job still has status %q after %d attempts", got.Status, got.Attempts)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			finished := time.Now()
+
+			if got.Status != tt.wantStatus || got.TerminalReason != tt.wantReason {
+				t.Fatalf("job status = %q (reason %q), want %q (reason %q)", got.Status, got.TerminalReason, tt.wantStatus, tt.wantReason)
+			}
+			if tt.wantDelay > 0 {
+				if delay := got.ProcessAfter.Sub(finished); delay > tt.wantDelay || delay < tt.wantDelay-time.Second {
+					t.Fatalf("retry in %s, want %s", delay, tt.wantDelay)
+				}
+			}
+		})
+	}
+}
+
+func TestRetrySchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule []time.Duration
+	}{
+		{name: "three retries", schedule: []time.Duration{50 * time.Millisecond, 150 * time.Millisecond, 100 * time.Millisecond}},
+		{name: "single retry", schedule: []time.Duration{100 * time.Millisecond}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Record how far each retry pushes the job out
+			var mu sync.Mutex
+			var delays []time.Duration
+			store := &hookStore[string]{MemoryStore: storage.NewMemoryStore[string]()}
+			store.onUpdate = func(job *scheduler.Job[string]) error {
+				if delay := time.Until(job.ProcessAfter); job.Status == "pending" && delay > 0 {
+					mu.Lock()
+					delays = append(delays, delay)
+					mu.Unlock()
+				}
+				return nil
+			}
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				return errors.New("downstream unavailable")
+			}
+
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger(),
+				scheduler.WithRetrySchedule[string](tt.schedule),
+			)
+			job := s.NewJob(time.Now(), "request")
+			if err := s.SubmitJob(job); err != nil {
+				t.Fatal(err)
+			}
+			s.Run(ctx)
+
+			// Once the schedule is exhausted the next failure is final
+			got := waitForStatus(t, store.MemoryStore, job.Id, "failed", 5*time.Second)
+			if got.Attempts != len(tt.schedule)+1 {
+				t.Fatalf("job failed after %d attempts, want %d", got.Attempts, len(tt.schedule)+1)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(delays) != len(tt.schedule) {
+				t.Fatalf("job retried %d times, want %d", len(delays), len(tt.schedule))
+			}
+			for i, delay := range delays {
+				if want := tt.schedule[i]; delay > want || delay < want-20*time.Millisecond {
+					t.Fatalf("retry %d in %s, want %s", i+1, delay, want)
+				}
+			}
+		})
+	}
+}
@@ -2,12 +2,18 @@ package scheduler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v5"
+	"golang.org/x/sync/errgroup"
 )
 
 // JobHandler defines the function signature for processing jobs
@@ -18,39 +24,327 @@ type JobHandler[T any] func(ctx context.Context, job Job[T]) error
 type Scheduler[T any] struct {
 	store             JobStore[T]
 	workerCount       int
-	interval          time.Duration
+	interval          atomic.Int64 // Polling interval in nanoseconds, changed at runtime with SetInterval
+	dynamicInterval   DynamicIntervalFn
 	visibilityTimeout time.Duration
 	log               *slog.Logger
 	jobHandler        JobHandler[T]
+	errorInterval     time.Duration
+	maxErrorInterval  time.Duration
+	maxPayloadBytes   int
+	httpClient        *http.Client
+	callbacks         sync.WaitGroup
+	costBudget        *costBudget[T]
+	breaker           *circuitBreaker
+	batchHandler      BatchJobHandler[T]
+	batchSize         int
+	batchMaxWait      time.Duration
+	maxAttempts       int
+	retryBackoff      func(attempt int) time.Duration
+	retryBudget       *retryBudget
+	dedupWindow       time.Duration
+	dedupKeyFn        DedupKeyFn[T]
+	middlewares       []JobMiddleware[T]
+	propagators       []ContextPropagator[T]
+	startupDelay      time.Duration
+	workerStagger     time.Duration
+	errorClassifier   ErrorClassifier
+	leaderTTL         time.Duration
+	fallback          JobStore[T]
+	fallbackPolicy    FallbackPolicy
+	payloadMetrics    *PayloadMetrics
+	tieBreak          TieBreak
+	chaos             *chaosMode     // Set by WithChaosMode in builds with the chaos tag
+	background        sync.WaitGroup // Leader lock and lease recovery goroutines
+	missedRunPolicy   MissedRunPolicy
+	onWorkerStart     func(ctx context.Context, workerID int) error
+	onWorkerStop      func(workerID int)
+	idGenerator       JobIDGenerator
+	maxIdleTime       time.Duration
+	maxJobAge         time.Duration
+	fetchStrategy     FetchStrategy
+	fetchLimiter      *FetchLimiter
+	leaseRecovery     time.Duration
+	visibilityRefresh time.Duration
+	dispatchFilter    func(job *Job[T]) bool
+	inline            bool
+	pool              *workerPool
+	typePools         []*typePool
+	resultQueueSize   int
+	completions       atomic.Pointer[completionQueue[T]]
+	completionLag     atomic.Int64 // Nanoseconds the last saved result waited in the completion queue
+	deliveryMode      DeliveryMode
+	secretManager     SecretManager
+	instanceID        string
+	activeJobs        atomic.Int64
+	inFlightMu        sync.Mutex
+	inFlight          map[string]*inFlightJob // Id of each job being processed to its processing state
+	typesMu           sync.Mutex
+	pausedTypes       map[string]bool   // Job types whose jobs aren't dispatched
+	typeResults       map[string][]bool // Recent handler results per job type, true for a failure
+	autoPauseRate     float64
+	autoPauseWindow   int
+	onTypePaused      func(jobType string, failureRate float64)
+	failureStreaks    map[string]int // Handler failures in a row per job type
+	maxFailureStreak  int
+	onFailureStreak   OnConsecutiveFailureFn
+	cronMu            sync.Mutex
+	crons             map[string]*cronRegistration[T] // Cron id to its registration
+	cronRuns          map[string]string               // Id of each cron's scheduled run to the cron id
+	waitersMu         sync.Mutex
+	waiters           map[string]chan *Job[T] // Id of each job awaited by EnqueueAndWait to its result channel
+	funcsMu           sync.Mutex
+	funcs             map[string]func(ctx context.Context) error // Func id of each job scheduled with ScheduleFunc to its func
+}
+
+// DedupKeyFn derives the key used to detect duplicate jobs
+type DedupKeyFn[T any] func(job *Job[T]) string
+
+// inFlightJob is the processing state of a job handed to a worker
+type inFlightJob struct {
+	startedAt time.Time
+	mu        sync.Mutex // Serializes updates to the job while its handler runs
+}
+
+// defaultWebhookTimeout bounds a single callback delivery attempt
+const defaultWebhookTimeout = 10 * time.Second
+
+// fetchMaxTries bounds the quick retries of a single fetch, longer outages are backed off by the
+// fetch loop's error interval
+const fetchMaxTries = 3
+
+// defaultErrorBackoffFactor bounds how far the error interval grows relative to the polling interval by default
+const defaultErrorBackoffFactor = 16
+
+// defaultInstanceID identifies the scheduler by its host name, empty if it can't be determined
+func defaultInstanceID() string {
+	host, _ := os.Hostname()
+	return host
 }
 
 // NewScheduler creates a new scheduler instance with visibility timeout
-func NewScheduler[T any](store JobStore[T], workerCount int, interval time.Duration, visibilityTimeout time.Duration, jobHandler JobHandler[T], log *slog.Logger) *Scheduler[T] {
-	return &Scheduler[T]{
+func NewScheduler[T any](store JobStore[T], workerCount int, interval time.Duration, visibilityTimeout time.Duration, jobHandler JobHandler[T], log *slog.Logger, opts ...Option[T]) *Scheduler[T] {
+	s := &Scheduler[T]{
 		store:             store,
 		workerCount:       workerCount,
-		interval:          interval,
 		visibilityTimeout: visibilityTimeout,
 		jobHandler:        jobHandler,
 		log:               log,
+		errorInterval:     interval,
+		maxErrorInterval:  interval * defaultErrorBackoffFactor,
+		httpClient:        &http.Client{Timeout: defaultWebhookTimeout},
+		maxAttempts:       1,
+		idGenerator:       UUIDv4Generator(),
+		instanceID:        defaultInstanceID(),
+		fetchStrategy:     DemandDriven(),
+		crons:             make(map[string]*cronRegistration[T]),
+		cronRuns:          make(map[string]string),
+		inFlight:          make(map[string]*inFlightJob),
+		pausedTypes:       make(map[string]bool),
+		typeResults:       make(map[string][]bool),
+		failureStreaks:    make(map[string]int),
+		waiters:           make(map[string]chan *Job[T]),
+		funcs:             make(map[string]func(ctx context.Context) error),
+	}
+	s.interval.Store(int64(interval))
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	s.jobHandler = chain(s.routeFuncs(s.jobHandler), s.middlewares)
+
+	return s
 }
 
-// Run starts the scheduler and returns a channel that closes when shutdown is complete
-func (s *Scheduler[T]) Run(ctx context.Context) <-chan struct{} {
-	done := make(chan struct{})
+// SetInterval changes the polling interval of a running scheduler, e.g. to poll more often while
+// working through a backlog. It takes effect from the fetch loop's next cycle; the error backoff
+// and the cost budget's refill period keep the interval the scheduler was created with.
+func (s *Scheduler[T]) SetInterval(d time.Duration) error {
+	if d <= 0 {
+		return &SchedulerError{Op: "set interval", Cause: fmt.Errorf("%w: %s", ErrInvalidInterval, d)}
+	}
+	s.interval.Store(int64(d))
+	return nil
+}
+
+// pollInterval returns the current polling interval
+func (s *Scheduler[T]) pollInterval() time.Duration {
+	return time.Duration(s.interval.Load())
+}
+
+// NewJob creates a pending job with an id from the scheduler's job id generator
+func (s *Scheduler[T]) NewJob(processAfter time.Time, payload T, opts ...JobOption[T]) *Job[T] {
+	return newJob(s.idGenerator(), processAfter, payload, opts...)
+}
+
+// SubmitJob validates the job against the scheduler configuration and adds it to the store
+// Errors are returned as a *SchedulerError with Op "submit job"
+func (s *Scheduler[T]) SubmitJob(job *Job[T]) error {
+	return s.SubmitJobContext(context.Background(), job)
+}
+
+// SubmitJobContext is SubmitJob for a job submitted on behalf of ctx, e.g. an HTTP request's
+// context, whose values the scheduler's context propagators carry to the job's handler
+func (s *Scheduler[T]) SubmitJobContext(ctx context.Context, job *Job[T]) error {
+	if err := s.submitJob(ctx, job); err != nil {
+		return &SchedulerError{Op: "submit job", JobID: job.Id, Cause: err}
+	}
+	return nil
+}
+
+func (s *Scheduler[T]) submitJob(ctx context.Context, job *Job[T]) error {
+	// A job due in the past runs as soon as it is fetched
+	dueAt := job.ProcessAfter
+	if now := time.Now(); dueAt.Before(now) {
+		dueAt = now
+	}
+	if s.jobExpired(job, dueAt) {
+		return fmt.Errorf("%w: due %s, more than %s after it was created", ErrJobExpired, dueAt.Format(time.RFC3339), s.maxJobAge)
+	}
+
+	if s.maxPayloadBytes > 0 {
+		data, err := json.Marshal(job.Payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal job payload: %w", err)
+		}
+		if len(data) > s.maxPayloadBytes {
+			return fmt.Errorf("%w: %d bytes exceeds limit of %d bytes", ErrPayloadTooLarge, len(data), s.maxPayloadBytes)
+		}
+	}
+
+	var dedupStore DedupStore[T]
+	var dedupKey string
+	if s.dedupWindow > 0 {
+		var ok bool
+		if dedupStore, ok = s.store.(DedupStore[T]); !ok {
+			return fmt.Errorf("dedup window: %w", ErrNotSupported)
+		}
+		// The key is derived from the plaintext payload
+		dedupKey = s.dedupKeyFn(job)
+	}
+
+	if s.secretManager != nil {
+		restore, err := s.sealPayload(ctx, job)
+		if err != nil {
+			return err
+		}
+		defer restore()
+	}
+
+	s.injectContext(ctx, job)
+
+	if dedupStore != nil {
+		return s.addWithFallback(job, func() error {
+			return dedupStore.AddUniqueJob(job, dedupKey, s.dedupWindow)
+		})
+	}
+
+	return s.addWithFallback(job, func() error {
+		return s.store.AddJob(job)
+	})
+}
+
+// Run starts the scheduler and returns a channel that receives the exit reason once shutdown is
+// complete: nil when ctx is cancelled, its deadline passes or the scheduler stops after being idle,
+// otherwise the cancellation cause or the error that stopped the scheduler. Job updates that fail
+// during shutdown, e.g. a result that couldn't be saved or a queued job that couldn't be made
+// visible again, leave jobs invisible until their visibility timeout and are joined to the reason.
+func (s *Scheduler[T]) Run(ctx context.Context) <-chan error {
+	done := make(chan error, 1)
+	parent := ctx
 
 	go func() {
-		defer close(done)
+		// Idle shutdown and fatal errors cancel the scheduler's own context, leaving the caller's untouched
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+		ctx = context.WithValue(ctx, deliveryModeKey{}, s.deliveryMode)
+		// Job updates that failed once shutdown began
+		var shutdownErrs []error
+		defer func() {
+			done <- errors.Join(append([]error{exitError(parent, ctx)}, shutdownErrs...)...)
+			close(done)
+		}()
+
+		// Workers only return the errors of updates that failed during shutdown, so the group
+		// never cancels the scheduler; unrecoverable errors cancel ctx with their cause instead
+		var workers errgroup.Group
+		jobs := make(chan *Job[T], s.queueCapacity())
+		s.startCompletionQueue(ctx)
+
+		// Inline dispatch handles jobs in this goroutine, which acts as the only worker
+		inline := s.inlineDispatch()
+		if inline {
+			if s.startWorker(ctx, 0) {
+				defer s.stopWorker(0)
+			} else {
+				cancel(&SchedulerError{Op: "start inline worker", Cause: errors.New("worker start hook failed")})
+			}
+		}
+
+		// An adaptive pool starts its own workers and is resized from the fetch loop
+		pool := s.adaptivePool()
+		if pool != nil {
+			s.startWorkerPool(ctx, pool, jobs, &workers)
+		}
+
+		for i := 0; i < s.workerCount && !inline && pool == nil; i++ {
+			if i > 0 {
+				sleepContext(ctx, s.workerStagger)
+			}
+
+			workers.Go(func() error {
+				if s.batchHandler != nil {
+					return s.batchWorker(ctx, i, jobs)
+				}
+				return s.worker(ctx, i, jobs, nil)
+			})
+		}
 
-		var wg sync.WaitGroup
-		jobs := make(chan *Job[T], s.workerCount)
+		// Give cold connection pools a chance to warm up before the first fetch
+		sleepContext(ctx, s.startupDelay)
 
-		for i := 0; i < s.workerCount; i++ {
-			wg.Add(1)
-			go s.worker(ctx, i, jobs, &wg)
+		// Jobs left in the fallback store while the primary store was down; failing to migrate
+		// them doesn't stop the scheduler, the primary store may still be recovering
+		if err := s.MigrateFromFallback(ctx); err != nil {
+			s.log.Warn("failed to migrate jobs from fallback store", "error", err)
 		}
 
+		leader, err := s.startLeaderLock(ctx)
+		if err != nil {
+			s.log.Error("failed to start leader lock, shutting down", "error", err)
+			cancel(err)
+		}
+
+		if err := s.startLeaseRecovery(ctx); err != nil {
+			s.log.Error("failed to start lease recovery, shutting down", "error", err)
+			cancel(err)
+		}
+
+		if err := s.applyTieBreak(); err != nil {
+			s.log.Error("failed to set tie break, shutting down", "error", err)
+			cancel(err)
+		}
+
+		// Per-type worker pools fetch and dispatch their own jobs alongside the fetch loop below
+		var pools errgroup.Group
+		if err := s.startTypePools(ctx, leader, &pools); err != nil {
+			s.log.Error("failed to start worker pools, shutting down", "error", err)
+			cancel(err)
+		}
+
+		// Pause after a failed fetch, doubled on every consecutive failure
+		errorDelay := s.errorInterval
+
+		// When jobs were last fetched, for idle shutdown
+		lastFetch := time.Now()
+
+		// When the worker pool was last sampled
+		lastSample := time.Now()
+
+		// Counts of the last fetch, for the dynamic interval
+		var cycle SchedulerStats
+
 		// Demand-driven fetching loop
 		for {
 			select {
@@ -59,65 +353,169 @@ func (s *Scheduler[T]) Run(ctx context.Context) <-chan struct{} {
 				s.log.Info("shutting down scheduler... making remaining jobs visible", "remaining-jobs", len(jobs))
 				// Graceful cleanup: make remaining jobs immediately visible
 				for remainingJob := range jobs {
-					remainingJob.MakeVisible()
-					_, err := backoff.Retry(ctx, func() (any, error) {
-						err := s.store.UpdateJob(remainingJob)
-						return nil, err
-					}, backoff.WithNotify(func(err error, d time.Duration) {
-						s.log.Error("failed to make unprocessed job visible, retrying...", "job-id", remainingJob.Id, "error", err, "duration", d)
-					}))
-					if err != nil {
-						s.log.Error("failed to make unprocessed job visible after retries", "job-id", remainingJob.Id, "error", err)
+					if err := s.releaseJob(ctx, remainingJob); err != nil {
+						shutdownErrs = append(shutdownErrs, err)
 					}
-
-					s.log.Debug("made unprocessed job visible", "job-id", remainingJob.Id)
 				}
-				wg.Wait()
+				if err := workers.Wait(); err != nil {
+					shutdownErrs = append(shutdownErrs, err)
+				}
+				if err := pools.Wait(); err != nil {
+					shutdownErrs = append(shutdownErrs, err)
+				}
+				if err := s.stopCompletionQueue(); err != nil {
+					shutdownErrs = append(shutdownErrs, err)
+				}
+				s.background.Wait()
+				s.callbacks.Wait()
 				s.log.Info("scheduler shutdown complete")
 				return
 
 			default:
-				// Calculate how many jobs we can fetch based on channel capacity
-				availableSlots := cap(jobs) - len(jobs)
+				if pool != nil && time.Since(lastSample) >= s.pollInterval() {
+					s.sampleWorkerPool(ctx, pool, jobs, &workers)
+					lastSample = time.Now()
+				}
+
+				// Only the instance holding the leader lock fetches
+				if leader != nil && !leader.isLeader() {
+					sleepContext(ctx, s.pollInterval())
+					continue
+				}
+
+				// Hold off fetching more work while results wait to be saved
+				if s.completionsBehind() {
+					s.log.Debug("completion queue full, pausing fetching", "queued-results", s.queuedResults())
+					sleepContext(ctx, s.pollInterval())
+					continue
+				}
+
+				// Ask the fetch strategy how many jobs to fetch, sends block once the channel is full
+				availableSlots := s.fetchStrategy.ShouldFetch(len(jobs), s.queueSize(), s.sharedActiveJobs())
+
+				// An open circuit pauses dispatch, a half-open one lets a single trial job through
+				trial := false
+				if s.breaker != nil && availableSlots > 0 {
+					limit, ok := s.breaker.acquire(time.Now())
+					if !ok {
+						sleepContext(ctx, s.pollInterval())
+						continue
+					}
+					if limit > 0 {
+						availableSlots = min(availableSlots, limit)
+						trial = true
+					}
+				}
+
 				if availableSlots > 0 {
 					// Fetch jobs to fill available slots
-					entries, err := backoff.Retry(ctx, func() ([]*Job[T], error) {
-						return s.store.FetchPendingJobs(time.Now(), availableSlots, s.visibilityTimeout)
-					}, backoff.WithNotify(func(err error, d time.Duration) {
-						s.log.Error("failed to fetch pending entries, retrying...", "error", err, "duration", d)
-					}))
+					entries, err := s.fetchJobs(ctx, availableSlots, s.sharedTypeFilter())
+					if trial && (err != nil || len(entries) == 0) {
+						s.breaker.cancelTrial()
+					}
 					if err != nil {
-						s.log.Error("failed to fetch pending entries", "error", err)
-						// Brief pause on error to prevent tight error loop
-						time.Sleep(s.interval)
+						if ctx.Err() != nil {
+							continue
+						}
+						err = &SchedulerError{Op: "fetch jobs", Cause: err}
+						s.log.Error("failed to fetch pending entries", "error", err, "retry-in", errorDelay)
+						// Back off on error so a failing store isn't hammered
+						sleepContext(ctx, errorDelay)
+						errorDelay = min(errorDelay*2, s.maxErrorInterval)
 						continue
 					}
+					errorDelay = s.errorInterval
+					cycle = SchedulerStats{Requested: availableSlots, Fetched: len(entries)}
 
 					if len(entries) == 0 {
+						if s.maxIdleTime > 0 && time.Since(lastFetch) >= s.maxIdleTime && !s.typePoolsFetchedWithin(s.maxIdleTime) {
+							s.log.Info("no jobs fetched within max idle time, shutting down", "max-idle-time", s.maxIdleTime)
+							cancel(nil)
+							continue
+						}
+
 						// No jobs available, brief pause to prevent busy waiting
-						time.Sleep(s.interval)
+						sleepContext(ctx, s.cycleDelay(ctx, cycle, len(jobs), s.pollInterval()))
 						continue
 					}
+					lastFetch = time.Now()
+
+					// Make jobs invisible and dispatch them, fetched entries not yet dispatched are
+					// tracked here rather than in the channel and must be released on shutdown too
+				dispatch:
+					for i, entry := range entries {
+						if ctx.Err() != nil {
+							shutdownErrs = append(shutdownErrs, s.releaseUndispatched(ctx, entries[i:]))
+							break
+						}
+
+						if s.jobExpired(entry, time.Now()) {
+							if trial {
+								s.breaker.cancelTrial()
+							}
+							s.expireJob(ctx, entry)
+							continue
+						}
+
+						if entry.Type != "" && s.TypePaused(entry.Type) {
+							s.log.Debug("job type paused, deferring job", "event", EventJobDeferred, "job-id", entry.Id, "job-type", entry.Type)
+							if trial {
+								s.breaker.cancelTrial()
+							}
+							s.deferPausedJob(ctx, entry)
+							continue
+						}
+
+						if s.dispatchFilter != nil && !s.dispatchFilter(entry) {
+							s.log.Debug("job rejected by dispatch filter, making it visible", "job-id", entry.Id)
+							if trial {
+								s.breaker.cancelTrial()
+							}
+							s.releaseJob(ctx, entry)
+							continue
+						}
 
-					// Make jobs invisible and dispatch them
-					for _, entry := range entries {
-						s.log.Debug("making job invisible", "job-id", entry.Id)
-						entry.MakeInvisible(s.visibilityTimeout)
-						_, err := backoff.Retry(ctx, func() (any, error) {
-							err := s.store.UpdateJob(entry)
-							return nil, err
-						}, backoff.WithNotify(func(err error, d time.Duration) {
-							s.log.Error("failed to make job invisible, retrying...", "job-id", entry.Id, "error", err, "duration", d)
-						}))
-						if err != nil {
-							s.log.Error("failed to make job invisible after retries", "job-id", entry.Id, "error", err)
+						if s.costBudget != nil && !s.costBudget.take(s.costBudget.costFn(*entry), time.Now()) {
+							s.log.Debug("cost budget exhausted, deferring remaining jobs", "deferred-jobs", len(entries)-i)
+							if trial {
+								s.breaker.cancelTrial()
+							}
+							for _, deferred := range entries[i:] {
+								s.releaseJob(ctx, deferred)
+							}
+							sleepContext(ctx, s.pollInterval())
+							break
 						}
 
-						s.log.Debug("dispatching job", "job-id", entry.Id)
-						jobs <- entry
+						if err := s.claimJob(ctx, entry); errors.Is(err, ErrConcurrentModification) {
+							s.log.Debug("job changed since it was fetched, skipping it", "job-id", entry.Id)
+							if trial {
+								s.breaker.cancelTrial()
+							}
+							continue
+						}
+						s.log.Debug("dispatching job", "event", EventJobDispatched, "job-id", entry.Id)
+
+						if inline {
+							if err := s.processJob(ctx, entry, 0); err != nil && ctx.Err() != nil {
+								shutdownErrs = append(shutdownErrs, err)
+							}
+							continue
+						}
+
+						select {
+						case jobs <- entry:
+						case <-ctx.Done():
+							shutdownErrs = append(shutdownErrs, s.releaseUndispatched(ctx, entries[i:]))
+							break dispatch
+						}
 					}
+
+					// Fetch again right away unless a dynamic interval says otherwise
+					sleepContext(ctx, s.cycleDelay(ctx, cycle, len(jobs), 0))
 				} else {
-					time.Sleep(s.interval)
+					// Every worker is busy
+					sleepContext(ctx, s.cycleDelay(ctx, cycle, len(jobs), s.pollInterval()))
 				}
 			}
 		}
@@ -126,38 +524,378 @@ func (s *Scheduler[T]) Run(ctx context.Context) <-chan struct{} {
 	return done
 }
 
-func (s *Scheduler[T]) worker(ctx context.Context, workerId int, jobs chan *Job[T], wg *sync.WaitGroup) {
-	defer wg.Done()
+// fetchJobs fetches up to limit pending jobs, retrying a failed fetch up to fetchMaxTries times
+// before the caller backs off by the error interval, only jobs passing filter unless it is nil
+func (s *Scheduler[T]) fetchJobs(ctx context.Context, limit int, filter *TypeFilter) ([]*Job[T], error) {
+	return backoff.Retry(ctx, func() ([]*Job[T], error) {
+		if !s.fetchLimiter.acquire(ctx) {
+			return nil, backoff.Permanent(ctx.Err())
+		}
+		defer s.fetchLimiter.release()
 
-	for job := range jobs {
-		startTime := time.Now()
-		s.log.Debug("processing job", "job-id", job.Id, "worker-id", workerId)
+		if err := s.chaos.fetchFault(ctx); err != nil {
+			return nil, err
+		}
 
-		// Pass job by value to prevent modifications
-		err := s.jobHandler(ctx, *job)
-		duration := time.Since(startTime)
+		if filter == nil {
+			return s.store.FetchPendingJobs(time.Now(), limit, s.visibilityTimeout)
+		}
+		fetcher, ok := s.store.(TypeFetcher[T])
+		if !ok {
+			return nil, backoff.Permanent(ErrNotSupported)
+		}
+		return fetcher.FetchPendingJobsByType(*filter, time.Now(), limit, s.visibilityTimeout)
+	}, backoff.WithMaxTries(fetchMaxTries), backoff.WithNotify(func(err error, d time.Duration) {
+		s.log.Error("failed to fetch pending entries, retrying...", "error", err, "duration", d)
+	}))
+}
 
-		// Update job status based on result
-		if err != nil {
-			s.log.Info("failed to process job", "job-id", job.Id, "worker-id", workerId, "duration", fmt.Sprintf("%.2fs", duration.Seconds()), "error", err)
-			job.MakeFailed()
-		} else {
-			s.log.Info("job completed", "job-id", job.Id, "worker-id", workerId, "duration", fmt.Sprintf("%.2fs", duration.Seconds()))
-			job.MakeCompleted()
+// claimJob makes a fetched job invisible to other fetches before it is dispatched, returning the
+// error of saving it; ErrConcurrentModification means the job changed since it was fetched
+func (s *Scheduler[T]) claimJob(ctx context.Context, entry *Job[T]) error {
+	s.log.Debug("making job invisible", "job-id", entry.Id)
+	entry.Attempts++
+	entry.ProcessedBy = s.instanceID
+	entry.MakeInvisible(s.visibilityTimeout)
+	if s.deliveryMode == AtMostOnce {
+		entry.MakeProcessing()
+	}
+	return s.updateJob(ctx, entry, "make job invisible")
+}
+
+// startLeaderLock starts maintaining the leader lock if configured, returning nil when no lock is used
+func (s *Scheduler[T]) startLeaderLock(ctx context.Context) (*leaderLock, error) {
+	if s.leaderTTL <= 0 {
+		return nil, nil
+	}
+
+	locker, ok := s.store.(LeaderLocker)
+	if !ok {
+		return nil, &SchedulerError{Op: "leader lock", Cause: ErrNotSupported}
+	}
+	leader := &leaderLock{locker: locker, ttl: s.leaderTTL}
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		s.runLeaderLock(ctx, leader)
+	}()
+
+	return leader, nil
+}
+
+// RunWithDone starts the scheduler like Run for callers that don't need the exit reason, the
+// returned channel closes when shutdown is complete
+func (s *Scheduler[T]) RunWithDone(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	errs := s.Run(ctx)
+
+	go func() {
+		<-errs
+		close(done)
+	}()
+
+	return done
+}
+
+// exitError returns why the scheduler stopped, ordinary cancellation of the caller's context is a clean exit
+func exitError(parent, ctx context.Context) error {
+	if cause := context.Cause(parent); cause != nil {
+		if errors.Is(cause, context.Canceled) || errors.Is(cause, context.DeadlineExceeded) {
+			return nil
 		}
+		return cause
+	}
 
-		// Update job with retry logic
-		_, updateErr := backoff.Retry(ctx, func() (any, error) {
-			err := s.store.UpdateJob(job)
-			return nil, err
-		}, backoff.WithNotify(func(err error, d time.Duration) {
-			s.log.Error("failed to update job, retrying...", "job-id", job.Id, "error", err, "duration", d)
-		}))
+	// The scheduler cancelled itself, with a nil cause for an idle shutdown
+	if cause := context.Cause(ctx); !errors.Is(cause, context.Canceled) {
+		return cause
+	}
+	return nil
+}
+
+// queueSize returns how many jobs the workers can take at once, batch workers need room to fill a batch each
+func (s *Scheduler[T]) queueSize() int {
+	if s.batchHandler != nil {
+		return s.workerCount * s.batchSize
+	}
+	return s.workers()
+}
+
+// workers returns the current number of workers
+func (s *Scheduler[T]) workers() int {
+	if pool := s.adaptivePool(); pool != nil {
+		return int(pool.size.Load())
+	}
+	return s.workerCount
+}
+
+// queueCapacity returns the capacity of the dispatch channel, enough for the largest worker pool
+func (s *Scheduler[T]) queueCapacity() int {
+	if pool := s.adaptivePool(); pool != nil {
+		return pool.max
+	}
+	return s.queueSize()
+}
 
-		if updateErr != nil {
-			s.log.Error("failed to update job after retries", "job-id", job.Id, "error", updateErr)
+// worker processes jobs until the channel is closed or quit, nil for workers that run until shutdown, is closed
+// It returns the errors of saving results that failed once shutdown began
+func (s *Scheduler[T]) worker(ctx context.Context, workerId int, jobs chan *Job[T], quit <-chan struct{}) error {
+	if !s.startWorker(ctx, workerId) {
+		return nil
+	}
+	defer s.stopWorker(workerId)
+
+	var errs []error
+	for {
+		select {
+		case job, ok := <-jobs:
+			if !ok {
+				s.log.Debug("worker finished", "worker-id", workerId)
+				return errors.Join(errs...)
+			}
+			if err := s.processJob(ctx, job, workerId); err != nil && ctx.Err() != nil {
+				errs = append(errs, err)
+			}
+		case <-quit:
+			s.log.Debug("worker stopped by pool scale down", "worker-id", workerId)
+			return errors.Join(errs...)
 		}
 	}
+}
+
+// processJob runs the handler for a single job and records the result, returning the error of saving it
+func (s *Scheduler[T]) processJob(ctx context.Context, job *Job[T], workerId int) error {
+	startTime := time.Now()
+	s.log.Debug("processing job", "event", EventJobStarted, "job-id", job.Id, "worker-id", workerId)
+
+	// Pass job by value to prevent modifications
+	s.beginJobs(job)
+	value, err := s.openPayload(ctx, job)
+	stopRefresh := s.startVisibilityRefresh(ctx, job)
+	if err == nil {
+		err = s.chaos.handlerFault()
+	}
+	if err == nil {
+		err = s.jobHandler(s.withCheckpoint(s.extractContext(ctx, value), job), value)
+	}
+	stopRefresh()
+	saveErr := s.finishJob(ctx, job, err, workerId, time.Since(startTime))
+	s.endJobs(job)
+
+	return saveErr
+}
+
+// inlineDispatch reports whether jobs are handled in the fetch loop, which needs a single worker
+func (s *Scheduler[T]) inlineDispatch() bool {
+	if !s.inline {
+		return false
+	}
+	if s.workerCount != 1 || s.batchHandler != nil || s.pool != nil {
+		s.log.Warn("inline dispatch needs a single worker, no batch handler and no adaptive pool, dispatching through workers", "workers", s.workerCount)
+		return false
+	}
+	return true
+}
+
+// beginJobs marks jobs as being processed
+func (s *Scheduler[T]) beginJobs(jobs ...*Job[T]) {
+	s.activeJobs.Add(int64(len(jobs)))
+
+	now := time.Now()
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+	for _, job := range jobs {
+		s.inFlight[job.Id] = &inFlightJob{startedAt: now}
+	}
+}
+
+// endJobs marks jobs as no longer being processed
+func (s *Scheduler[T]) endJobs(jobs ...*Job[T]) {
+	s.activeJobs.Add(-int64(len(jobs)))
+
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+	for _, job := range jobs {
+		delete(s.inFlight, job.Id)
+	}
+}
+
+// lockJob serializes updates to a job being processed, e.g. checkpoints and visibility refreshes,
+// without holding up updates to other jobs. It returns the func releasing the lock.
+func (s *Scheduler[T]) lockJob(job *Job[T]) (unlock func()) {
+	s.inFlightMu.Lock()
+	state, ok := s.inFlight[job.Id]
+	s.inFlightMu.Unlock()
+
+	if !ok {
+		// The job is done, so nothing else updates it
+		return func() {}
+	}
+
+	state.mu.Lock()
+	return state.mu.Unlock
+}
+
+// startWorker runs the worker start hook, returning false if the worker must exit
+func (s *Scheduler[T]) startWorker(ctx context.Context, workerId int) bool {
+	if s.onWorkerStart == nil {
+		return true
+	}
+
+	if err := s.onWorkerStart(ctx, workerId); err != nil {
+		s.log.Error("worker start hook failed, worker exiting", "worker-id", workerId, "error", err)
+		return false
+	}
+
+	return true
+}
+
+// stopWorker runs the worker stop hook
+func (s *Scheduler[T]) stopWorker(workerId int) {
+	if s.onWorkerStop != nil {
+		s.onWorkerStop(workerId)
+	}
+}
+
+// finishJob records the handler result and persists the job's terminal state, returning the error
+// of saving it unless it is saved from the completion queue
+func (s *Scheduler[T]) finishJob(ctx context.Context, job *Job[T], err error, workerId int, duration time.Duration) error {
+	decision := s.classify(err)
+
+	// Update job status based on result
+	if err != nil && decision.Retry && job.Attempts < s.maxAttempts {
+		processAfter := time.Now().Add(s.retryDelay(job.Attempts, decision.Delay))
+		s.log.Info("failed to process job, retrying", "event", EventJobRetried, "job-id", job.Id, "worker-id", workerId, "duration", fmt.Sprintf("%.2fs", duration.Seconds()), "attempt", job.Attempts, "retry-at", processAfter, "error", err)
+		job.MakeRetry(processAfter)
+	} else if err != nil {
+		reason := s.terminalReason(job, err, decision)
+		s.log.Info("failed to process job", "event", EventJobFailed, "job-id", job.Id, "worker-id", workerId, "duration", fmt.Sprintf("%.2fs", duration.Seconds()), "reason", reason, "error", err)
+		job.MakeFailedWithReason(reason)
+	} else if job.Repeats() {
+		skipped := s.missedRuns(job, time.Now())
+		job.MakeRepeat(skipped)
+		s.log.Info("job run completed, repeating", "event", EventJobRepeated, "job-id", job.Id, "worker-id", workerId, "duration", fmt.Sprintf("%.2fs", duration.Seconds()), "remaining-runs", job.RemainingRuns, "skipped-runs", skipped, "next-run", job.ProcessAfter)
+	} else {
+		s.log.Info("job completed", "event", EventJobCompleted, "job-id", job.Id, "worker-id", workerId, "duration", fmt.Sprintf("%.2fs", duration.Seconds()))
+		job.MakeCompleted()
+	}
+
+	// Pools run outside the circuit breaker, which only guards the shared workers
+	if s.breaker != nil && !s.pooledType(job.Type) {
+		if state, changed := s.breaker.record(err, time.Now()); changed {
+			s.log.Info("circuit breaker state changed", "state", state.String())
+		}
+	}
+
+	s.recordTypeResult(job.Type, err)
+	s.recordConsecutiveFailure(job.Type, err)
+
+	if q := s.completions.Load(); q != nil {
+		q.pending <- completion[T]{job: job, err: err, finishedAt: time.Now()}
+		return nil
+	}
+
+	return s.saveResult(ctx, job, err)
+}
+
+// saveResult persists the job's new state and follows up on it, returning the error of saving it
+func (s *Scheduler[T]) saveResult(ctx context.Context, job *Job[T], err error) error {
+	// Update job with retry logic
+	saveErr := s.updateJob(ctx, job, "update job")
+	if saveErr == nil {
+		s.notifyWaiter(job)
+		// Only results that were saved are reported
+		s.sendCallback(ctx, *job, err)
+	}
+
+	s.cronRunFinished(job)
+	s.funcRunFinished(job)
+
+	return saveErr
+}
+
+// classify decides whether a handler error is worth retrying, errors are retryable by default
+func (s *Scheduler[T]) classify(err error) RetryDecision {
+	if err == nil || s.errorClassifier == nil {
+		return RetryDecision{Retry: true}
+	}
+	return s.errorClassifier(err)
+}
+
+// retryDelay returns how long to wait before the next attempt, including any wait for the retry budget
+// A positive override replaces the configured backoff
+func (s *Scheduler[T]) retryDelay(attempt int, override time.Duration) time.Duration {
+	delay := override
+	if delay <= 0 && s.retryBackoff != nil {
+		delay = s.retryBackoff(attempt)
+	}
+
+	if s.retryBudget != nil {
+		delay += s.retryBudget.reserve(time.Now())
+	}
+
+	return delay
+}
+
+// sleepContext pauses for d or until the context is cancelled
+func sleepContext(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// updateJob persists the job with exponential backoff, op describes the update in log messages
+func (s *Scheduler[T]) updateJob(ctx context.Context, job *Job[T], op string) error {
+	var storeErr error
+	_, err := backoff.Retry(ctx, func() (any, error) {
+		storeErr = s.store.UpdateJob(job)
+		if errors.Is(storeErr, ErrConcurrentModification) {
+			// Retrying with the same stale copy can't succeed
+			return nil, backoff.Permanent(storeErr)
+		}
+		return nil, storeErr
+	}, backoff.WithNotify(func(err error, d time.Duration) {
+		s.log.Error("failed to "+op+", retrying...", "job-id", job.Id, "error", err, "duration", d)
+	}))
+	if err != nil {
+		// Report the store's error rather than the cancellation that stopped retrying during shutdown
+		err = &SchedulerError{Op: op, JobID: job.Id, Cause: storeErr}
+		s.log.Error("failed to "+op+" after retries", "job-id", job.Id, "error", err)
+	}
+
+	return err
+}
+
+// releaseUndispatched makes fetched jobs that never reached the channel visible again on shutdown,
+// returning the errors of jobs left invisible
+func (s *Scheduler[T]) releaseUndispatched(ctx context.Context, entries []*Job[T]) error {
+	s.log.Info("shutting down scheduler... making undispatched jobs visible", "undispatched-jobs", len(entries))
+	var errs []error
+	for _, undispatched := range entries {
+		if err := s.releaseJob(ctx, undispatched); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// releaseJob makes a fetched but unprocessed job immediately visible again
+func (s *Scheduler[T]) releaseJob(ctx context.Context, job *Job[T]) error {
+	job.Status = "pending"
+	job.MakeVisible()
+	if err := s.updateJob(ctx, job, "make unprocessed job visible"); err != nil {
+		return err
+	}
 
-	s.log.Debug("worker finished", "worker-id", workerId)
+	s.log.Debug("made unprocessed job visible", "event", EventJobReleased, "job-id", job.Id)
+	return nil
 }
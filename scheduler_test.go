@@ -0,0 +1,709 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func noopHandler[T any](ctx context.Context, job scheduler.Job[T]) error {
+	return nil
+}
+
+func TestSubmitJobSizeLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		size    int
+		wantErr error
+	}{
+		{name: "10MB payload over a 1MB limit", limit: 1 << 20, size: 10 << 20, wantErr: scheduler.ErrPayloadTooLarge},
+		{name: "payload under the limit", limit: 1 << 20, size: 1 << 10},
+		{name: "no limit", size: 10 << 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore[string]()
+			var opts []scheduler.Option[string]
+			if tt.limit > 0 {
+				opts = append(opts, scheduler.WithJobSizeLimit[string](tt.limit))
+			}
+			s := scheduler.NewScheduler(store, 1, time.Second, time.Minute, noopHandler[string], discardLogger(), opts...)
+
+			err := s.SubmitJob(s.NewJob(time.Now(), strings.Repeat("x", tt.size)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SubmitJob() error = %v, want %v", err, tt.wantErr)
+			}
+
+			wantLen := 1
+			if tt.wantErr != nil {
+				wantLen = 0
+				var schedErr *scheduler.SchedulerError
+				if !errors.As(err, &schedErr) || schedErr.Op != "submit job" {
+					t.Fatalf("SubmitJob() error = %#v, want a submit job SchedulerError", err)
+				}
+			}
+			if store.Len() != wantLen {
+				t.Fatalf("store holds %d jobs, want %d", store.Len(), wantLen)
+			}
+		})
+	}
+}
+
+func TestFetchLoopSleeps(t *testing.T) {
+	tests := []struct {
+		name       string
+		fail       bool
+		interval   time.Duration
+		errorOpt   scheduler.Option[string]
+		fetches    int             // Fetches to observe, a failed fetch retries the store a few times
+		wantPauses []time.Duration // Pause before each fetch after the first
+	}{
+		{
+			name:       "empty fetches pause for the polling interval",
+			interval:   100 * time.Millisecond,
+			fetches:    4,
+			wantPauses: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond},
+		},
+		{
+			name:       "failed fetches double the error interval",
+			fail:       true,
+			interval:   time.Hour,
+			errorOpt:   scheduler.WithErrorInterval[string](200*time.Millisecond, time.Second),
+			fetches:    3,
+			wantPauses: []time.Duration{200 * time.Millisecond, 400 * time.Millisecond},
+		},
+		{
+			name:       "error interval is capped",
+			fail:       true,
+			interval:   time.Hour,
+			errorOpt:   scheduler.WithErrorInterval[string](200*time.Millisecond, 300*time.Millisecond),
+			fetches:    3,
+			wantPauses: []time.Duration{200 * time.Millisecond, 300 * time.Millisecond},
+		},
+	}
+
+	const slack = 150 * time.Millisecond
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Each store call is recorded by when it started and ended
+			type call struct{ start, end time.Time }
+			calls := make(chan call, 100)
+			store := &hookStore[string]{MemoryStore: storage.NewMemoryStore[string]()}
+			store.onFetch = func() error {
+				c := call{start: time.Now()}
+				defer func() { c.end = time.Now(); calls <- c }()
+				if tt.fail {
+					return errors.New("store unavailable")
+				}
+				return nil
+			}
+
+			var opts []scheduler.Option[string]
+			if tt.errorOpt != nil {
+				opts = append(opts, tt.errorOpt)
+			}
+			s := scheduler.NewScheduler(store, 1, tt.interval, time.Minute, noopHandler[string], discardLogger(), opts...)
+			s.Run(ctx)
+
+			// A failed fetch retries quickly in between, the pauses that matter follow the last try
+			tries := 1
+			if tt.fail {
+				tries = 3
+			}
+			var prevEnd time.Time
+			for i := range tt.fetches {
+				var first, last call
+				for try := range tries {
+					select {
+					case c := <-calls:
+						if try == 0 {
+							first = c
+						}
+						last = c
+					case <-time.After(10 * time.Second):
+						t.Fatalf("fetch %d didn't happen", i)
+					}
+				}
+
+				if i > 0 {
+					pause, want := first.start.Sub(prevEnd), tt.wantPauses[i-1]
+					if pause < want || pause > want+slack {
+						t.Errorf("pause before fetch %d = %s, want %s", i, pause, want)
+					}
+				}
+				prevEnd = last.end
+			}
+		})
+	}
+}
+
+func TestRunStopsDuringErrorPause(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	failed := make(chan struct{}, 10)
+	store := &hookStore[string]{MemoryStore: storage.NewMemoryStore[string]()}
+	store.onFetch = func() error {
+		failed <- struct{}{}
+		return errors.New("store unavailable")
+	}
+
+	s := scheduler.NewScheduler(store, 1, time.Hour, time.Minute, noopHandler[string], discardLogger(),
+		scheduler.WithErrorInterval[string](time.Hour, time.Hour),
+	)
+	done := s.Run(ctx)
+
+	// Wait for the quick retries of the first fetch, so the loop pauses for the error interval
+	for range 3 {
+		<-failed
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduler didn't stop while pausing after a failed fetch")
+	}
+}
+
+func TestStartupDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		workers   int
+		opts      []scheduler.Option[string]
+		wantDelay time.Duration // Minimum time from Run to the first fetch
+	}{
+		{name: "no delay", workers: 3},
+		{name: "startup delay", workers: 1, opts: []scheduler.Option[string]{scheduler.WithStartupDelay[string](300 * time.Millisecond)}, wantDelay: 300 * time.Millisecond},
+		{name: "staggered workers", workers: 3, opts: []scheduler.Option[string]{scheduler.WithWorkerStagger[string](100 * time.Millisecond)}, wantDelay: 200 * time.Millisecond},
+	}
+
+	const slack = 150 * time.Millisecond
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			fetched := make(chan time.Time, 10)
+			store := &hookStore[string]{MemoryStore: storage.NewMemoryStore[string]()}
+			store.onFetch = func() error {
+				fetched <- time.Now()
+				return nil
+			}
+
+			s := scheduler.NewScheduler(store, tt.workers, time.Hour, time.Minute, noopHandler[string], discardLogger(), tt.opts...)
+			started := time.Now()
+			s.Run(ctx)
+
+			select {
+			case at := <-fetched:
+				if delay := at.Sub(started); delay < tt.wantDelay || delay > tt.wantDelay+slack {
+					t.Fatalf("first fetch after %s, want %s", delay, tt.wantDelay)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no fetch happened")
+			}
+		})
+	}
+}
+
+func TestShutdownReleasesPrefetchedJobs(t *testing.T) {
+	tests := []struct {
+		name     string
+		workers  int
+		prefetch int
+	}{
+		{name: "prefetch beyond the channel", workers: 2, prefetch: 20},
+		{name: "single worker", workers: 1, prefetch: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Workers hold their first job until shutdown began, so dispatch blocks on a full channel
+			started := make(chan string, 100)
+			release := make(chan struct{})
+			handler := func(ctx context.Context, job scheduler.Job[int]) error {
+				started <- job.Id
+				<-release
+				return nil
+			}
+
+			store := storage.NewMemoryStore[int]()
+			s := scheduler.NewScheduler(store, tt.workers, 10*time.Millisecond, time.Hour, handler, discardLogger(),
+				scheduler.WithFetchStrategy[int](scheduler.PrefetchFixed(tt.prefetch)),
+			)
+			total := tt.workers + tt.prefetch + 10
+			for i := range total {
+				if err := s.SubmitJob(s.NewJob(time.Now(), i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			done := s.Run(ctx)
+
+			for range tt.workers {
+				<-started
+			}
+			// Let dispatch fill the channel and block with the rest of the batch
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+			close(release)
+			if err := <-done; err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			var completed, visible int
+			for _, job := range store.GetJobs() {
+				switch {
+				case job.Status == "completed":
+					completed++
+				case job.Status == "pending" && job.IsVisible():
+					visible++
+				default:
+					t.Errorf("job %s left %s and invisible until %v", job.Id, job.Status, job.VisibleAfter)
+				}
+			}
+			// Workers may still take queued jobs while shutting down, every other job is released
+			handled := tt.workers + len(started)
+			if completed != handled || visible != total-handled {
+				t.Fatalf("%d jobs completed and %d visible, want %d and %d", completed, visible, handled, total-handled)
+			}
+		})
+	}
+}
+
+func TestFIFOProcessingOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []scheduler.Option[int]
+	}{
+		{name: "worker channel"},
+		{name: "inline dispatch", opts: []scheduler.Option[int]{scheduler.WithInlineDispatch[int]()}},
+		{name: "prefetching", opts: []scheduler.Option[int]{scheduler.WithFetchStrategy[int](scheduler.PrefetchFixed(5))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var order []int
+			handler := func(ctx context.Context, job scheduler.Job[int]) error {
+				order = append(order, job.Payload)
+				return nil
+			}
+
+			store := storage.NewMemoryStore[int]()
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger(), tt.opts...)
+
+			// Every job is due at the same instant, only the order they were added in sets them apart
+			due := time.Now()
+			var last *scheduler.Job[int]
+			for i := range 20 {
+				last = s.NewJob(due, i)
+				if err := s.SubmitJob(last); err != nil {
+					t.Fatal(err)
+				}
+			}
+			done := s.Run(ctx)
+
+			waitForStatus(t, store, last.Id, "completed", 5*time.Second)
+			cancel()
+			<-done
+
+			for i, payload := range order {
+				if payload != i {
+					t.Fatalf("processing order = %v, want submission order", order)
+				}
+			}
+			if len(order) != 20 {
+				t.Fatalf("processed %d jobs, want 20", len(order))
+			}
+		})
+	}
+}
+
+func TestMaxIdleTime(t *testing.T) {
+	tests := []struct {
+		name string
+		jobs int
+	}{
+		{name: "drains the queue then stops", jobs: 5},
+		{name: "stops with nothing to do"},
+	}
+
+	const (
+		maxIdleTime = 300 * time.Millisecond
+		epsilon     = 150 * time.Millisecond
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := storage.NewMemoryStore[int]()
+			s := scheduler.NewScheduler(store, 2, 20*time.Millisecond, time.Minute, noopHandler[int], discardLogger(),
+				scheduler.WithMaxIdleTime[int](maxIdleTime),
+			)
+			var jobs []*scheduler.Job[int]
+			for i := range tt.jobs {
+				job := s.NewJob(time.Now(), i)
+				if err := s.SubmitJob(job); err != nil {
+					t.Fatal(err)
+				}
+				jobs = append(jobs, job)
+			}
+
+			started := time.Now()
+			done := s.Run(context.Background())
+			for _, job := range jobs {
+				waitForStatus(t, store, job.Id, "completed", 5*time.Second)
+			}
+
+			// The idle time counts from the last fetch that found jobs, at the latest when they all completed
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+				if ran := time.Since(started); ran < maxIdleTime {
+					t.Fatalf("stopped after %s, before the max idle time", ran)
+				}
+			case <-time.After(maxIdleTime + epsilon):
+				t.Fatal("scheduler didn't stop after the max idle time")
+			}
+		})
+	}
+}
+
+func TestRunExitError(t *testing.T) {
+	errStoreGone := errors.New("store permanently unavailable")
+
+	tests := []struct {
+		name    string
+		stop    func() (context.Context, func())
+		wantErr error
+	}{
+		{
+			name: "cancelled",
+			stop: func() (context.Context, func()) {
+				return context.WithCancel(context.Background())
+			},
+		},
+		{
+			name: "deadline exceeded",
+			stop: func() (context.Context, func()) {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				return ctx, func() { <-ctx.Done(); cancel() }
+			},
+		},
+		{
+			name: "cancelled with a cause",
+			stop: func() (context.Context, func()) {
+				ctx, cancel := context.WithCancelCause(context.Background())
+				return ctx, func() { cancel(errStoreGone) }
+			},
+			wantErr: errStoreGone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, stop := tt.stop()
+			s := scheduler.NewScheduler(storage.NewMemoryStore[string](), 1, 10*time.Millisecond, time.Minute, noopHandler[string], discardLogger())
+			done := s.Run(ctx)
+			stop()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Run() error = %v, want %v", err, tt.wantErr)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("scheduler didn't stop")
+			}
+		})
+	}
+}
+
+func TestRunWithDone(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	s := scheduler.NewScheduler(storage.NewMemoryStore[string](), 1, 10*time.Millisecond, time.Minute, noopHandler[string], discardLogger())
+	done := s.RunWithDone(ctx)
+
+	// The exit reason is dropped, the channel only closes
+	cancel(errors.New("shutting down"))
+	select {
+	case _, ok := <-done:
+		if ok {
+			t.Fatal("done channel received a value instead of closing")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("done channel didn't close")
+	}
+}
+
+func TestProcessedBy(t *testing.T) {
+	host, err := os.Hostname()
+	if err != nil {
+		t.Skip("no host name:", err)
+	}
+
+	tests := []struct {
+		name string
+		opts []scheduler.Option[string]
+		want string
+	}{
+		{name: "configured instance id", opts: []scheduler.Option[string]{scheduler.WithInstanceID[string]("node-7")}, want: "node-7"},
+		{name: "host name by default", want: host},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The id is saved when the job is claimed, before the handler runs
+			claimedBy := make(chan string, 1)
+			store := &hookStore[string]{MemoryStore: storage.NewMemoryStore[string]()}
+			store.onUpdate = func(job *scheduler.Job[string]) error {
+				if job.Status == "pending" && !job.IsVisible() {
+					select {
+					case claimedBy <- job.ProcessedBy:
+					default:
+					}
+				}
+				return nil
+			}
+
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, noopHandler[string], discardLogger(), tt.opts...)
+			job := s.NewJob(time.Now(), "report")
+			if err := s.SubmitJob(job); err != nil {
+				t.Fatal(err)
+			}
+			s.Run(ctx)
+
+			got := waitForStatus(t, store.MemoryStore, job.Id, "completed", 5*time.Second)
+			if got.ProcessedBy != tt.want {
+				t.Fatalf("completed job ProcessedBy = %q, want %q", got.ProcessedBy, tt.want)
+			}
+			select {
+			case claimed := <-claimedBy:
+				if claimed != tt.want {
+					t.Fatalf("claimed job ProcessedBy = %q, want %q", claimed, tt.want)
+				}
+			default:
+				t.Fatal("job completed without being claimed")
+			}
+		})
+	}
+}
+
+func TestShutdownSurfacesUpdateErrors(t *testing.T) {
+	errUpdate := errors.New("store unavailable")
+
+	tests := []struct {
+		name      string
+		failSaves bool
+		opts      []scheduler.Option[string]
+		wantErr   error
+	}{
+		{name: "result saved", wantErr: nil},
+		{name: "saving the result fails", failSaves: true, wantErr: errUpdate},
+		{name: "saving a queued result fails", failSaves: true, opts: []scheduler.Option[string]{scheduler.WithCompletionQueue[string](4)}, wantErr: errUpdate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The store fails every update once shutdown begins
+			var shuttingDown atomic.Bool
+			store := &hookStore[string]{MemoryStore: storage.NewMemoryStore[string]()}
+			store.onUpdate = func(job *scheduler.Job[string]) error {
+				if tt.failSaves && shuttingDown.Load() {
+					return errUpdate
+				}
+				return nil
+			}
+
+			// The handler is running when shutdown begins and finishes after it
+			started := make(chan struct{})
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				close(started)
+				<-ctx.Done()
+				return nil
+			}
+
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger(), tt.opts...)
+			job := s.NewJob(time.Now(), "report")
+			if err := s.SubmitJob(job); err != nil {
+				t.Fatal(err)
+			}
+			done := s.Run(ctx)
+
+			<-started
+			shuttingDown.Store(true)
+			cancel()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Run() error = %v, want %v", err, tt.wantErr)
+				}
+				var schedErr *scheduler.SchedulerError
+				if tt.wantErr != nil && (!errors.As(err, &schedErr) || schedErr.JobID != job.Id) {
+					t.Fatalf("Run() error = %v, want a SchedulerError for job %s", err, job.Id)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("scheduler didn't stop")
+			}
+		})
+	}
+}
+
+func TestSetInterval(t *testing.T) {
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		changeTo  time.Duration
+		wantError error
+	}{
+		{name: "speed up", interval: 300 * time.Millisecond, changeTo: 50 * time.Millisecond},
+		{name: "slow down", interval: 50 * time.Millisecond, changeTo: 250 * time.Millisecond},
+		{name: "zero rejected", interval: 100 * time.Millisecond, wantError: scheduler.ErrInvalidInterval},
+		{name: "negative rejected", interval: 100 * time.Millisecond, changeTo: -time.Second, wantError: scheduler.ErrInvalidInterval},
+	}
+
+	const slack = 150 * time.Millisecond
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The interval is changed during the second fetch, so the pause after it is the first
+			// to use the new interval
+			var s *scheduler.Scheduler[string]
+			var fetches int
+			setErr := make(chan error, 1)
+			ends := make(chan time.Time, 100)
+			store := &hookStore[string]{MemoryStore: storage.NewMemoryStore[string]()}
+			store.onFetch = func() error {
+				if fetches++; fetches == 2 {
+					setErr <- s.SetInterval(tt.changeTo)
+				}
+				ends <- time.Now()
+				return nil
+			}
+
+			s = scheduler.NewScheduler(store, 1, tt.interval, time.Minute, noopHandler[string], discardLogger())
+			s.Run(ctx)
+
+			var times []time.Time
+			for range 4 {
+				select {
+				case end := <-ends:
+					times = append(times, end)
+				case <-time.After(10 * time.Second):
+					t.Fatalf("only %d fetches happened", len(times))
+				}
+			}
+			if err := <-setErr; !errors.Is(err, tt.wantError) {
+				t.Fatalf("SetInterval(%s) error = %v, want %v", tt.changeTo, err, tt.wantError)
+			}
+
+			// A rejected interval leaves the cadence as it was
+			wantPauses := []time.Duration{tt.interval, tt.changeTo, tt.changeTo}
+			if tt.wantError != nil {
+				wantPauses = []time.Duration{tt.interval, tt.interval, tt.interval}
+			}
+			for i, want := range wantPauses {
+				if pause := times[i+1].Sub(times[i]); pause < want || pause > want+slack {
+					t.Errorf("pause after fetch %d = %s, want %s", i+1, pause, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClaimSkipsJobChangedSinceFetch(t *testing.T) {
+	tests := []struct {
+		name    string
+		jobType string
+		opts    []scheduler.Option[string]
+	}{
+		{name: "shared workers"},
+		{name: "worker pool", jobType: "report", opts: []scheduler.Option[string]{scheduler.WithPool[string]("report", 1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The job is cancelled between fetch and claim, leaving the fetched copy stale
+			var claims atomic.Int32
+			store := &hookStore[string]{MemoryStore: storage.NewMemoryStore[string]()}
+			store.onUpdate = func(job *scheduler.Job[string]) error {
+				if claims.Add(1) == 1 {
+					if _, err := store.TransitionStatus(job.Id, "pending", "cancelled"); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			var handled atomic.Int32
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				handled.Add(1)
+				return nil
+			}
+
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger(), tt.opts...)
+			job := s.NewJob(time.Now(), "report", scheduler.WithJobType[string](tt.jobType))
+			if err := s.SubmitJob(job); err != nil {
+				t.Fatal(err)
+			}
+			s.Run(ctx)
+
+			deadline := time.Now().Add(5 * time.Second)
+			for claims.Load() == 0 && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			// Give a wrongly dispatched job the chance to run
+			time.Sleep(200 * time.Millisecond)
+
+			if handled.Load() != 0 {
+				t.Fatalf("handler ran %d times for a job cancelled before its claim", handled.Load())
+			}
+			if got := store.GetJobs()[job.Id].Status; got != "cancelled" {
+				t.Fatalf("job status = %q, want %q", got, "cancelled")
+			}
+		})
+	}
+}
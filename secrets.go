@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SecretManager encrypts job payloads in the scheduler, so they stay encrypted in the store and are
+// only decrypted by the worker about to handle the job, e.g. with a key held in a KMS
+type SecretManager interface {
+	// Encrypt returns the ciphertext for the given serialized payload
+	Encrypt(ctx context.Context, data []byte) ([]byte, error)
+
+	// Decrypt returns the serialized payload for the given ciphertext
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// NoopSecretManager passes payloads through unchanged, for development and tests
+type NoopSecretManager struct{}
+
+// Encrypt returns data unchanged
+func (NoopSecretManager) Encrypt(ctx context.Context, data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// Decrypt returns ciphertext unchanged
+func (NoopSecretManager) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	return ciphertext, nil
+}
+
+// encrypterSecretManager adapts an Encrypter to a SecretManager
+type encrypterSecretManager struct {
+	enc Encrypter
+}
+
+// EncrypterSecretManager uses enc, e.g. an AESGCMEncrypter, as a SecretManager
+func EncrypterSecretManager(enc Encrypter) SecretManager {
+	return encrypterSecretManager{enc: enc}
+}
+
+func (m encrypterSecretManager) Encrypt(ctx context.Context, data []byte) ([]byte, error) {
+	return m.enc.Encrypt(data)
+}
+
+func (m encrypterSecretManager) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	return m.enc.Decrypt(ciphertext)
+}
+
+// sealPayload replaces the job payload with its encryption in SealedPayload, returning a func that
+// puts the plaintext payload back once the job has been stored
+func (s *Scheduler[T]) sealPayload(ctx context.Context, job *Job[T]) (restore func(), err error) {
+	data, err := json.Marshal(job.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+
+	sealed, err := s.secretManager.Encrypt(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt job payload: %w", err)
+	}
+
+	payload := job.Payload
+	var zero T
+	job.Payload = zero
+	job.SealedPayload = sealed
+
+	return func() {
+		job.Payload = payload
+		job.SealedPayload = nil
+	}, nil
+}
+
+// openPayload returns the copy of the job handed to a handler, with a sealed payload decrypted into
+// Payload. The job itself stays sealed, as stores may write the whole job back on update.
+func (s *Scheduler[T]) openPayload(ctx context.Context, job *Job[T]) (Job[T], error) {
+	value := *job
+	if job.SealedPayload == nil {
+		return value, nil
+	}
+
+	if s.secretManager == nil {
+		return value, errors.New("job payload is encrypted but no secret manager is configured")
+	}
+
+	data, err := s.secretManager.Decrypt(ctx, job.SealedPayload)
+	if err != nil {
+		return value, fmt.Errorf("failed to decrypt job payload: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &value.Payload); err != nil {
+		return value, fmt.Errorf("failed to unmarshal job payload: %w", err)
+	}
+	value.SealedPayload = nil
+
+	return value, nil
+}
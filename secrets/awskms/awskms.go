@@ -0,0 +1,110 @@
+// Package awskms provides a scheduler.SecretManager backed by AWS KMS. It is a separate module so
+// the AWS SDK is only a dependency of applications that use it.
+package awskms
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	scheduler "go-sched"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// KMSClient is the part of the KMS API the secret manager uses, implemented by *kms.Client
+type KMSClient interface {
+	GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// AWSKMSSecretManager encrypts payloads with envelope encryption: every payload is sealed with
+// AES-GCM under a fresh data key generated by KMS, and stored with that key encrypted by the KMS
+// key. Payloads can be larger than the 4 KiB KMS Encrypt accepts, and each one costs a single
+// KMS call to encrypt and to decrypt.
+type AWSKMSSecretManager struct {
+	client            KMSClient
+	keyID             string
+	encryptionContext map[string]string
+}
+
+// Option configures optional AWSKMSSecretManager behaviour
+type Option func(*AWSKMSSecretManager)
+
+// WithEncryptionContext binds every data key to the given KMS encryption context, which must
+// be the same to decrypt and is recorded in CloudTrail
+func WithEncryptionContext(encryptionContext map[string]string) Option {
+	return func(m *AWSKMSSecretManager) {
+		m.encryptionContext = encryptionContext
+	}
+}
+
+// NewAWSKMSSecretManager creates a secret manager encrypting data keys with the KMS key keyID,
+// a key id, ARN or alias
+func NewAWSKMSSecretManager(client KMSClient, keyID string, opts ...Option) *AWSKMSSecretManager {
+	m := &AWSKMSSecretManager{client: client, keyID: keyID}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+var _ scheduler.SecretManager = (*AWSKMSSecretManager)(nil)
+
+// Encrypt seals data under a new data key and returns the encrypted data key, prefixed by its
+// length, followed by the sealed data
+func (m *AWSKMSSecretManager) Encrypt(ctx context.Context, data []byte) ([]byte, error) {
+	key, err := m.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:             aws.String(m.keyID),
+		KeySpec:           types.DataKeySpecAes256,
+		EncryptionContext: m.encryptionContext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generate data key: %w", err)
+	}
+
+	enc, err := scheduler.NewAESGCMEncrypter(key.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := enc.Encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := binary.BigEndian.AppendUint16(nil, uint16(len(key.CiphertextBlob)))
+	out = append(out, key.CiphertextBlob...)
+	return append(out, sealed...), nil
+}
+
+// Decrypt decrypts the data key with KMS and opens the data sealed by Encrypt
+func (m *AWSKMSSecretManager) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 2 {
+		return nil, errors.New("ciphertext too short")
+	}
+	keyLen := int(binary.BigEndian.Uint16(ciphertext))
+	if len(ciphertext) < 2+keyLen {
+		return nil, errors.New("ciphertext too short")
+	}
+	encryptedKey, sealed := ciphertext[2:2+keyLen], ciphertext[2+keyLen:]
+
+	key, err := m.client.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob:    encryptedKey,
+		KeyId:             aws.String(m.keyID),
+		EncryptionContext: m.encryptionContext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decrypt data key: %w", err)
+	}
+
+	enc, err := scheduler.NewAESGCMEncrypter(key.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return enc.Decrypt(sealed)
+}
@@ -0,0 +1,106 @@
+package awskms
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// fakeKMS wraps data keys by XOR with a fixed mask and checks the encryption context
+type fakeKMS struct {
+	mask  byte
+	calls int
+}
+
+func (f *fakeKMS) wrap(key []byte) []byte {
+	out := make([]byte, len(key))
+	for i, b := range key {
+		out[i] = b ^ f.mask
+	}
+	return out
+}
+
+func (f *fakeKMS) GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	f.calls++
+	key := make([]byte, 32)
+	rand.Read(key)
+	// The context is appended to the wrapped key so Decrypt can check it
+	blob := append(f.wrap(key), []byte(params.EncryptionContext["tenant"])...)
+	return &kms.GenerateDataKeyOutput{Plaintext: key, CiphertextBlob: blob}, nil
+}
+
+func (f *fakeKMS) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	f.calls++
+	blob := params.CiphertextBlob
+	if len(blob) < 32 || string(blob[32:]) != params.EncryptionContext["tenant"] {
+		return nil, errors.New("InvalidCiphertextException")
+	}
+	return &kms.DecryptOutput{Plaintext: f.wrap(blob[:32])}, nil
+}
+
+func TestAWSKMSSecretManagerRoundTrip(t *testing.T) {
+	large := bytes.Repeat([]byte("x"), 64<<10)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		encCtx  map[string]string
+		decCtx  map[string]string
+		corrupt bool
+		wantErr bool
+	}{
+		{name: "small payload", data: []byte(`{"token":"secret"}`)},
+		{name: "payload above the KMS encrypt limit", data: large},
+		{name: "empty payload", data: []byte{}},
+		{name: "matching encryption context", data: []byte("a"), encCtx: map[string]string{"tenant": "t1"}, decCtx: map[string]string{"tenant": "t1"}},
+		{name: "mismatched encryption context", data: []byte("a"), encCtx: map[string]string{"tenant": "t1"}, decCtx: map[string]string{"tenant": "t2"}, wantErr: true},
+		{name: "tampered ciphertext", data: []byte("a"), corrupt: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := &fakeKMS{mask: 0x5a}
+
+			ciphertext, err := NewAWSKMSSecretManager(client, "alias/jobs", WithEncryptionContext(tt.encCtx)).Encrypt(ctx, tt.data)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+			if len(tt.data) > 8 && bytes.Contains(ciphertext, tt.data) {
+				t.Fatal("ciphertext contains the plaintext")
+			}
+			if tt.corrupt {
+				ciphertext[len(ciphertext)-1] ^= 0xff
+			}
+
+			decCtx := tt.decCtx
+			if decCtx == nil {
+				decCtx = maps.Clone(tt.encCtx)
+			}
+			got, err := NewAWSKMSSecretManager(client, "alias/jobs", WithEncryptionContext(decCtx)).Decrypt(ctx, ciphertext)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decrypt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, tt.data) {
+				t.Fatalf("Decrypt() = %d bytes, want %d", len(got), len(tt.data))
+			}
+			if client.calls != 2 {
+				t.Fatalf("KMS calls = %d, want one per Encrypt and Decrypt", client.calls)
+			}
+		})
+	}
+}
+
+func TestAWSKMSSecretManagerShortCiphertext(t *testing.T) {
+	m := NewAWSKMSSecretManager(&fakeKMS{}, "alias/jobs")
+	for _, ciphertext := range [][]byte{nil, {0}, {0, 40, 1, 2}} {
+		if _, err := m.Decrypt(context.Background(), ciphertext); err == nil {
+			t.Errorf("Decrypt(%v) succeeded, want an error", ciphertext)
+		}
+	}
+}
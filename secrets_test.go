@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+type secretPayload struct {
+	Token string `json:"token"`
+}
+
+func TestSecretManagerRoundTrip(t *testing.T) {
+	enc, err := NewAESGCMEncrypter(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		sm         SecretManager
+		wantSealed bool // Whether the sealed payload differs from the serialized payload
+	}{
+		{name: "noop", sm: NoopSecretManager{}},
+		{name: "encrypter", sm: EncrypterSecretManager(enc), wantSealed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			data := []byte(`{"token":"secret"}`)
+
+			ciphertext, err := tt.sm.Encrypt(ctx, data)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+			if sealed := !bytes.Equal(ciphertext, data); sealed != tt.wantSealed {
+				t.Fatalf("Encrypt() changed data = %v, want %v", sealed, tt.wantSealed)
+			}
+			plaintext, err := tt.sm.Decrypt(ctx, ciphertext)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			if !bytes.Equal(plaintext, data) {
+				t.Fatalf("Decrypt() = %q, want %q", plaintext, data)
+			}
+
+			s := &Scheduler[secretPayload]{secretManager: tt.sm}
+			job := &Job[secretPayload]{Id: "job-1", Payload: secretPayload{Token: "secret"}}
+			restore, err := s.sealPayload(ctx, job)
+			if err != nil {
+				t.Fatalf("sealPayload() error = %v", err)
+			}
+			if job.Payload.Token != "" || job.SealedPayload == nil {
+				t.Fatalf("sealPayload() left payload %+v, sealed %q", job.Payload, job.SealedPayload)
+			}
+
+			opened, err := s.openPayload(ctx, job)
+			if err != nil {
+				t.Fatalf("openPayload() error = %v", err)
+			}
+			if opened.Payload.Token != "secret" || opened.SealedPayload != nil {
+				t.Fatalf("openPayload() = %+v, sealed %q", opened.Payload, opened.SealedPayload)
+			}
+			if job.SealedPayload == nil {
+				t.Fatal("openPayload() unsealed the stored job")
+			}
+
+			restore()
+			if job.Payload.Token != "secret" || job.SealedPayload != nil {
+				t.Fatalf("restore() left payload %+v, sealed %q", job.Payload, job.SealedPayload)
+			}
+		})
+	}
+}
+
+func TestOpenPayloadWithoutSecretManager(t *testing.T) {
+	s := &Scheduler[secretPayload]{}
+	job := &Job[secretPayload]{Id: "job-1", SealedPayload: []byte("sealed")}
+	if _, err := s.openPayload(context.Background(), job); err == nil {
+		t.Fatal("openPayload() succeeded without a secret manager")
+	}
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Stats is a point-in-time snapshot of the scheduler's backlog
+type Stats struct {
+	// InvisibleJobs counts jobs being processed or awaiting visibility recovery. A count that
+	// keeps growing while throughput stays flat points to stuck or crashed workers.
+	InvisibleJobs int
+
+	// AvgPayloadBytes is the running average size of the payloads serialized by the stores, set
+	// with WithPayloadMetrics
+	AvgPayloadBytes float64
+}
+
+// Stats queries the store for the current backlog, the store must implement InvisibleCounter
+func (s *Scheduler[T]) Stats() (Stats, error) {
+	counter, ok := s.store.(InvisibleCounter)
+	if !ok {
+		return Stats{}, &SchedulerError{Op: "stats", Cause: fmt.Errorf("count invisible jobs: %w", ErrNotSupported)}
+	}
+
+	invisible, err := counter.CountInvisible()
+	if err != nil {
+		return Stats{}, &SchedulerError{Op: "stats", Cause: err}
+	}
+
+	stats := Stats{InvisibleJobs: invisible}
+	if s.payloadMetrics != nil {
+		stats.AvgPayloadBytes = s.payloadMetrics.Average()
+	}
+
+	return stats, nil
+}
+
+// SchedulerStats describes the fetch loop's last fetch and its queue, passed to the WithDynamicInterval
+// function
+type SchedulerStats struct {
+	Requested  int // Jobs the cycle asked the store for
+	Fetched    int // Jobs the store returned, as many as were requested suggests more are due
+	QueuedJobs int // Fetched jobs waiting for a free worker
+	ActiveJobs int // Jobs being processed by the shared workers
+	Workers    int // Shared workers
+}
+
+// DynamicIntervalFn returns how long the fetch loop sleeps after a cycle, it must not block
+type DynamicIntervalFn func(ctx context.Context, stats SchedulerStats) time.Duration
+
+// cycleDelay returns how long the fetch loop sleeps after a cycle, defaultDelay unless
+// WithDynamicInterval is set. cycle holds the last fetch's counts, the queue is sampled now.
+func (s *Scheduler[T]) cycleDelay(ctx context.Context, cycle SchedulerStats, queued int, defaultDelay time.Duration) time.Duration {
+	if s.dynamicInterval == nil {
+		return defaultDelay
+	}
+
+	cycle.QueuedJobs = queued
+	cycle.ActiveJobs = s.sharedActiveJobs()
+	cycle.Workers = s.queueSize()
+	return max(s.dynamicInterval(ctx, cycle), 0)
+}
@@ -0,0 +1,99 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+	"go-sched/storage/noop"
+)
+
+func TestStatsInvisibleJobs(t *testing.T) {
+	store := storage.NewMemoryStore[string]()
+	s := scheduler.NewScheduler(store, 1, time.Second, time.Minute, noopHandler[string], discardLogger())
+	for range 5 {
+		if err := s.SubmitJob(s.NewJob(time.Now().Add(-time.Second), "work")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Jobs claimed by a worker that crashed stay hidden until their visibility timeout expires
+	for range 3 {
+		if _, ok, err := store.ClaimNext(time.Minute); err != nil || !ok {
+			t.Fatalf("ClaimNext() = %v, %v", ok, err)
+		}
+	}
+
+	stats, err := s.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.InvisibleJobs != 3 {
+		t.Fatalf("InvisibleJobs = %d, want 3", stats.InvisibleJobs)
+	}
+}
+
+func TestStatsNotSupported(t *testing.T) {
+	s := scheduler.NewScheduler(noop.NewNoopStore(""), 1, time.Second, time.Minute, noopHandler[string], discardLogger())
+	if _, err := s.Stats(); !errors.Is(err, scheduler.ErrNotSupported) {
+		t.Fatalf("Stats() error = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestDynamicInterval(t *testing.T) {
+	// No sleep while jobs are pending, sleep for an hour otherwise
+	noSleepWhilePending := func(ctx context.Context, stats scheduler.SchedulerStats) time.Duration {
+		if stats.Fetched > 0 || stats.QueuedJobs+stats.ActiveJobs > 0 {
+			return 0
+		}
+		return time.Hour
+	}
+
+	tests := []struct {
+		name        string
+		opts        []scheduler.Option[int]
+		wantAllDone bool // Whether every job is done within the deadline
+	}{
+		{name: "zero interval while jobs are pending", opts: []scheduler.Option[int]{scheduler.WithDynamicInterval[int](noSleepWhilePending)}, wantAllDone: true},
+		{name: "polling interval while workers are busy"},
+	}
+
+	const total = 100
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var handled atomic.Int64
+			handler := func(ctx context.Context, job scheduler.Job[int]) error {
+				time.Sleep(time.Millisecond)
+				handled.Add(1)
+				return nil
+			}
+
+			// The hour long polling interval stalls any cycle that sleeps
+			store := storage.NewMemoryStore[int]()
+			s := scheduler.NewScheduler(store, 4, time.Hour, time.Minute, handler, discardLogger(), tt.opts...)
+			for i := range total {
+				if err := s.SubmitJob(s.NewJob(time.Now().Add(-time.Second), i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s.Run(ctx)
+
+			deadline := time.Now().Add(2 * time.Second)
+			for handled.Load() < total && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if allDone := handled.Load() == total; allDone != tt.wantAllDone {
+				t.Fatalf("%d of %d jobs handled within 2s, want all done = %v", handled.Load(), total, tt.wantAllDone)
+			}
+		})
+	}
+}
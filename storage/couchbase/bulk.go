@@ -0,0 +1,94 @@
+package couchbase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/couchbase/gocb/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultBulkDeleteBatchSize is how many removes BulkDelete sends in a single bulk operation
+const defaultBulkDeleteBatchSize = 100
+
+// BatchError reports the jobs a bulk operation failed for, keyed by job id
+type BatchError struct {
+	Errors map[string]error
+}
+
+func (e *BatchError) Error() string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	if len(ids) == 1 {
+		return fmt.Sprintf("job %s: %v", ids[0], e.Errors[ids[0]])
+	}
+	return fmt.Sprintf("%d jobs failed, first job %s: %v", len(ids), ids[0], e.Errors[ids[0]])
+}
+
+// Unwrap returns the errors of the failed jobs, so errors.Is matches e.g. gocb.ErrDocumentNotFound
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// BulkDelete removes the jobs with the given ids using Couchbase bulk operations, sending batches
+// of WithBulkDeleteBatchSize removes (default 100) in parallel. It returns the number of jobs
+// deleted and a *BatchError for the ids that couldn't be, e.g. because the job doesn't exist.
+func (s *CouchbaseStore[T]) BulkDelete(ctx context.Context, ids []string) (int, error) {
+	batchSize := s.cfg.bulkDeleteBatch
+	if batchSize <= 0 {
+		batchSize = defaultBulkDeleteBatchSize
+	}
+
+	collection := s.bucket.Scope(s.scopeName).Collection(s.collectionName)
+
+	var mu sync.Mutex
+	deleted := 0
+	failed := make(map[string]error)
+
+	var g errgroup.Group
+	for start := 0; start < len(ids); start += batchSize {
+		batch := ids[start:min(start+batchSize, len(ids))]
+
+		g.Go(func() error {
+			removes := make([]*gocb.RemoveOp, len(batch))
+			ops := make([]gocb.BulkOp, len(batch))
+			for i, id := range batch {
+				removes[i] = &gocb.RemoveOp{ID: s.docKey(id)}
+				ops[i] = removes[i]
+			}
+
+			err := collection.Do(ops, &gocb.BulkOpOptions{Context: ctx})
+
+			mu.Lock()
+			defer mu.Unlock()
+			for i, id := range batch {
+				switch {
+				case err != nil:
+					failed[id] = err
+				case removes[i].Err != nil:
+					failed[id] = removes[i].Err
+				default:
+					deleted++
+				}
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	if len(failed) > 0 {
+		return deleted, &BatchError{Errors: failed}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,143 @@
+package couchbase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	scheduler "go-sched"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+const (
+	// claimCandidates is how many of the next jobs in fetch order ClaimNext tries per query, so
+	// claimers racing for the first job can fall back to the next ones without querying again
+	claimCandidates = 10
+	// claimRounds bounds how many times ClaimNext queries candidates when it loses all of them,
+	// as the query index may keep returning jobs that were already claimed
+	claimRounds = 3
+)
+
+// ClaimNext makes the next due, visible pending job in fetch order invisible for visibilityTimeout
+// and returns it, or false if no job is due. Each candidate is read and written back with the same
+// CAS, so a job claimed concurrently makes the write fail and the next candidate is tried. Under
+// heavy contention false may be returned while due jobs remain, call again to claim them.
+func (s *CouchbaseStore[T]) ClaimNext(visibilityTimeout time.Duration) (*scheduler.Job[T], bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	for range claimRounds {
+		ids, err := s.claimCandidates(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(ids) == 0 {
+			return nil, false, nil
+		}
+
+		for _, id := range ids {
+			entry, err := s.claim(ctx, id, visibilityTimeout)
+			if err != nil {
+				return nil, false, err
+			}
+			if entry != nil {
+				return entry, true, nil
+			}
+		}
+	}
+
+	return nil, false, nil
+}
+
+// claimCandidates returns the ids of the next due, visible pending jobs in fetch order
+func (s *CouchbaseStore[T]) claimCandidates(ctx context.Context) ([]string, error) {
+	query := fmt.Sprintf(`
+		SELECT RAW id
+		FROM %s
+		WHERE status = $status
+		AND processAfter < $now
+		AND (visibleAfter IS MISSING OR visibleAfter IS NULL OR visibleAfter < $now)
+		%s
+		ORDER BY %s
+		LIMIT $limit`, "`"+s.collectionName+"`", s.tenantClause(), s.fetchOrder())
+
+	options := &gocb.QueryOptions{
+		Context: ctx,
+		NamedParameters: s.tenantParams(map[string]interface{}{
+			"status": "pending",
+			"now":    s.cfg.timeFormat.timeValue(time.Now()),
+			"limit":  claimCandidates,
+		}),
+	}
+
+	result, err := s.bucket.Scope(s.scopeName).Query(query, options)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	var ids []string
+	for result.Next() {
+		var id string
+		if err := result.Row(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, result.Err()
+}
+
+// claim makes the job invisible if it is still due, visible and pending, returning nil if it
+// isn't or another claimer changed it first
+func (s *CouchbaseStore[T]) claim(ctx context.Context, id string, visibilityTimeout time.Duration) (*scheduler.Job[T], error) {
+	collection := s.bucket.Scope(s.scopeName).Collection(s.collectionName)
+
+	result, err := collection.Get(s.docKey(id), &gocb.GetOptions{Context: ctx})
+	if errors.Is(err, gocb.ErrDocumentNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var row json.RawMessage
+	if err := result.Content(&row); err != nil {
+		return nil, err
+	}
+	var doc Job[T]
+	if err := json.Unmarshal(row, &doc); err != nil {
+		s.skipUndecodable(row, err)
+		return nil, nil
+	}
+
+	// The query index may lag behind, check the job is still claimable
+	now := time.Now()
+	if doc.Status != "pending" || !doc.ProcessAfter.time.Before(now) ||
+		(doc.VisibleAfter != nil && !doc.VisibleAfter.time.Before(now)) {
+		return nil, nil
+	}
+
+	visibleAfter := now.Add(visibilityTimeout)
+	doc.VisibleAfter = newStoredTimePtr(&visibleAfter, s.cfg.timeFormat)
+
+	_, err = collection.Replace(s.docKey(id), doc, &gocb.ReplaceOptions{Context: ctx, Cas: result.Cas()})
+	if errors.Is(err, gocb.ErrCasMismatch) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := doc.toSchedulerJob(s.cfg)
+	if err != nil {
+		// Left invisible, the job is skipped again once its visibility timeout lapses
+		s.skipUndecodable(row, err)
+		return nil, nil
+	}
+
+	return entry, nil
+}
@@ -2,48 +2,166 @@ package couchbase
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	scheduler "go-sched"
 
 	"github.com/couchbase/gocb/v2"
+	"github.com/google/uuid"
 )
 
+// jobFields lists the document fields selected when reading jobs with N1QL
+const jobFields = "id, status, type, createdAt, processAfter, visibleAfter, processedAt, callbackUrl, idempotencyKey, tenantId, attempts, processedBy, priority, `interval`, remainingRuns, sequence, checkpoint, terminalReason, sealedPayload, metadata, payload, encryptedPayload"
+
+// sequenceKey is the key of the counter document that assigns job sequence numbers
+const sequenceKey = "_sequence"
+
 type CouchbaseStore[T any] struct {
 	bucket         *gocb.Bucket
 	scopeName      string
 	collectionName string
+	cfg            storeConfig
+	owner          string // Identifies this store instance as the leader lock owner
+
+	decodeErrors atomic.Int64
+	tieBreak     atomic.Int64 // scheduler.TieBreak set with SetTieBreak
 }
 
 // NewCouchbaseStore creates a store with custom scope and collection (Couchbase 7.0+)
-func NewCouchbaseStore[T any](bucket *gocb.Bucket, scopeName, collectionName string) *CouchbaseStore[T] {
-	return &CouchbaseStore[T]{
+func NewCouchbaseStore[T any](bucket *gocb.Bucket, scopeName, collectionName string, opts ...CouchbaseStoreOption) *CouchbaseStore[T] {
+	s := &CouchbaseStore[T]{
 		bucket:         bucket,
 		scopeName:      scopeName,
 		collectionName: collectionName,
+		owner:          uuid.New().String(),
+	}
+
+	for _, opt := range opts {
+		opt(&s.cfg)
+	}
+
+	if s.cfg.waitForIndexes {
+		s.waitForIndexes(context.Background(), defaultIndexWaitTimeout)
+	}
+
+	return s
+}
+
+// NewCouchbaseStoreForTenant creates a store whose reads and writes are scoped to a single tenant
+func NewCouchbaseStoreForTenant[T any](bucket *gocb.Bucket, scopeName, collectionName, tenantID string, opts ...CouchbaseStoreOption) *CouchbaseStore[T] {
+	return NewCouchbaseStore[T](bucket, scopeName, collectionName, append(opts, WithTenant(tenantID))...)
+}
+
+// docKey returns the document key for a job id, prefixed with the tenant if the store is tenant-scoped
+func (s *CouchbaseStore[T]) docKey(id string) string {
+	if s.cfg.tenantID != "" {
+		return s.cfg.tenantID + "::" + id
+	}
+	return id
+}
+
+// tenantClause restricts N1QL queries to the store's tenant, if any
+func (s *CouchbaseStore[T]) tenantClause() string {
+	if s.cfg.tenantID != "" {
+		return "AND tenantId = $tenant"
 	}
+	return ""
+}
+
+// tenantParams adds the tenant named parameter used by tenantClause
+func (s *CouchbaseStore[T]) tenantParams(params map[string]interface{}) map[string]interface{} {
+	if s.cfg.tenantID != "" {
+		params["tenant"] = s.cfg.tenantID
+	}
+	return params
+}
+
+// fetchOrder returns the ORDER BY clause for fetching, by priority (aged if configured), fewest
+// attempts if configured, due time, then the tie break
+func (s *CouchbaseStore[T]) fetchOrder() string {
+	order := "IFMISSINGORNULL(priority, 0) DESC"
+	if s.cfg.aging > 0 {
+		order = fmt.Sprintf("IFMISSINGORNULL(priority, 0) + GREATEST(0, FLOOR((NOW_MILLIS() - %s) / %d)) DESC", s.processAfterMillis(), s.cfg.aging.Milliseconds())
+	}
+	if s.cfg.deprioritizeFailing {
+		order += ", IFMISSINGORNULL(attempts, 0) ASC"
+	}
+	return order + ", processAfter ASC, " + s.tieOrder()
+}
+
+// SetTieBreak orders pending jobs that are equally due and of equal priority by tb. Random ties
+// are ordered by the tieKey field, jobs added before it existed come first.
+func (s *CouchbaseStore[T]) SetTieBreak(tb scheduler.TieBreak) {
+	s.tieBreak.Store(int64(tb))
+}
+
+// tieOrder returns the ORDER BY term ordering equally due jobs of equal priority
+func (s *CouchbaseStore[T]) tieOrder() string {
+	switch scheduler.TieBreak(s.tieBreak.Load()) {
+	case scheduler.TieBreakLIFO:
+		return "IFMISSINGORNULL(sequence, 0) DESC"
+	case scheduler.TieBreakRandom:
+		return "tieKey ASC"
+	default:
+		return "IFMISSINGORNULL(sequence, 0) ASC"
+	}
+}
+
+// processAfterMillis returns a N1QL expression for processAfter in epoch milliseconds
+func (s *CouchbaseStore[T]) processAfterMillis() string {
+	if s.cfg.timeFormat == TimeFormatEpochMillis {
+		return "processAfter"
+	}
+	return "STR_TO_MILLIS(processAfter)"
 }
 
 func (s *CouchbaseStore[T]) FetchPendingJobs(after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	return s.fetch(after, limit, nil)
+}
+
+// FetchPendingJobsByType retrieves pending jobs that are ready to be processed and pass filter
+func (s *CouchbaseStore[T]) FetchPendingJobsByType(filter scheduler.TypeFilter, after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	return s.fetch(after, limit, &filter)
+}
+
+// fetch returns up to limit due and visible pending jobs in fetch order, of the types passing
+// filter if it isn't nil
+func (s *CouchbaseStore[T]) fetch(after time.Time, limit int, filter *scheduler.TypeFilter) ([]*scheduler.Job[T], error) {
+	params := s.tenantParams(map[string]interface{}{
+		"status": "pending",
+		"after":  s.cfg.timeFormat.timeValue(after),
+		"now":    s.cfg.timeFormat.timeValue(time.Now()),
+		"limit":  limit,
+	})
+
+	// Untyped jobs have no type field, so compare them as the empty type
+	typeClause := ""
+	if filter != nil {
+		typeClause = "AND IFMISSINGORNULL(`type`, \"\") IN $types"
+		if filter.Exclude {
+			typeClause = "AND IFMISSINGORNULL(`type`, \"\") NOT IN $types"
+		}
+		params["types"] = filter.Types
+	}
+
 	// N1QL query to find pending and visible jobs
 	query := fmt.Sprintf(`
-		SELECT id, status, processAfter, visibleAfter, processedAt, payload
+		SELECT %s
 		FROM %s
 		WHERE status = $status 
 		AND processAfter < $after
 		AND (visibleAfter IS MISSING OR visibleAfter IS NULL OR visibleAfter < $now)
-		ORDER BY processAfter ASC
-		LIMIT $limit`, "`"+s.collectionName+"`")
+		%s
+		%s
+		ORDER BY %s
+		LIMIT $limit`, jobFields, "`"+s.collectionName+"`", s.tenantClause(), typeClause, s.fetchOrder())
 
 	options := &gocb.QueryOptions{
-		NamedParameters: map[string]interface{}{
-			"status": "pending",
-			"after":  after,
-			"now":    time.Now(),
-			"limit":  limit,
-		},
+		NamedParameters: params,
 	}
 
 	result, err := s.bucket.Scope(s.scopeName).Query(query, options)
@@ -54,20 +172,19 @@ func (s *CouchbaseStore[T]) FetchPendingJobs(after time.Time, limit int, visibil
 
 	var jobs []*scheduler.Job[T]
 	for result.Next() {
-		var job Job[T]
-		if err := result.Row(&job); err != nil {
+		var row json.RawMessage
+		if err := result.Row(&row); err != nil {
 			return nil, err
 		}
 
-		// Convert to scheduler.Job
-		jobs = append(jobs, &scheduler.Job[T]{
-			Id:           job.Id,
-			Status:       job.Status,
-			ProcessAfter: job.ProcessAfter,
-			VisibleAfter: job.VisibleAfter,
-			ProcessedAt:  job.ProcessedAt,
-			Payload:      job.Payload,
-		})
+		entry, err := s.decodeJob(row)
+		if err != nil {
+			// A single undecodable job must not starve the rest of the batch
+			s.skipUndecodable(row, err)
+			continue
+		}
+
+		jobs = append(jobs, entry)
 	}
 
 	if err := result.Err(); err != nil {
@@ -77,6 +194,46 @@ func (s *CouchbaseStore[T]) FetchPendingJobs(after time.Time, limit int, visibil
 	return jobs, nil
 }
 
+// decodeJob decodes a N1QL result row into a scheduler job
+func (s *CouchbaseStore[T]) decodeJob(row json.RawMessage) (*scheduler.Job[T], error) {
+	var job Job[T]
+	if err := json.Unmarshal(row, &job); err != nil {
+		return nil, err
+	}
+
+	return job.toSchedulerJob(s.cfg)
+}
+
+// skipUndecodable records a job skipped by FetchPendingJobs and moves it to the poison status, if configured
+func (s *CouchbaseStore[T]) skipUndecodable(row json.RawMessage, err error) {
+	var ref struct {
+		Id string `json:"id"`
+	}
+	json.Unmarshal(row, &ref)
+
+	s.decodeErrors.Add(1)
+
+	if s.cfg.onDecodeError != nil {
+		s.cfg.onDecodeError(ref.Id, err)
+	}
+
+	if s.cfg.poisonStatus != "" && ref.Id != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		// Best effort, a job left pending is skipped again on the next fetch
+		collection := s.bucket.Scope(s.scopeName).Collection(s.collectionName)
+		collection.MutateIn(s.docKey(ref.Id), []gocb.MutateInSpec{
+			gocb.ReplaceSpec("status", s.cfg.poisonStatus, nil),
+		}, &gocb.MutateInOptions{Context: ctx})
+	}
+}
+
+// DecodeErrors returns the number of jobs FetchPendingJobs skipped because they couldn't be decoded
+func (s *CouchbaseStore[T]) DecodeErrors() int64 {
+	return s.decodeErrors.Load()
+}
+
 func (s *CouchbaseStore[T]) UpdateJob(job *scheduler.Job[T]) error {
 	if job.Id == "" {
 		return errors.New("job Id cannot be empty")
@@ -85,17 +242,13 @@ func (s *CouchbaseStore[T]) UpdateJob(job *scheduler.Job[T]) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	jobDoc := Job[T]{
-		Id:           job.Id,
-		Status:       job.Status,
-		ProcessAfter: job.ProcessAfter,
-		VisibleAfter: job.VisibleAfter,
-		ProcessedAt:  job.ProcessedAt,
-		Payload:      job.Payload,
+	jobDoc, err := newJob(job, s.cfg)
+	if err != nil {
+		return err
 	}
 
 	collection := s.bucket.Scope(s.scopeName).Collection(s.collectionName)
-	_, err := collection.Replace(job.Id, jobDoc, &gocb.ReplaceOptions{
+	_, err = collection.Replace(s.docKey(job.Id), jobDoc, &gocb.ReplaceOptions{
 		Context: ctx,
 	})
 	if err != nil {
@@ -105,6 +258,64 @@ func (s *CouchbaseStore[T]) UpdateJob(job *scheduler.Job[T]) error {
 	return nil
 }
 
+// TransitionStatus sets the job status to `to` only if it is currently `from` and the job isn't
+// being processed. The status is read and written with the same CAS, so a concurrent update makes
+// the write fail and the status is checked again
+func (s *CouchbaseStore[T]) TransitionStatus(id, from, to string) (bool, error) {
+	if id == "" {
+		return false, errors.New("job Id cannot be empty")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	collection := s.bucket.Scope(s.scopeName).Collection(s.collectionName)
+
+	for {
+		lookup, err := collection.LookupIn(s.docKey(id), []gocb.LookupInSpec{
+			gocb.GetSpec("status", nil),
+			gocb.GetSpec("visibleAfter", nil),
+		}, &gocb.LookupInOptions{Context: ctx})
+		if err != nil {
+			return false, err
+		}
+
+		var status string
+		if err := lookup.ContentAt(0, &status); err != nil {
+			return false, err
+		}
+
+		var visibleAfter *storedTime
+		if lookup.Exists(1) {
+			if err := lookup.ContentAt(1, &visibleAfter); err != nil {
+				return false, err
+			}
+		}
+
+		if status != from || inFlight(status, visibleAfter, time.Now()) {
+			return false, nil
+		}
+
+		_, err = collection.MutateIn(s.docKey(id), []gocb.MutateInSpec{
+			gocb.ReplaceSpec("status", to, nil),
+		}, &gocb.MutateInOptions{Context: ctx, Cas: lookup.Cas()})
+		if errors.Is(err, gocb.ErrCasMismatch) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+}
+
+// inFlight reports whether a worker is processing a job with the given status and visibility, i.e.
+// it is marked as processing or is pending but invisible
+func inFlight(status string, visibleAfter *storedTime, now time.Time) bool {
+	return status == "processing" || (status == "pending" && visibleAfter != nil && !now.After(visibleAfter.time))
+}
+
 func (s *CouchbaseStore[T]) AddJob(job *scheduler.Job[T]) error {
 	if job.Id == "" {
 		return errors.New("job Id cannot be empty")
@@ -113,22 +324,305 @@ func (s *CouchbaseStore[T]) AddJob(job *scheduler.Job[T]) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	jobDoc := Job[T]{
-		Id:           job.Id,
-		Status:       job.Status,
-		ProcessAfter: job.ProcessAfter,
-		VisibleAfter: job.VisibleAfter,
-		ProcessedAt:  job.ProcessedAt,
-		Payload:      job.Payload,
+	collection := s.bucket.Scope(s.scopeName).Collection(s.collectionName)
+
+	counter, err := collection.Binary().Increment(s.docKey(sequenceKey), &gocb.IncrementOptions{
+		Initial: 1,
+		Delta:   1,
+		Context: ctx,
+	})
+	if err != nil {
+		return err
 	}
+	job.Sequence = int64(counter.Content())
 
-	collection := s.bucket.Scope(s.scopeName).Collection(s.collectionName)
-	_, err := collection.Insert(job.Id, jobDoc, &gocb.InsertOptions{
+	jobDoc, err := newJob(job, s.cfg)
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.Insert(s.docKey(job.Id), jobDoc, &gocb.InsertOptions{
 		Context: ctx,
 	})
 	if err != nil {
 		return err
 	}
 
+	observePayload(job, s.cfg)
 	return nil
 }
+
+// CountInvisible returns the number of pending jobs whose visibility timeout hasn't expired
+func (s *CouchbaseStore[T]) CountInvisible() (int, error) {
+	query := fmt.Sprintf(`
+		SELECT RAW COUNT(*)
+		FROM %s
+		WHERE status = $status
+		AND visibleAfter > $now
+		%s`, "`"+s.collectionName+"`", s.tenantClause())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	options := &gocb.QueryOptions{
+		NamedParameters: s.tenantParams(map[string]interface{}{
+			"status": "pending",
+			"now":    s.cfg.timeFormat.timeValue(time.Now()),
+		}),
+		Context: ctx,
+	}
+
+	result, err := s.bucket.Scope(s.scopeName).Query(query, options)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := result.One(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CancelByKey deletes the pending jobs with the idempotency key that aren't being processed
+// Index idempotencyKey for the delete to avoid a collection scan
+func (s *CouchbaseStore[T]) CancelByKey(key string) (int, error) {
+	if key == "" {
+		return 0, errors.New("idempotency key cannot be empty")
+	}
+
+	query := fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE idempotencyKey = $key
+		AND status = $status
+		AND (visibleAfter IS MISSING OR visibleAfter IS NULL OR visibleAfter < $now)
+		%s
+		RETURNING RAW id`, "`"+s.collectionName+"`", s.tenantClause())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	options := &gocb.QueryOptions{
+		NamedParameters: s.tenantParams(map[string]interface{}{
+			"key":    key,
+			"status": "pending",
+			"now":    s.cfg.timeFormat.timeValue(time.Now()),
+		}),
+		Context: ctx,
+	}
+
+	result, err := s.bucket.Scope(s.scopeName).Query(query, options)
+	if err != nil {
+		return 0, err
+	}
+	defer result.Close()
+
+	cancelled := 0
+	for result.Next() {
+		cancelled++
+	}
+
+	return cancelled, result.Err()
+}
+
+// ShiftSchedule adds by to the due time of pending jobs due within [from, to) with a single N1QL
+// UPDATE, by is applied with millisecond precision
+func (s *CouchbaseStore[T]) ShiftSchedule(from, to time.Time, by time.Duration) (int, error) {
+	shifted := "processAfter + $by"
+	if s.cfg.timeFormat != TimeFormatEpochMillis {
+		shifted = "MILLIS_TO_UTC(STR_TO_MILLIS(processAfter) + $by)"
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET processAfter = %s
+		WHERE status = $status
+		AND %s >= $from
+		AND %s < $to
+		%s
+		RETURNING RAW id`, "`"+s.collectionName+"`", shifted, s.processAfterMillis(), s.processAfterMillis(), s.tenantClause())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	options := &gocb.QueryOptions{
+		NamedParameters: s.tenantParams(map[string]interface{}{
+			"status": "pending",
+			"from":   from.UnixMilli(),
+			"to":     to.UnixMilli(),
+			"by":     by.Milliseconds(),
+		}),
+		Context: ctx,
+	}
+
+	result, err := s.bucket.Scope(s.scopeName).Query(query, options)
+	if err != nil {
+		return 0, err
+	}
+	defer result.Close()
+
+	count := 0
+	for result.Next() {
+		count++
+	}
+
+	return count, result.Err()
+}
+
+// FetchCompleted returns up to limit jobs (0 for no limit) completed within [from, to), ordered by completion time
+func (s *CouchbaseStore[T]) FetchCompleted(from, to time.Time, limit int) ([]*scheduler.Job[T], error) {
+	limitClause := ""
+	if limit > 0 {
+		limitClause = fmt.Sprintf("LIMIT %d", limit)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE status = $status
+		AND STR_TO_MILLIS(processedAt) >= $from
+		AND STR_TO_MILLIS(processedAt) < $to
+		%s
+		ORDER BY STR_TO_MILLIS(processedAt) ASC
+		%s`, jobFields, "`"+s.collectionName+"`", s.tenantClause(), limitClause)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	options := &gocb.QueryOptions{
+		NamedParameters: s.tenantParams(map[string]interface{}{
+			"status": "completed",
+			"from":   from.UnixMilli(),
+			"to":     to.UnixMilli(),
+		}),
+		Context: ctx,
+	}
+
+	result, err := s.bucket.Scope(s.scopeName).Query(query, options)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	var jobs []*scheduler.Job[T]
+	for result.Next() {
+		var row json.RawMessage
+		if err := result.Row(&row); err != nil {
+			return nil, err
+		}
+
+		entry, err := s.decodeJob(row)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, entry)
+	}
+
+	return jobs, result.Err()
+}
+
+// CountFailedByReason returns the number of failed jobs per terminal reason
+func (s *CouchbaseStore[T]) CountFailedByReason() (map[scheduler.TerminalReason]int, error) {
+	query := fmt.Sprintf(`
+		SELECT IFMISSINGORNULL(terminalReason, "") AS reason, COUNT(*) AS count
+		FROM %s
+		WHERE status = $status
+		%s
+		GROUP BY IFMISSINGORNULL(terminalReason, "")`, "`"+s.collectionName+"`", s.tenantClause())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	options := &gocb.QueryOptions{
+		NamedParameters: s.tenantParams(map[string]interface{}{
+			"status": "failed",
+		}),
+		Context: ctx,
+	}
+
+	result, err := s.bucket.Scope(s.scopeName).Query(query, options)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	counts := make(map[scheduler.TerminalReason]int)
+	for result.Next() {
+		var row struct {
+			Reason scheduler.TerminalReason `json:"reason"`
+			Count  int                      `json:"count"`
+		}
+		if err := result.Row(&row); err != nil {
+			return nil, err
+		}
+		counts[row.Reason] = row.Count
+	}
+
+	return counts, result.Err()
+}
+
+// ExplainFetch reports which fetch predicates the job fails right now
+func (s *CouchbaseStore[T]) ExplainFetch(id string) (scheduler.FetchExplanation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	collection := s.bucket.Scope(s.scopeName).Collection(s.collectionName)
+	result, err := collection.Get(s.docKey(id), &gocb.GetOptions{Context: ctx})
+	if errors.Is(err, gocb.ErrDocumentNotFound) {
+		return scheduler.FetchExplanation{}, fmt.Errorf("job not found: %s", id)
+	}
+	if err != nil {
+		return scheduler.FetchExplanation{}, err
+	}
+
+	var job Job[T]
+	if err := result.Content(&job); err != nil {
+		return scheduler.FetchExplanation{}, err
+	}
+
+	entry, err := job.toSchedulerJob(s.cfg)
+	if err != nil {
+		return scheduler.FetchExplanation{}, err
+	}
+
+	return scheduler.ExplainFetch(entry, time.Now()), nil
+}
+
+// IterateJobs streams all jobs from a N1QL query, calling fn for each until it returns false
+// Documents without an id, such as the leader lease, are skipped
+func (s *CouchbaseStore[T]) IterateJobs(ctx context.Context, fn func(*scheduler.Job[T]) bool) error {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE id IS VALUED %s`, jobFields, "`"+s.collectionName+"`", s.tenantClause())
+
+	options := &gocb.QueryOptions{
+		NamedParameters: s.tenantParams(map[string]interface{}{}),
+		Context:         ctx,
+	}
+
+	result, err := s.bucket.Scope(s.scopeName).Query(query, options)
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var job Job[T]
+		if err := result.Row(&job); err != nil {
+			return err
+		}
+
+		entry, err := job.toSchedulerJob(s.cfg)
+		if err != nil {
+			return err
+		}
+
+		if !fn(entry) {
+			return nil
+		}
+	}
+
+	return result.Err()
+}
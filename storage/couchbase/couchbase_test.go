@@ -0,0 +1,130 @@
+package couchbase
+
+import (
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+)
+
+func TestCouchbaseStoreTenantScoping(t *testing.T) {
+	tests := []struct {
+		name       string
+		tenant     string
+		wantKey    string
+		wantClause string
+		wantParam  bool
+	}{
+		{name: "unscoped", wantKey: "job-1"},
+		{name: "tenant a", tenant: "tenant-a", wantKey: "tenant-a::job-1", wantClause: "AND tenantId = $tenant", wantParam: true},
+		{name: "tenant b", tenant: "tenant-b", wantKey: "tenant-b::job-1", wantClause: "AND tenantId = $tenant", wantParam: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []CouchbaseStoreOption
+			if tt.tenant != "" {
+				opts = append(opts, WithTenant(tt.tenant))
+			}
+			store := NewCouchbaseStore[string](nil, "scope", "jobs", opts...)
+
+			// Job ids may collide across tenants, the composite key keeps their documents apart
+			if got := store.docKey("job-1"); got != tt.wantKey {
+				t.Errorf("docKey() = %q, want %q", got, tt.wantKey)
+			}
+			if got := store.tenantClause(); got != tt.wantClause {
+				t.Errorf("tenantClause() = %q, want %q", got, tt.wantClause)
+			}
+			params := store.tenantParams(map[string]interface{}{"status": "pending"})
+			if tenant, ok := params["tenant"]; ok != tt.wantParam || (ok && tenant != tt.tenant) {
+				t.Errorf("tenantParams() = %v, want tenant %q", params, tt.tenant)
+			}
+
+			doc, err := newJob(&scheduler.Job[string]{Id: "job-1", TenantID: "other"}, store.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.tenant != "" && doc.TenantID != tt.tenant {
+				t.Errorf("document tenant = %q, want %q", doc.TenantID, tt.tenant)
+			}
+		})
+	}
+}
+
+func TestCouchbaseStoreDeprioritizeFailing(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []CouchbaseStoreOption
+		wantOrder string
+	}{
+		{name: "earliest due first by default", wantOrder: "IFMISSINGORNULL(priority, 0) DESC, processAfter ASC, IFMISSINGORNULL(sequence, 0) ASC"},
+		{name: "fewest attempts first", opts: []CouchbaseStoreOption{WithDeprioritizeFailing()}, wantOrder: "IFMISSINGORNULL(priority, 0) DESC, IFMISSINGORNULL(attempts, 0) ASC, processAfter ASC, IFMISSINGORNULL(sequence, 0) ASC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewCouchbaseStore[string](nil, "scope", "jobs", tt.opts...)
+			if got := store.fetchOrder(); got != tt.wantOrder {
+				t.Fatalf("fetchOrder() = %q, want %q", got, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestCouchbaseStoreTieBreak(t *testing.T) {
+	tests := []struct {
+		name      string
+		tieBreak  scheduler.TieBreak
+		wantOrder string
+	}{
+		{name: "fifo", tieBreak: scheduler.TieBreakFIFO, wantOrder: "IFMISSINGORNULL(priority, 0) DESC, processAfter ASC, IFMISSINGORNULL(sequence, 0) ASC"},
+		{name: "lifo", tieBreak: scheduler.TieBreakLIFO, wantOrder: "IFMISSINGORNULL(priority, 0) DESC, processAfter ASC, IFMISSINGORNULL(sequence, 0) DESC"},
+		{name: "random", tieBreak: scheduler.TieBreakRandom, wantOrder: "IFMISSINGORNULL(priority, 0) DESC, processAfter ASC, tieKey ASC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewCouchbaseStore[string](nil, "scope", "jobs")
+			store.SetTieBreak(tt.tieBreak)
+			if got := store.fetchOrder(); got != tt.wantOrder {
+				t.Fatalf("fetchOrder() = %q, want %q", got, tt.wantOrder)
+			}
+
+			// Every job stores the hash random ties are ordered by
+			doc, err := newJob(&scheduler.Job[string]{Id: "job-1"}, store.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.TieKey != scheduler.TieKey("job-1") {
+				t.Fatalf("document tieKey = %d, want %d", doc.TieKey, scheduler.TieKey("job-1"))
+			}
+		})
+	}
+}
+
+func TestCouchbaseStoreTransitionSkipsInFlightJobs(t *testing.T) {
+	now := time.Now()
+	lapsed := newStoredTime(now.Add(-time.Second), TimeFormatNative)
+	hidden := newStoredTime(now.Add(time.Minute), TimeFormatEpochMillis)
+
+	tests := []struct {
+		name         string
+		status       string
+		visibleAfter *storedTime
+		want         bool
+	}{
+		{name: "pending and visible", status: "pending"},
+		{name: "pending after its visibility timeout lapsed", status: "pending", visibleAfter: &lapsed},
+		{name: "pending but invisible", status: "pending", visibleAfter: &hidden, want: true},
+		{name: "processing", status: "processing", want: true},
+		{name: "paused with a stale visibility", status: "paused", visibleAfter: &hidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inFlight(tt.status, tt.visibleAfter, now); got != tt.want {
+				t.Fatalf("inFlight(%q) = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package couchbase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// indexPollInterval is the pause between index state checks while waiting for an index to build
+const indexPollInterval = 500 * time.Millisecond
+
+// defaultIndexWaitTimeout bounds how long NewCouchbaseStore waits for indexes with WithWaitForIndexes
+const defaultIndexWaitTimeout = time.Minute
+
+// WaitForIndexReady blocks until the named index on the store's collection is online, so N1QL
+// queries don't return partial results while it is still building. It returns an error if the
+// index doesn't exist or isn't online within timeout.
+func (s *CouchbaseStore[T]) WaitForIndexReady(ctx context.Context, indexName string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	query := `
+		SELECT RAW state
+		FROM system:indexes
+		WHERE name = $name
+		AND bucket_id = $bucket
+		AND scope_id = $scope
+		AND keyspace_id = $collection`
+
+	return pollIndexState(ctx, indexName, indexPollInterval, func(ctx context.Context) ([]string, error) {
+		return s.queryIndexes(ctx, query, map[string]interface{}{"name": indexName})
+	})
+}
+
+// pollIndexState reads the index state with states every interval until it is online or ctx is done
+func pollIndexState(ctx context.Context, indexName string, interval time.Duration, states func(ctx context.Context) ([]string, error)) error {
+	for {
+		found, err := states(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read state of index %s: %w", indexName, err)
+		}
+
+		state := "missing"
+		if len(found) > 0 {
+			state = found[0]
+		}
+		if state == "online" {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("index %s not online, last state %s: %w", indexName, state, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// waitForIndexes waits for every index on the store's collection to come online
+func (s *CouchbaseStore[T]) waitForIndexes(ctx context.Context, timeout time.Duration) error {
+	query := `
+		SELECT RAW name
+		FROM system:indexes
+		WHERE bucket_id = $bucket
+		AND scope_id = $scope
+		AND keyspace_id = $collection`
+
+	names, err := s.queryIndexes(ctx, query, map[string]interface{}{})
+	if err != nil {
+		return fmt.Errorf("failed to list indexes: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for _, name := range names {
+		if err := s.WaitForIndexReady(ctx, name, time.Until(deadline)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queryIndexes runs a system:indexes query scoped to the store's collection, returning raw string values
+func (s *CouchbaseStore[T]) queryIndexes(ctx context.Context, query string, params map[string]interface{}) ([]string, error) {
+	params["bucket"] = s.bucket.Name()
+	params["scope"] = s.scopeName
+	params["collection"] = s.collectionName
+
+	result, err := s.bucket.Scope(s.scopeName).Query(query, &gocb.QueryOptions{
+		NamedParameters: params,
+		Context:         ctx,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	var values []string
+	for result.Next() {
+		var value string
+		if err := result.Row(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, result.Err()
+}
@@ -0,0 +1,49 @@
+package couchbase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPollIndexState(t *testing.T) {
+	errUnavailable := errors.New("query service unavailable")
+
+	tests := []struct {
+		name      string
+		states    [][]string // Result of each system:indexes query, the last one repeats
+		queryErr  error
+		wantPolls int
+		wantErr   error
+	}{
+		{name: "already online", states: [][]string{{"online"}}, wantPolls: 1},
+		{name: "online after building", states: [][]string{nil, {"deferred"}, {"building"}, {"online"}}, wantPolls: 4},
+		{name: "never online", states: [][]string{{"building"}}, wantErr: context.DeadlineExceeded},
+		{name: "query fails", queryErr: errUnavailable, wantPolls: 1, wantErr: errUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			polls := 0
+			err := pollIndexState(ctx, "idx_jobs", time.Millisecond, func(ctx context.Context) ([]string, error) {
+				polls++
+				if tt.queryErr != nil {
+					return nil, tt.queryErr
+				}
+				return tt.states[min(polls, len(tt.states))-1], nil
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("pollIndexState() error = %v, want %v", err, tt.wantErr)
+			}
+			// Polling stops as soon as the index is online
+			if tt.wantPolls > 0 && polls != tt.wantPolls {
+				t.Fatalf("polled %d times, want %d", polls, tt.wantPolls)
+			}
+		})
+	}
+}
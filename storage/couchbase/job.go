@@ -1,12 +1,143 @@
 package couchbase
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	scheduler "go-sched"
+)
 
 type Job[T any] struct {
-	Id           string     `json:"id"`
-	Status       string     `json:"status"`
-	ProcessAfter time.Time  `json:"processAfter"`
-	VisibleAfter *time.Time `json:"visibleAfter,omitempty"`
-	ProcessedAt  *time.Time `json:"processedAt,omitempty"`
-	Payload      T          `json:"payload"`
+	Id               string                   `json:"id"`
+	Status           string                   `json:"status"`
+	Type             string                   `json:"type,omitempty"`
+	CreatedAt        time.Time                `json:"createdAt"`
+	ProcessAfter     storedTime               `json:"processAfter"`
+	VisibleAfter     *storedTime              `json:"visibleAfter,omitempty"`
+	ProcessedAt      *time.Time               `json:"processedAt,omitempty"`
+	CallbackURL      string                   `json:"callbackUrl,omitempty"`
+	IdempotencyKey   string                   `json:"idempotencyKey,omitempty"`
+	TenantID         string                   `json:"tenantId,omitempty"`
+	Attempts         int                      `json:"attempts"`
+	ProcessedBy      string                   `json:"processedBy,omitempty"`
+	Priority         int                      `json:"priority"`
+	Interval         time.Duration            `json:"interval,omitempty"`
+	RemainingRuns    int                      `json:"remainingRuns,omitempty"`
+	Sequence         int64                    `json:"sequence,omitempty"`
+	TieKey           int64                    `json:"tieKey"` // Hash of the id, orders ties with TieBreakRandom
+	Checkpoint       json.RawMessage          `json:"checkpoint,omitempty"`
+	TerminalReason   scheduler.TerminalReason `json:"terminalReason,omitempty"`
+	SealedPayload    []byte                   `json:"sealedPayload,omitempty"` // Payload encrypted by the scheduler's SecretManager
+	Metadata         scheduler.Metadata       `json:"metadata,omitempty"`
+	Payload          *T                       `json:"payload,omitempty"`
+	EncryptedPayload []byte                   `json:"encryptedPayload,omitempty"` // Set instead of Payload when an encrypter is configured
+}
+
+// newJob converts a scheduler job into its document representation
+func newJob[T any](job *scheduler.Job[T], cfg storeConfig) (*Job[T], error) {
+	doc := &Job[T]{
+		Id:             job.Id,
+		Status:         job.Status,
+		Type:           job.Type,
+		CreatedAt:      job.CreatedAt,
+		ProcessAfter:   newStoredTime(job.ProcessAfter, cfg.timeFormat),
+		VisibleAfter:   newStoredTimePtr(job.VisibleAfter, cfg.timeFormat),
+		ProcessedAt:    job.ProcessedAt,
+		CallbackURL:    job.CallbackURL,
+		IdempotencyKey: job.IdempotencyKey,
+		TenantID:       job.TenantID,
+		Attempts:       job.Attempts,
+		ProcessedBy:    job.ProcessedBy,
+		Priority:       job.Priority,
+		Interval:       job.Interval,
+		RemainingRuns:  job.RemainingRuns,
+		Sequence:       job.Sequence,
+		TieKey:         scheduler.TieKey(job.Id),
+		Checkpoint:     job.Checkpoint,
+		TerminalReason: job.TerminalReason,
+		SealedPayload:  job.SealedPayload,
+		Metadata:       job.Metadata,
+	}
+
+	if cfg.tenantID != "" {
+		doc.TenantID = cfg.tenantID
+	}
+
+	if cfg.encrypter == nil {
+		payload := job.Payload
+		doc.Payload = &payload
+		return doc, nil
+	}
+
+	plaintext, err := json.Marshal(job.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.EncryptedPayload, err = cfg.encrypter.Encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// observePayload records the size of a stored job's payload with the configured collector,
+// measured as JSON before encryption whatever the document encoding
+func observePayload[T any](job *scheduler.Job[T], cfg storeConfig) {
+	if cfg.metrics == nil {
+		return
+	}
+	if raw, err := json.Marshal(job.Payload); err == nil {
+		cfg.metrics.ObservePayloadBytes(job.Type, len(raw))
+	}
+}
+
+// toSchedulerJob converts the document back into a scheduler job, decrypting the payload if needed
+func (j *Job[T]) toSchedulerJob(cfg storeConfig) (*scheduler.Job[T], error) {
+	job := &scheduler.Job[T]{
+		Id:             j.Id,
+		Status:         j.Status,
+		Type:           j.Type,
+		CreatedAt:      j.CreatedAt,
+		ProcessAfter:   j.ProcessAfter.time,
+		VisibleAfter:   j.VisibleAfter.timePtr(),
+		ProcessedAt:    j.ProcessedAt,
+		CallbackURL:    j.CallbackURL,
+		IdempotencyKey: j.IdempotencyKey,
+		TenantID:       j.TenantID,
+		Attempts:       j.Attempts,
+		ProcessedBy:    j.ProcessedBy,
+		Priority:       j.Priority,
+		Interval:       j.Interval,
+		RemainingRuns:  j.RemainingRuns,
+		Sequence:       j.Sequence,
+		Checkpoint:     j.Checkpoint,
+		TerminalReason: j.TerminalReason,
+		SealedPayload:  j.SealedPayload,
+		Metadata:       j.Metadata,
+	}
+
+	if j.EncryptedPayload == nil {
+		if j.Payload != nil {
+			job.Payload = *j.Payload
+		}
+		return job, nil
+	}
+
+	if cfg.encrypter == nil {
+		return nil, errors.New("job payload is encrypted but no encrypter is configured")
+	}
+
+	plaintext, err := cfg.encrypter.Decrypt(j.EncryptedPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(plaintext, &job.Payload); err != nil {
+		return nil, err
+	}
+
+	return job, nil
 }
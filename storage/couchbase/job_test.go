@@ -0,0 +1,142 @@
+package couchbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+)
+
+type emailPayload struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+}
+
+func TestJobDocumentPayloadEncryption(t *testing.T) {
+	enc, err := scheduler.NewAESGCMEncrypter(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		cfg           storeConfig
+		wantEncrypted bool
+	}{
+		{name: "plaintext", cfg: storeConfig{}},
+		{name: "encrypted", cfg: storeConfig{encrypter: enc}, wantEncrypted: true},
+		{name: "encrypted epoch millis", cfg: storeConfig{encrypter: enc, timeFormat: TimeFormatEpochMillis}, wantEncrypted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := emailPayload{To: "a@example.com", Subject: "confidential"}
+			job := &scheduler.Job[emailPayload]{Id: "job-1", Status: "pending", ProcessAfter: time.Now().Truncate(time.Millisecond), Payload: payload}
+
+			doc, err := newJob(job, tt.cfg)
+			if err != nil {
+				t.Fatalf("newJob() error = %v", err)
+			}
+
+			// Round trip through JSON as the SDK would on write and fetch
+			stored, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if encrypted := !bytes.Contains(stored, []byte("confidential")); encrypted != tt.wantEncrypted {
+				t.Fatalf("stored payload encrypted = %v, want %v", encrypted, tt.wantEncrypted)
+			}
+
+			var fetched Job[emailPayload]
+			if err := json.Unmarshal(stored, &fetched); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := fetched.toSchedulerJob(tt.cfg)
+			if err != nil {
+				t.Fatalf("toSchedulerJob() error = %v", err)
+			}
+			if got.Payload != payload {
+				t.Fatalf("payload = %+v, want %+v", got.Payload, payload)
+			}
+			if !got.ProcessAfter.Equal(job.ProcessAfter) {
+				t.Fatalf("ProcessAfter = %s, want %s", got.ProcessAfter, job.ProcessAfter)
+			}
+		})
+	}
+}
+
+func TestCouchbaseStoreSkipsUndecodableJobs(t *testing.T) {
+	rows := []string{
+		`{"id":"job-1","status":"pending","processAfter":"2024-01-01T00:00:00Z","payload":{"to":"a@example.com"}}`,
+		`{"id":"job-2","status":"pending","processAfter":"2024-01-01T00:00:00Z","payload":"not an email"}`,
+		`{"id":"job-3","status":"pending","processAfter":"2024-01-01T00:00:00Z","payload":{"to":"b@example.com"}}`,
+	}
+
+	var skipped []string
+	store := NewCouchbaseStore[emailPayload](nil, "scope", "jobs", WithOnDecodeError(func(id string, err error) {
+		skipped = append(skipped, id)
+	}))
+
+	// Rows are handled as FetchPendingJobs handles each query result row
+	var fetched []string
+	for _, row := range rows {
+		job, err := store.decodeJob(json.RawMessage(row))
+		if err != nil {
+			store.skipUndecodable(json.RawMessage(row), err)
+			continue
+		}
+		fetched = append(fetched, job.Id)
+	}
+
+	if !slices.Equal(fetched, []string{"job-1", "job-3"}) {
+		t.Fatalf("decoded %v, want job-1 and job-3", fetched)
+	}
+	if !slices.Equal(skipped, []string{"job-2"}) || store.DecodeErrors() != 1 {
+		t.Fatalf("skipped %v (%d decode errors), want job-2", skipped, store.DecodeErrors())
+	}
+}
+
+func TestJobDocumentPayloadMetrics(t *testing.T) {
+	enc, err := scheduler.NewAESGCMEncrypter(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		cfg       storeConfig
+		added     bool // Whether the job is added, rather than only serialized for an update
+		wantCount uint64
+	}{
+		{name: "added", added: true, wantCount: 1},
+		{name: "added encrypted", cfg: storeConfig{encrypter: enc}, added: true, wantCount: 1},
+		{name: "serialized for an update"},
+		{name: "serialized encrypted for an update", cfg: storeConfig{encrypter: enc}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := scheduler.NewPayloadMetrics(100, 1000)
+			tt.cfg.metrics = metrics
+
+			job := &scheduler.Job[string]{Id: "job-1", Type: "report", Payload: strings.Repeat("x", 500)}
+			if _, err := newJob(job, tt.cfg); err != nil {
+				t.Fatalf("newJob() error = %v", err)
+			}
+			if tt.added {
+				observePayload(job, tt.cfg)
+			}
+
+			// Payloads are observed as JSON, a string payload gains its quotes
+			h := metrics.Histogram()
+			if h.Count != tt.wantCount || h.Sum != tt.wantCount*502 {
+				t.Fatalf("observed %d payloads of %d bytes, want %d of 502 bytes", h.Count, h.Sum, tt.wantCount)
+			}
+		})
+	}
+}
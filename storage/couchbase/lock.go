@@ -0,0 +1,100 @@
+package couchbase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// lockKey is the key of the leader lease document, stored alongside the jobs
+const lockKey = "_scheduler_lock"
+
+// lease is the leader lock document, it expires through the document expiry
+type lease struct {
+	Owner string `json:"owner"`
+}
+
+// TryAcquireLock takes the leader lease for ttl if it is free or already held by this store
+// An expired lease is removed by Couchbase, so the insert succeeds once it lapses
+func (s *CouchbaseStore[T]) TryAcquireLock(ctx context.Context, ttl time.Duration) (bool, error) {
+	collection := s.bucket.Scope(s.scopeName).Collection(s.collectionName)
+
+	_, err := collection.Insert(s.docKey(lockKey), lease{Owner: s.owner}, &gocb.InsertOptions{
+		Expiry:  ttl,
+		Context: ctx,
+	})
+	if errors.Is(err, gocb.ErrDocumentExists) {
+		return s.RenewLock(ctx, ttl)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RenewLock extends the leader lease by ttl if it is still held by this store
+func (s *CouchbaseStore[T]) RenewLock(ctx context.Context, ttl time.Duration) (bool, error) {
+	collection := s.bucket.Scope(s.scopeName).Collection(s.collectionName)
+
+	current, cas, err := s.getLease(ctx)
+	if err != nil || current == nil || current.Owner != s.owner {
+		return false, err
+	}
+
+	_, err = collection.Replace(s.docKey(lockKey), current, &gocb.ReplaceOptions{
+		Expiry:  ttl,
+		Cas:     cas,
+		Context: ctx,
+	})
+	if errors.Is(err, gocb.ErrCasMismatch) || errors.Is(err, gocb.ErrDocumentNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReleaseLock removes the leader lease if it is held by this store
+func (s *CouchbaseStore[T]) ReleaseLock(ctx context.Context) error {
+	collection := s.bucket.Scope(s.scopeName).Collection(s.collectionName)
+
+	current, cas, err := s.getLease(ctx)
+	if err != nil || current == nil || current.Owner != s.owner {
+		return err
+	}
+
+	_, err = collection.Remove(s.docKey(lockKey), &gocb.RemoveOptions{
+		Cas:     cas,
+		Context: ctx,
+	})
+	if errors.Is(err, gocb.ErrCasMismatch) || errors.Is(err, gocb.ErrDocumentNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// getLease reads the leader lease and its CAS, returning nil if no lease exists
+func (s *CouchbaseStore[T]) getLease(ctx context.Context) (*lease, gocb.Cas, error) {
+	collection := s.bucket.Scope(s.scopeName).Collection(s.collectionName)
+
+	result, err := collection.Get(s.docKey(lockKey), &gocb.GetOptions{Context: ctx})
+	if errors.Is(err, gocb.ErrDocumentNotFound) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var current lease
+	if err := result.Content(&current); err != nil {
+		return nil, 0, err
+	}
+
+	return &current, result.Cas(), nil
+}
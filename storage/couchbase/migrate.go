@@ -0,0 +1,148 @@
+package couchbase
+
+import (
+	"context"
+	"fmt"
+
+	scheduler "go-sched"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// migrateBatchSize is the number of documents read per query while migrating a collection
+const migrateBatchSize = 500
+
+// migrationRow is a job document together with its document key
+type migrationRow[T any] struct {
+	DocKey string `json:"docKey"`
+	Job[T]
+}
+
+// MigrateCollection copies every job from srcCollection to dstCollection in the store's scope,
+// applying transform to each job on the way, and returns the number of jobs migrated. Returning
+// nil from transform skips the job. Documents keep their keys and are read in batches ordered by
+// key, which requires a primary index on srcCollection. When both collections are the same, jobs
+// are staged in a temporary collection first so the source isn't rewritten while it is read.
+func (s *CouchbaseStore[T]) MigrateCollection(ctx context.Context, srcCollection, dstCollection string, transform func(*scheduler.Job[T]) *scheduler.Job[T]) (int64, error) {
+	if srcCollection != dstCollection {
+		return s.migrate(ctx, srcCollection, dstCollection, transform)
+	}
+
+	tempCollection := srcCollection + "_migration"
+	manager := s.bucket.CollectionsV2()
+
+	err := manager.CreateCollection(s.scopeName, tempCollection, nil, &gocb.CreateCollectionOptions{Context: ctx})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary collection: %w", err)
+	}
+	defer manager.DropCollection(s.scopeName, tempCollection, &gocb.DropCollectionOptions{Context: context.WithoutCancel(ctx)})
+
+	err = s.bucket.Scope(s.scopeName).Collection(tempCollection).QueryIndexes().CreatePrimaryIndex(&gocb.CreatePrimaryQueryIndexOptions{
+		IgnoreIfExists: true,
+		Context:        ctx,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to index temporary collection: %w", err)
+	}
+
+	count, err := s.migrate(ctx, srcCollection, tempCollection, transform)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := s.migrate(ctx, tempCollection, dstCollection, nil); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (s *CouchbaseStore[T]) migrate(ctx context.Context, srcCollection, dstCollection string, transform func(*scheduler.Job[T]) *scheduler.Job[T]) (int64, error) {
+	query := fmt.Sprintf(`
+		SELECT META().id AS docKey, %s
+		FROM %s
+		WHERE META().id > $last
+		AND id IS VALUED
+		%s
+		ORDER BY META().id
+		LIMIT $limit`, jobFields, "`"+srcCollection+"`", s.tenantClause())
+
+	collection := s.bucket.Scope(s.scopeName).Collection(dstCollection)
+
+	var count int64
+	last := ""
+	for {
+		rows, err := s.migrationBatch(ctx, query, last)
+		if err != nil {
+			return count, err
+		}
+
+		for _, row := range rows {
+			jobDoc, err := s.migrateRow(row, transform)
+			if err != nil {
+				return count, err
+			}
+			if jobDoc == nil {
+				continue
+			}
+
+			_, err = collection.Upsert(row.DocKey, jobDoc, &gocb.UpsertOptions{
+				Context: ctx,
+			})
+			if err != nil {
+				return count, fmt.Errorf("failed to write job %s: %w", row.DocKey, err)
+			}
+
+			count++
+		}
+
+		if len(rows) < migrateBatchSize {
+			return count, nil
+		}
+		last = rows[len(rows)-1].DocKey
+	}
+}
+
+// migrateRow applies transform to a source row and returns the document to write,
+// or nil when transform skips the job
+func (s *CouchbaseStore[T]) migrateRow(row migrationRow[T], transform func(*scheduler.Job[T]) *scheduler.Job[T]) (*Job[T], error) {
+	job, err := row.toSchedulerJob(s.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job %s: %w", row.DocKey, err)
+	}
+
+	if transform != nil {
+		if job = transform(job); job == nil {
+			return nil, nil
+		}
+	}
+
+	return newJob(job, s.cfg)
+}
+
+func (s *CouchbaseStore[T]) migrationBatch(ctx context.Context, query, last string) ([]migrationRow[T], error) {
+	options := &gocb.QueryOptions{
+		NamedParameters: s.tenantParams(map[string]interface{}{
+			"last":  last,
+			"limit": migrateBatchSize,
+		}),
+		Context: ctx,
+	}
+
+	result, err := s.bucket.Scope(s.scopeName).Query(query, options)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	var rows []migrationRow[T]
+	for result.Next() {
+		var row migrationRow[T]
+		if err := result.Row(&row); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, result.Err()
+}
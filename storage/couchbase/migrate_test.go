@@ -0,0 +1,79 @@
+package couchbase
+
+import (
+	"encoding/json"
+	"testing"
+
+	scheduler "go-sched"
+)
+
+func TestMigrateRowDefaultsPriority(t *testing.T) {
+	// Jobs written before priority existed have no priority field at all. It decodes as zero,
+	// so rewriting the document is enough to store the default explicitly.
+	setDefaultPriority := func(job *scheduler.Job[string]) *scheduler.Job[string] { return job }
+	skipAll := func(*scheduler.Job[string]) *scheduler.Job[string] { return nil }
+
+	tests := []struct {
+		name         string
+		row          string
+		transform    func(*scheduler.Job[string]) *scheduler.Job[string]
+		wantSkipped  bool
+		wantPriority int
+	}{
+		{
+			name:      "missing priority",
+			row:       `{"docKey":"job-1","id":"job-1","status":"pending","processAfter":"2024-01-01T00:00:00Z","payload":"a"}`,
+			transform: setDefaultPriority,
+		},
+		{
+			name:         "existing priority kept",
+			row:          `{"docKey":"job-2","id":"job-2","status":"pending","processAfter":"2024-01-01T00:00:00Z","priority":5,"payload":"b"}`,
+			transform:    setDefaultPriority,
+			wantPriority: 5,
+		},
+		{
+			name:        "skipped by transform",
+			row:         `{"docKey":"job-3","id":"job-3","status":"pending","processAfter":"2024-01-01T00:00:00Z","payload":"c"}`,
+			transform:   skipAll,
+			wantSkipped: true,
+		},
+	}
+
+	store := NewCouchbaseStore[string](nil, "scope", "jobs")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var row migrationRow[string]
+			if err := json.Unmarshal([]byte(tt.row), &row); err != nil {
+				t.Fatal(err)
+			}
+
+			doc, err := store.migrateRow(row, tt.transform)
+			if err != nil {
+				t.Fatalf("migrateRow() error = %v", err)
+			}
+			if skipped := doc == nil; skipped != tt.wantSkipped {
+				t.Fatalf("migrateRow() skipped = %v, want %v", skipped, tt.wantSkipped)
+			}
+			if doc == nil {
+				return
+			}
+
+			// The written document carries an explicit priority field
+			stored, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fields map[string]any
+			if err := json.Unmarshal(stored, &fields); err != nil {
+				t.Fatal(err)
+			}
+			priority, ok := fields["priority"]
+			if !ok || int(priority.(float64)) != tt.wantPriority {
+				t.Fatalf("stored priority = %v (present %v), want %d", priority, ok, tt.wantPriority)
+			}
+			if doc.Id != row.Id || *doc.Payload != *row.Payload {
+				t.Fatalf("migrated doc = %+v, want id %q payload %q", doc, row.Id, *row.Payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package couchbase
+
+import (
+	"time"
+
+	scheduler "go-sched"
+)
+
+// CouchbaseStoreOption configures optional CouchbaseStore behaviour
+type CouchbaseStoreOption func(*storeConfig)
+
+type storeConfig struct {
+	encrypter           scheduler.Encrypter
+	tenantID            string
+	aging               time.Duration
+	onDecodeError       func(id string, err error)
+	poisonStatus        string
+	waitForIndexes      bool
+	deprioritizeFailing bool
+	timeFormat          TimeFormat
+	bulkDeleteBatch     int
+	metrics             scheduler.MetricsCollector
+}
+
+// WithEncrypter encrypts job payloads at rest, payloads are serialized to JSON
+// and stored as an opaque blob which is decrypted on read
+func WithEncrypter(encrypter scheduler.Encrypter) CouchbaseStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.encrypter = encrypter
+	}
+}
+
+// WithTenant scopes the store to a single tenant, jobs are stamped with the tenant
+// and the store prefixes document keys with the tenant and filters every query by it
+func WithTenant(tenantID string) CouchbaseStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.tenantID = tenantID
+	}
+}
+
+// WithAgingInterval raises a job's fetch priority by one for every interval it has waited
+// past its ProcessAfter, preventing starvation of low priority jobs. The effective priority
+// is computed in the N1QL ORDER BY clause.
+func WithAgingInterval(interval time.Duration) CouchbaseStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.aging = interval
+	}
+}
+
+// WithOnDecodeError calls fn for every job FetchPendingJobs skips because its document can't be
+// decoded, e.g. after a payload schema change. The rest of the batch is still returned.
+func WithOnDecodeError(fn func(id string, err error)) CouchbaseStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.onDecodeError = fn
+	}
+}
+
+// WithPoisonStatus moves jobs that can't be decoded to status, e.g. "poison", so they stop being
+// fetched and can be inspected later. Without it undecodable jobs stay pending and are skipped on
+// every fetch.
+func WithPoisonStatus(status string) CouchbaseStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.poisonStatus = status
+	}
+}
+
+// WithWaitForIndexes makes NewCouchbaseStore block until every index on the collection is online,
+// for at most a minute. Waiting is best effort since the constructor can't fail; call
+// WaitForIndexReady directly to handle a timeout.
+func WithWaitForIndexes(enabled bool) CouchbaseStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.waitForIndexes = enabled
+	}
+}
+
+// WithDeprioritizeFailing orders jobs with fewer attempts first among jobs of the same priority,
+// so during a partial outage jobs that keep failing don't crowd out fresh and long-waiting ones
+func WithDeprioritizeFailing() CouchbaseStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.deprioritizeFailing = true
+	}
+}
+
+// WithTimeFormat sets how processAfter and visibleAfter are stored and queried (default
+// TimeFormatNative). Documents in either format are read, but only jobs stored in the configured
+// format are fetched, so migrate existing documents before switching.
+func WithTimeFormat(format TimeFormat) CouchbaseStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.timeFormat = format
+	}
+}
+
+// WithBulkDeleteBatchSize sets how many removes BulkDelete sends in a single bulk operation
+// (default 100), batches are sent in parallel
+func WithBulkDeleteBatchSize(n int) CouchbaseStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.bulkDeleteBatch = n
+	}
+}
+
+// WithMetricsCollector records the payload size of every job the store adds with collector,
+// e.g. a scheduler.PayloadMetrics. Payloads are measured as JSON before encryption.
+func WithMetricsCollector(collector scheduler.MetricsCollector) CouchbaseStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.metrics = collector
+	}
+}
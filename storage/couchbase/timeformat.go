@@ -0,0 +1,71 @@
+package couchbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// TimeFormat decides how the processAfter and visibleAfter fields are stored
+type TimeFormat int
+
+const (
+	// TimeFormatNative stores times as RFC 3339 strings
+	TimeFormatNative TimeFormat = iota
+	// TimeFormatEpochMillis stores times as integer milliseconds since the Unix epoch, for consumers
+	// of the raw documents that expect integers
+	TimeFormatEpochMillis
+)
+
+// timeValue returns t in the representation the store writes and queries with
+func (f TimeFormat) timeValue(t time.Time) any {
+	if f == TimeFormatEpochMillis {
+		return t.UnixMilli()
+	}
+	return t
+}
+
+// storedTime is a job time field in the store's time format, either format is accepted on read
+// so documents written before a format change still decode
+type storedTime struct {
+	time   time.Time
+	format TimeFormat
+}
+
+func newStoredTime(t time.Time, format TimeFormat) storedTime {
+	return storedTime{time: t, format: format}
+}
+
+func newStoredTimePtr(t *time.Time, format TimeFormat) *storedTime {
+	if t == nil {
+		return nil
+	}
+	stored := newStoredTime(*t, format)
+	return &stored
+}
+
+// timePtr returns the time of an optional stored time
+func (t *storedTime) timePtr() *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.time
+}
+
+func (t storedTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.format.timeValue(t.time))
+}
+
+func (t *storedTime) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		t.format = TimeFormatNative
+		return t.time.UnmarshalJSON(data)
+	}
+
+	var millis int64
+	if err := json.Unmarshal(data, &millis); err != nil {
+		return err
+	}
+	t.time, t.format = time.UnixMilli(millis), TimeFormatEpochMillis
+	return nil
+}
@@ -0,0 +1,64 @@
+package couchbase
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+)
+
+func TestTimeFormatRoundTrip(t *testing.T) {
+	due := time.Date(2024, 3, 1, 9, 30, 0, 125_000_000, time.UTC)
+	visible := due.Add(30 * time.Second)
+
+	tests := []struct {
+		name       string
+		format     TimeFormat
+		wantStored string // processAfter as it appears in the raw document
+	}{
+		{name: "native", format: TimeFormatNative, wantStored: `"2024-03-01T09:30:00.125Z"`},
+		{name: "epoch millis", format: TimeFormatEpochMillis, wantStored: "1709285400125"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := storeConfig{timeFormat: tt.format}
+			doc, err := newJob(&scheduler.Job[string]{Id: "job-1", Status: "pending", ProcessAfter: due, VisibleAfter: &visible}, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var raw map[string]json.RawMessage
+			if err := json.Unmarshal(data, &raw); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(raw["processAfter"]); got != tt.wantStored {
+				t.Fatalf("processAfter stored as %s, want %s", got, tt.wantStored)
+			}
+			// Queries compare against the same representation
+			if got, _ := json.Marshal(tt.format.timeValue(due)); string(got) != tt.wantStored {
+				t.Fatalf("processAfter queried as %s, want %s", got, tt.wantStored)
+			}
+
+			// Documents in either format are read, whichever format the store is configured with
+			for _, readFormat := range []TimeFormat{TimeFormatNative, TimeFormatEpochMillis} {
+				var fetched Job[string]
+				if err := json.Unmarshal(data, &fetched); err != nil {
+					t.Fatal(err)
+				}
+				job, err := fetched.toSchedulerJob(storeConfig{timeFormat: readFormat})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !job.ProcessAfter.Equal(due) || !job.VisibleAfter.Equal(visible) {
+					t.Fatalf("read processAfter %s and visibleAfter %s, want %s and %s", job.ProcessAfter, job.VisibleAfter, due, visible)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package fair
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	scheduler "go-sched"
+)
+
+// ErrUnknownTenant is returned for a job whose TenantID has no store in the FairStore
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// TenantWeights maps a tenant id to its share of throughput relative to the other tenants,
+// e.g. weights 1 and 3 give the tenants a quarter and three quarters of the jobs fetched.
+// Tenants without a positive weight get weight 1.
+type TenantWeights map[string]int
+
+// tenant is one tenant's store and its position in the weighted round robin
+type tenant[T any] struct {
+	id     string
+	store  scheduler.JobStore[T]
+	weight float64
+	pass   float64 // Virtual time consumed by the jobs handed out so far, advanced by 1/weight per job
+}
+
+// FairStore shares fetch capacity between tenant-scoped stores by weight, so a tenant with a deep
+// backlog can't take every worker while other tenants have pending jobs. The scheduler fetches as
+// many jobs as it has free worker slots, so sharing fetches shares workers. Capacity a tenant
+// leaves unused because it has no pending jobs goes to the other tenants, and a tenant that was
+// idle rejoins at the current virtual time instead of catching up on the share it missed.
+type FairStore[T any] struct {
+	mu      sync.Mutex
+	tenants []*tenant[T]
+	byID    map[string]*tenant[T]
+	vtime   float64 // Lowest pass among tenants that still had pending jobs on the last fetch
+}
+
+// NewFairStore creates a store fetching from the given tenant stores, keyed by tenant id, in
+// proportion to their weights. Jobs are routed to their tenant's store by Job.TenantID.
+func NewFairStore[T any](stores map[string]scheduler.JobStore[T], weights TenantWeights) *FairStore[T] {
+	s := &FairStore[T]{byID: make(map[string]*tenant[T], len(stores))}
+	for id, store := range stores {
+		t := &tenant[T]{id: id, store: store, weight: float64(max(weights[id], 1))}
+		s.tenants = append(s.tenants, t)
+		s.byID[id] = t
+	}
+	// Break ties between tenants in a stable order
+	sort.Slice(s.tenants, func(i, j int) bool { return s.tenants[i].id < s.tenants[j].id })
+
+	return s
+}
+
+// FetchPendingJobs splits limit between the tenants by weight and fetches each tenant's share from
+// its store. Slots a tenant can't fill are offered to the tenants that filled theirs until limit is
+// reached or every tenant runs out of jobs. A failing tenant store is skipped, an error is returned
+// only if no jobs could be fetched.
+func (s *FairStore[T]) FetchPendingJobs(after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit <= 0 {
+		return s.fetchAll(after, visibilityTimeout)
+	}
+
+	for _, t := range s.tenants {
+		t.pass = max(t.pass, s.vtime)
+	}
+
+	var jobs []*scheduler.Job[T]
+	var errs []error
+
+	active := s.tenants
+	for remaining := limit; remaining > 0 && len(active) > 0; {
+		shares := allocate(active, remaining)
+
+		var next []*tenant[T]
+		for i, t := range active {
+			if shares[i] == 0 {
+				next = append(next, t)
+				continue
+			}
+
+			entries, err := t.store.FetchPendingJobs(after, shares[i], visibilityTimeout)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("tenant %s: %w", t.id, err))
+				continue
+			}
+
+			jobs = append(jobs, stamp(t, entries)...)
+			t.pass += float64(len(entries)) / t.weight
+			remaining -= len(entries)
+
+			// A tenant returning less than its share has no more pending jobs for now
+			if len(entries) == shares[i] {
+				next = append(next, t)
+			}
+		}
+		active = next
+	}
+
+	if len(active) > 0 {
+		s.vtime = active[0].pass
+		for _, t := range active[1:] {
+			s.vtime = min(s.vtime, t.pass)
+		}
+	}
+
+	if len(jobs) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return jobs, nil
+}
+
+// fetchAll fetches every pending job from every tenant for an unlimited fetch
+func (s *FairStore[T]) fetchAll(after time.Time, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	var jobs []*scheduler.Job[T]
+	var errs []error
+	for _, t := range s.tenants {
+		entries, err := t.store.FetchPendingJobs(after, 0, visibilityTimeout)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", t.id, err))
+			continue
+		}
+		jobs = append(jobs, stamp(t, entries)...)
+	}
+
+	if len(jobs) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return jobs, nil
+}
+
+// stamp sets the tenant on jobs from stores that don't, so their updates are routed back
+func stamp[T any](t *tenant[T], jobs []*scheduler.Job[T]) []*scheduler.Job[T] {
+	for _, job := range jobs {
+		if job.TenantID == "" {
+			job.TenantID = t.id
+		}
+	}
+	return jobs
+}
+
+// allocate hands out n slots one at a time to the tenant that would reach the lowest pass by
+// taking it, returning each tenant's number of slots
+func allocate[T any](tenants []*tenant[T], n int) []int {
+	shares := make([]int, len(tenants))
+	passes := make([]float64, len(tenants))
+	for i, t := range tenants {
+		passes[i] = t.pass
+	}
+
+	for ; n > 0; n-- {
+		next := 0
+		for i, t := range tenants {
+			if passes[i]+1/t.weight < passes[next]+1/tenants[next].weight {
+				next = i
+			}
+		}
+		shares[next]++
+		passes[next] += 1 / tenants[next].weight
+	}
+
+	return shares
+}
+
+// UpdateJob updates the job in its tenant's store
+func (s *FairStore[T]) UpdateJob(job *scheduler.Job[T]) error {
+	t, err := s.tenantFor(job)
+	if err != nil {
+		return err
+	}
+	return t.store.UpdateJob(job)
+}
+
+// AddJob adds the job to its tenant's store
+func (s *FairStore[T]) AddJob(job *scheduler.Job[T]) error {
+	t, err := s.tenantFor(job)
+	if err != nil {
+		return err
+	}
+	return t.store.AddJob(job)
+}
+
+func (s *FairStore[T]) tenantFor(job *scheduler.Job[T]) (*tenant[T], error) {
+	t, ok := s.byID[job.TenantID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTenant, job.TenantID)
+	}
+	return t, nil
+}
@@ -0,0 +1,92 @@
+package fair
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestFairStoreThroughputRatio(t *testing.T) {
+	tests := []struct {
+		name      string
+		weights   TenantWeights
+		backlogs  map[string]int
+		wantShare float64 // Share of the handled jobs belonging to tenant "heavy"
+	}{
+		{
+			name:      "weights 1:3",
+			weights:   TenantWeights{"light": 1, "heavy": 3},
+			backlogs:  map[string]int{"light": 400, "heavy": 400},
+			wantShare: 0.75,
+		},
+		{
+			name:      "equal weights",
+			backlogs:  map[string]int{"light": 400, "heavy": 400},
+			wantShare: 0.5,
+		},
+		{
+			name:      "unused share goes to the busy tenant",
+			weights:   TenantWeights{"light": 3, "heavy": 1},
+			backlogs:  map[string]int{"heavy": 400},
+			wantShare: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stores := make(map[string]scheduler.JobStore[string])
+			for _, id := range []string{"light", "heavy"} {
+				store := storage.NewMemoryStore[string]()
+				for i := range tt.backlogs[id] {
+					job := &scheduler.Job[string]{Id: fmt.Sprintf("%s-%d", id, i), Status: "pending", ProcessAfter: time.Now(), TenantID: id, Payload: id}
+					if err := store.AddJob(job); err != nil {
+						t.Fatal(err)
+					}
+				}
+				stores[id] = store
+			}
+
+			// Sample the first jobs handled while both tenants still have a backlog
+			const sample = 200
+			var mu sync.Mutex
+			handled := make(map[string]int)
+			sampled := make(chan struct{})
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				defer mu.Unlock()
+				handled[job.Payload]++
+				if handled["light"]+handled["heavy"] == sample {
+					close(sampled)
+				}
+				return nil
+			}
+
+			log := slog.New(slog.NewTextHandler(io.Discard, nil))
+			s := scheduler.NewScheduler(NewFairStore(stores, tt.weights), 8, time.Millisecond, time.Minute, handler, log)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s.Run(ctx)
+
+			select {
+			case <-sampled:
+			case <-time.After(10 * time.Second):
+				t.Fatalf("fewer than %d jobs handled", sample)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if share := float64(handled["heavy"]) / float64(sample); share < tt.wantShare-0.05 || share > tt.wantShare+0.05 {
+				t.Fatalf("heavy tenant handled %.2f of the jobs (%v), want %.2f", share, handled, tt.wantShare)
+			}
+		})
+	}
+}
@@ -1,85 +1,467 @@
 package storage
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	scheduler "go-sched"
 )
 
 // MemoryStore is an in-memory implementation of JobStore for testing and development
+// Jobs are copied in and out of the store, so callers never share state with it
 type MemoryStore[T any] struct {
-	jobs map[string]*scheduler.Job[T]
+	mu    sync.RWMutex
+	jobs  map[string]*scheduler.Job[T]
+	dedup map[string]time.Time // Dedup key to the end of its dedup window
+	seq   int64                // Sequence assigned to the last added job
+	cfg   memoryConfig
+	tie   scheduler.TieBreak // Orders equally due jobs of equal priority, set with SetTieBreak
+}
+
+// MemoryStoreOption configures optional MemoryStore behaviour
+type MemoryStoreOption func(*memoryConfig)
+
+type memoryConfig struct {
+	aging               time.Duration
+	maxJobs             int
+	deprioritizeFailing bool
+}
+
+// WithMaxJobs caps the number of jobs kept in the store. Once exceeded, the oldest
+// completed and failed jobs are evicted; pending jobs are never evicted.
+func WithMaxJobs(maxJobs int) MemoryStoreOption {
+	return func(cfg *memoryConfig) {
+		cfg.maxJobs = maxJobs
+	}
+}
+
+// WithAgingInterval raises a job's fetch priority by one for every interval it has waited
+// past its ProcessAfter, preventing starvation of low priority jobs
+func WithAgingInterval(interval time.Duration) MemoryStoreOption {
+	return func(cfg *memoryConfig) {
+		cfg.aging = interval
+	}
+}
+
+// WithDeprioritizeFailing orders jobs with fewer attempts first among jobs of the same priority,
+// so during a partial outage jobs that keep failing don't crowd out fresh and long-waiting ones
+func WithDeprioritizeFailing() MemoryStoreOption {
+	return func(cfg *memoryConfig) {
+		cfg.deprioritizeFailing = true
+	}
 }
 
 // NewMemoryStore creates a new in-memory job store
-func NewMemoryStore[T any]() *MemoryStore[T] {
-	return &MemoryStore[T]{
-		jobs: make(map[string]*scheduler.Job[T]),
+func NewMemoryStore[T any](opts ...MemoryStoreOption) *MemoryStore[T] {
+	s := &MemoryStore[T]{
+		jobs:  make(map[string]*scheduler.Job[T]),
+		dedup: make(map[string]time.Time),
 	}
+
+	for _, opt := range opts {
+		opt(&s.cfg)
+	}
+
+	return s
 }
 
 // FetchPendingJobs retrieves pending jobs that are ready to be processed
 // Sets visibility timeout on fetched jobs to mark them as being processed
 func (s *MemoryStore[T]) FetchPendingJobs(after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	return s.fetch(after, limit, nil)
+}
+
+// FetchPendingJobsByType retrieves pending jobs that are ready to be processed and pass filter
+func (s *MemoryStore[T]) FetchPendingJobsByType(filter scheduler.TypeFilter, after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	return s.fetch(after, limit, &filter)
+}
+
+// fetch returns up to limit due and visible pending jobs in fetch order, of the types passing
+// filter if it isn't nil
+func (s *MemoryStore[T]) fetch(after time.Time, limit int, filter *scheduler.TypeFilter) ([]*scheduler.Job[T], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	entries := make([]*scheduler.Job[T], 0)
 
 	for _, job := range s.jobs {
 		// Only fetch jobs that are pending, ready to run, and visible
 		if job.Status == "pending" &&
 			job.ProcessAfter.Before(after) &&
-			job.IsVisible() {
+			job.IsVisible() &&
+			(filter == nil || filter.Matches(job.Type)) {
 
-			entries = append(entries, job)
+			entry := *job
+			entries = append(entries, &entry)
 		}
+	}
 
-		if len(entries) >= limit {
-			break
-		}
+	now := time.Now()
+	sort.Slice(entries, func(i, j int) bool {
+		return s.fetchesBefore(entries[i], entries[j], now)
+	})
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
 	}
 
 	return entries, nil
 }
 
-// UpdateJob updates an existing job's status and processing timestamp
+// fetchesBefore reports whether a is fetched before b: highest (aged) priority first, then fewest
+// attempts if configured, earliest due, tie break
+func (s *MemoryStore[T]) fetchesBefore(a, b *scheduler.Job[T], now time.Time) bool {
+	pa, pb := a.EffectivePriority(now, s.cfg.aging), b.EffectivePriority(now, s.cfg.aging)
+	if pa != pb {
+		return pa > pb
+	}
+	if s.cfg.deprioritizeFailing && a.Attempts != b.Attempts {
+		return a.Attempts < b.Attempts
+	}
+	if !a.ProcessAfter.Equal(b.ProcessAfter) {
+		return a.ProcessAfter.Before(b.ProcessAfter)
+	}
+	return scheduler.TieBreakLess(s.tie, a, b)
+}
+
+// SetTieBreak orders pending jobs that are equally due and of equal priority by tb
+func (s *MemoryStore[T]) SetTieBreak(tb scheduler.TieBreak) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tie = tb
+}
+
+// ClaimNext makes the next due, visible pending job in fetch order invisible for visibilityTimeout
+// and returns it, or false if no job is due
+func (s *MemoryStore[T]) ClaimNext(visibilityTimeout time.Duration) (*scheduler.Job[T], bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var next *scheduler.Job[T]
+	for _, job := range s.jobs {
+		if job.Status != "pending" || !job.ProcessAfter.Before(now) || !job.IsVisible() {
+			continue
+		}
+		if next == nil || s.fetchesBefore(job, next, now) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, false, nil
+	}
+
+	next.MakeInvisible(visibilityTimeout)
+	next.Version++
+	entry := *next
+
+	return &entry, true, nil
+}
+
+// CancelByKey deletes the pending jobs with the idempotency key that aren't being processed
+func (s *MemoryStore[T]) CancelByKey(key string) (int, error) {
+	if key == "" {
+		return 0, errors.New("idempotency key cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cancelled := 0
+	for id, job := range s.jobs {
+		if job.IdempotencyKey == key && job.IsVisible() {
+			delete(s.jobs, id)
+			cancelled++
+		}
+	}
+
+	return cancelled, nil
+}
+
+// ShiftSchedule adds by to the due time of pending jobs due within [from, to) that aren't being
+// processed, a worker's update of a job it handles would otherwise fail on the bumped version
+func (s *MemoryStore[T]) ShiftSchedule(from, to time.Time, by time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shifted := 0
+	for _, job := range s.jobs {
+		if job.IsVisible() && !job.ProcessAfter.Before(from) && job.ProcessAfter.Before(to) {
+			job.ProcessAfter = job.ProcessAfter.Add(by)
+			job.Version++
+			shifted++
+		}
+	}
+
+	return shifted, nil
+}
+
+// UpdateJob updates an existing job's status, terminal reason, schedule, attempts, processing instance, remaining runs, checkpoint and processing timestamp
+// An update from a copy whose Version is stale fails with ErrConcurrentModification
 func (s *MemoryStore[T]) UpdateJob(job *scheduler.Job[T]) error {
 	if job.Id == "" {
 		return errors.New("job Id cannot be empty")
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	existingJob, ok := s.jobs[job.Id]
 	if !ok {
 		return fmt.Errorf("job not found: %s", job.Id)
 	}
 
-	// Update fields
+	if job.Version != existingJob.Version {
+		return fmt.Errorf("%w: job %s is at version %d, update is from version %d", scheduler.ErrConcurrentModification, job.Id, existingJob.Version, job.Version)
+	}
+
+	// Update fields, bumping the version on both copies so the caller can keep updating
+	existingJob.Version++
+	job.Version = existingJob.Version
 	existingJob.Status = job.Status
+	existingJob.ProcessAfter = job.ProcessAfter
 	existingJob.ProcessedAt = job.ProcessedAt
 	existingJob.VisibleAfter = job.VisibleAfter
+	existingJob.Attempts = job.Attempts
+	existingJob.ProcessedBy = job.ProcessedBy
+	existingJob.RemainingRuns = job.RemainingRuns
+	existingJob.Checkpoint = job.Checkpoint
+	existingJob.TerminalReason = job.TerminalReason
 
+	s.evictLocked()
 	return nil
 }
 
+// TransitionStatus sets the job status to `to` only if it is currently `from`
+// A job being processed isn't transitioned, as its worker owns the status until it saves the result
+func (s *MemoryStore[T]) TransitionStatus(id, from, to string) (bool, error) {
+	if id == "" {
+		return false, errors.New("job Id cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return false, fmt.Errorf("job not found: %s", id)
+	}
+
+	if job.Status != from || inFlight(job) {
+		return false, nil
+	}
+
+	job.Status = to
+	job.Version++
+	return true, nil
+}
+
 // AddJob adds a new job to the store
 func (s *MemoryStore[T]) AddJob(job *scheduler.Job[T]) error {
 	if job.Id == "" {
 		return errors.New("job Id cannot be empty")
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addJobLocked(job)
+}
+
+// AddUniqueJob adds the job unless a job with the same dedup key was added within window
+func (s *MemoryStore[T]) AddUniqueJob(job *scheduler.Job[T], key string, window time.Duration) error {
+	if job.Id == "" {
+		return errors.New("job Id cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, expiresAt := range s.dedup {
+		if !now.Before(expiresAt) {
+			delete(s.dedup, k)
+		}
+	}
+
+	if _, exists := s.dedup[key]; exists {
+		return fmt.Errorf("%w: key %s", scheduler.ErrDuplicateJob, key)
+	}
+
+	if err := s.addJobLocked(job); err != nil {
+		return err
+	}
+
+	s.dedup[key] = now.Add(window)
+	return nil
+}
+
+func (s *MemoryStore[T]) addJobLocked(job *scheduler.Job[T]) error {
 	if _, exists := s.jobs[job.Id]; exists {
 		return fmt.Errorf("job already exists: %s", job.Id)
 	}
 
-	s.jobs[job.Id] = job
+	s.seq++
+	job.Sequence = s.seq
+
+	stored := *job
+	s.jobs[job.Id] = &stored
+
+	s.evictLocked()
 	return nil
 }
 
+// evictLocked removes the oldest finished jobs until the store is within its job cap
+func (s *MemoryStore[T]) evictLocked() {
+	if s.cfg.maxJobs <= 0 || len(s.jobs) <= s.cfg.maxJobs {
+		return
+	}
+
+	finished := make([]*scheduler.Job[T], 0)
+	for _, job := range s.jobs {
+		if job.Status != "pending" {
+			finished = append(finished, job)
+		}
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finishedAt(finished[i]).Before(finishedAt(finished[j]))
+	})
+
+	for _, job := range finished {
+		if len(s.jobs) <= s.cfg.maxJobs {
+			return
+		}
+		delete(s.jobs, job.Id)
+	}
+}
+
+// finishedAt returns when a job finished, failed jobs carry no ProcessedAt so fall back to ProcessAfter
+func finishedAt[T any](job *scheduler.Job[T]) time.Time {
+	if job.ProcessedAt != nil {
+		return *job.ProcessedAt
+	}
+	return job.ProcessAfter
+}
+
+// inFlight reports whether a worker is processing the job, i.e. it is marked as processing or is
+// pending but invisible
+func inFlight[T any](job *scheduler.Job[T]) bool {
+	return job.Status == "processing" || (job.Status == "pending" && !job.IsVisible())
+}
+
+// ExplainFetch reports which fetch predicates the job fails right now
+func (s *MemoryStore[T]) ExplainFetch(id string) (scheduler.FetchExplanation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return scheduler.FetchExplanation{}, fmt.Errorf("job not found: %s", id)
+	}
+
+	return scheduler.ExplainFetch(job, time.Now()), nil
+}
+
+// CountInvisible returns the number of pending jobs whose visibility timeout hasn't expired
+func (s *MemoryStore[T]) CountInvisible() (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, job := range s.jobs {
+		if job.Status == "pending" && !job.IsVisible() {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Len returns the number of jobs currently held by the store
+func (s *MemoryStore[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.jobs)
+}
+
 // GetJobs returns all jobs (for debugging/testing)
 func (s *MemoryStore[T]) GetJobs() map[string]*scheduler.Job[T] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	result := make(map[string]*scheduler.Job[T])
 	for k, v := range s.jobs {
-		result[k] = v
+		job := *v
+		result[k] = &job
 	}
 	return result
 }
+
+// Iterate calls fn with a copy of each job until fn returns false, without copying the whole store
+// The store is read-locked while iterating, so fn must not write to the store
+func (s *MemoryStore[T]) Iterate(fn func(*scheduler.Job[T]) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, v := range s.jobs {
+		job := *v
+		if !fn(&job) {
+			return
+		}
+	}
+}
+
+// IterateJobs implements scheduler.JobIterator
+func (s *MemoryStore[T]) IterateJobs(ctx context.Context, fn func(*scheduler.Job[T]) bool) error {
+	s.Iterate(func(job *scheduler.Job[T]) bool {
+		return ctx.Err() == nil && fn(job)
+	})
+
+	return ctx.Err()
+}
+
+// CountFailedByReason returns the number of failed jobs per terminal reason
+func (s *MemoryStore[T]) CountFailedByReason() (map[scheduler.TerminalReason]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[scheduler.TerminalReason]int)
+	for _, job := range s.jobs {
+		if job.Status == "failed" {
+			counts[job.TerminalReason]++
+		}
+	}
+
+	return counts, nil
+}
+
+// FetchCompleted returns up to limit jobs (0 for no limit) completed within [from, to), ordered by completion time
+func (s *MemoryStore[T]) FetchCompleted(from, to time.Time, limit int) ([]*scheduler.Job[T], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entries []*scheduler.Job[T]
+	for _, job := range s.jobs {
+		if job.Status == "completed" && job.ProcessedAt != nil &&
+			!job.ProcessedAt.Before(from) && job.ProcessedAt.Before(to) {
+			jobCopy := *job
+			entries = append(entries, &jobCopy)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ProcessedAt.Before(*entries[j].ProcessedAt)
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,690 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+)
+
+func TestMemoryStoreVersionConflicts(t *testing.T) {
+	tests := []struct {
+		name         string
+		interfere    func(s *MemoryStore[string], job *scheduler.Job[string]) error
+		wantConflict bool
+	}{
+		{
+			name: "stale update",
+			interfere: func(s *MemoryStore[string], job *scheduler.Job[string]) error {
+				other := s.GetJobs()[job.Id]
+				other.Attempts++
+				return s.UpdateJob(other)
+			},
+			wantConflict: true,
+		},
+		{
+			name: "schedule shift skips an in-flight job",
+			interfere: func(s *MemoryStore[string], job *scheduler.Job[string]) error {
+				_, err := s.ShiftSchedule(time.Time{}, time.Now().Add(time.Hour), time.Hour)
+				return err
+			},
+		},
+		{
+			name: "status transition skips an in-flight job",
+			interfere: func(s *MemoryStore[string], job *scheduler.Job[string]) error {
+				changed, err := s.TransitionStatus(job.Id, "pending", "paused")
+				if changed {
+					return errors.New("in-flight job transitioned")
+				}
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore[string]()
+			if err := s.AddJob(&scheduler.Job[string]{Id: "job-1", Status: "pending", ProcessAfter: time.Now().Add(-time.Second)}); err != nil {
+				t.Fatal(err)
+			}
+
+			// The worker's copy, claimed and invisible while its handler runs
+			job, ok, err := s.ClaimNext(time.Minute)
+			if err != nil || !ok {
+				t.Fatalf("ClaimNext() = %v, %v", ok, err)
+			}
+
+			if err := tt.interfere(s, job); err != nil {
+				t.Fatalf("interfering update failed: %v", err)
+			}
+
+			job.Status = "completed"
+			err = s.UpdateJob(job)
+			if conflict := errors.Is(err, scheduler.ErrConcurrentModification); conflict != tt.wantConflict {
+				t.Fatalf("UpdateJob() error = %v, want conflict = %v", err, tt.wantConflict)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreShiftScheduleMovesVisibleJobs(t *testing.T) {
+	s := NewMemoryStore[string]()
+	due := time.Now().Add(time.Minute)
+	if err := s.AddJob(&scheduler.Job[string]{Id: "job-1", Status: "pending", ProcessAfter: due}); err != nil {
+		t.Fatal(err)
+	}
+
+	shifted, err := s.ShiftSchedule(due, due.Add(time.Second), time.Hour)
+	if err != nil || shifted != 1 {
+		t.Fatalf("ShiftSchedule() = %d, %v, want 1 job shifted", shifted, err)
+	}
+	if got := s.GetJobs()["job-1"].ProcessAfter; !got.Equal(due.Add(time.Hour)) {
+		t.Fatalf("ProcessAfter = %s, want %s", got, due.Add(time.Hour))
+	}
+}
+
+func TestMemoryStoreClaimNextOrder(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		jobs   []*scheduler.Job[string]
+		wantID string
+	}{
+		{
+			name: "earliest due first",
+			jobs: []*scheduler.Job[string]{
+				{Id: "later", Status: "pending", ProcessAfter: now.Add(-time.Second)},
+				{Id: "earlier", Status: "pending", ProcessAfter: now.Add(-time.Minute)},
+			},
+			wantID: "earlier",
+		},
+		{
+			name: "highest priority before earliest due",
+			jobs: []*scheduler.Job[string]{
+				{Id: "earlier", Status: "pending", ProcessAfter: now.Add(-time.Minute)},
+				{Id: "urgent", Status: "pending", ProcessAfter: now.Add(-time.Second), Priority: 5},
+			},
+			wantID: "urgent",
+		},
+		{
+			name: "skips invisible and future jobs",
+			jobs: []*scheduler.Job[string]{
+				{Id: "future", Status: "pending", ProcessAfter: now.Add(time.Hour), Priority: 9},
+				{Id: "done", Status: "completed", ProcessAfter: now.Add(-time.Hour)},
+				{Id: "due", Status: "pending", ProcessAfter: now.Add(-time.Second)},
+			},
+			wantID: "due",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore[string]()
+			for _, job := range tt.jobs {
+				if err := s.AddJob(job); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			job, ok, err := s.ClaimNext(time.Minute)
+			if err != nil || !ok {
+				t.Fatalf("ClaimNext() = %v, %v", ok, err)
+			}
+			if job.Id != tt.wantID {
+				t.Fatalf("ClaimNext() claimed %s, want %s", job.Id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreClaimNextConcurrent(t *testing.T) {
+	const jobs, claimers = 200, 8
+
+	s := NewMemoryStore[string]()
+	for i := range jobs {
+		job := &scheduler.Job[string]{Id: fmt.Sprintf("job-%d", i), Status: "pending", ProcessAfter: time.Now().Add(-time.Second)}
+		if err := s.AddJob(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	claimed := make(map[string]int)
+	var wg sync.WaitGroup
+	for range claimers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, ok, err := s.ClaimNext(time.Minute)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if !ok {
+					return
+				}
+				mu.Lock()
+				claimed[job.Id]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(claimed) != jobs {
+		t.Fatalf("claimed %d distinct jobs, want %d", len(claimed), jobs)
+	}
+	for id, n := range claimed {
+		if n != 1 {
+			t.Errorf("job %s claimed %d times", id, n)
+		}
+	}
+}
+
+func TestMemoryStoreIterateJobs(t *testing.T) {
+	tests := []struct {
+		name      string
+		stopAfter int  // fn returns false once it has seen this many jobs, 0 to never stop
+		cancel    bool // Cancel the context after the first job
+		wantSeen  int
+		wantErr   error
+	}{
+		{name: "full traversal", wantSeen: 50},
+		{name: "early termination", stopAfter: 10, wantSeen: 10},
+		{name: "cancelled context", cancel: true, wantSeen: 1, wantErr: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore[int]()
+			for i := range 50 {
+				if err := s.AddJob(&scheduler.Job[int]{Id: fmt.Sprintf("job-%d", i), Status: "pending", Payload: i}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			seen := make(map[string]bool)
+			err := s.IterateJobs(ctx, func(job *scheduler.Job[int]) bool {
+				seen[job.Id] = true
+				// Jobs are copies, changing them leaves the store untouched
+				job.Status = "completed"
+				if tt.cancel {
+					cancel()
+				}
+				return tt.stopAfter == 0 || len(seen) < tt.stopAfter
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("IterateJobs() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(seen) != tt.wantSeen {
+				t.Fatalf("IterateJobs() visited %d jobs, want %d", len(seen), tt.wantSeen)
+			}
+			for _, job := range s.GetJobs() {
+				if job.Status != "pending" {
+					t.Fatalf("job %s changed through its copy", job.Id)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStorePriorityAging(t *testing.T) {
+	tests := []struct {
+		name      string
+		aging     time.Duration
+		oldWaited time.Duration // How long the low priority job has been due
+		wantFirst string
+	}{
+		{name: "no aging, priority wins", oldWaited: time.Hour, wantFirst: "fresh-urgent"},
+		{name: "aged less than the priority gap", aging: time.Minute, oldWaited: 3 * time.Minute, wantFirst: "fresh-urgent"},
+		{name: "old job outranks fresh high priority ones", aging: time.Minute, oldWaited: 10 * time.Minute, wantFirst: "old-low"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []MemoryStoreOption
+			if tt.aging > 0 {
+				opts = append(opts, WithAgingInterval(tt.aging))
+			}
+			s := NewMemoryStore[string](opts...)
+
+			now := time.Now()
+			jobs := []*scheduler.Job[string]{
+				{Id: "old-low", Status: "pending", Priority: 0, ProcessAfter: now.Add(-tt.oldWaited)},
+				{Id: "fresh-urgent", Status: "pending", Priority: 5, ProcessAfter: now.Add(-time.Second)},
+				{Id: "fresh-high", Status: "pending", Priority: 4, ProcessAfter: now.Add(-time.Second)},
+			}
+			for _, job := range jobs {
+				if err := s.AddJob(job); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fetched, err := s.FetchPendingJobs(now, 1, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(fetched) != 1 || fetched[0].Id != tt.wantFirst {
+				t.Fatalf("fetched %v first, want %s", fetched, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreMaxJobs(t *testing.T) {
+	base := time.Now().Add(-time.Hour)
+	at := func(d time.Duration) *time.Time {
+		ts := base.Add(d)
+		return &ts
+	}
+
+	tests := []struct {
+		name    string
+		maxJobs int
+		pending int // Pending jobs added after the finished ones
+		wantIDs []string
+	}{
+		{
+			name:    "no cap keeps everything",
+			pending: 3,
+			wantIDs: []string{"completed-old", "completed-new", "failed", "pending-0", "pending-1", "pending-2"},
+		},
+		{
+			name:    "oldest finished evicted first",
+			maxJobs: 4,
+			pending: 2,
+			wantIDs: []string{"completed-new", "failed", "pending-0", "pending-1"},
+		},
+		{
+			name:    "pending jobs kept past the cap",
+			maxJobs: 2,
+			pending: 3,
+			wantIDs: []string{"pending-0", "pending-1", "pending-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore[string](WithMaxJobs(tt.maxJobs))
+
+			// Failed jobs carry no ProcessedAt and are ordered by their due time
+			jobs := []*scheduler.Job[string]{
+				{Id: "completed-old", Status: "completed", ProcessAfter: base, ProcessedAt: at(time.Minute)},
+				{Id: "completed-new", Status: "completed", ProcessAfter: base, ProcessedAt: at(3 * time.Minute)},
+				{Id: "failed", Status: "failed", ProcessAfter: base.Add(2 * time.Minute)},
+			}
+			for i := 0; i < tt.pending; i++ {
+				jobs = append(jobs, &scheduler.Job[string]{Id: fmt.Sprintf("pending-%d", i), Status: "pending", ProcessAfter: base})
+			}
+			for _, job := range jobs {
+				if err := s.AddJob(job); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := s.Len(); got != len(tt.wantIDs) {
+				t.Fatalf("Len() = %d, want %d", got, len(tt.wantIDs))
+			}
+			stored := s.GetJobs()
+			for _, id := range tt.wantIDs {
+				if _, ok := stored[id]; !ok {
+					t.Errorf("job %s was evicted", id)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreTransitionStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		from, to   string
+		wantOK     bool
+		wantStatus string
+	}{
+		{name: "matching status", status: "pending", from: "pending", to: "cancelled", wantOK: true, wantStatus: "cancelled"},
+		{name: "job already completed", status: "completed", from: "pending", to: "cancelled", wantStatus: "completed"},
+		{name: "job already cancelled", status: "cancelled", from: "pending", to: "paused", wantStatus: "cancelled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore[string]()
+			if err := s.AddJob(&scheduler.Job[string]{Id: "job-1", Status: tt.status, ProcessAfter: time.Now().Add(time.Hour)}); err != nil {
+				t.Fatal(err)
+			}
+
+			ok, err := s.TransitionStatus("job-1", tt.from, tt.to)
+			if err != nil || ok != tt.wantOK {
+				t.Fatalf("TransitionStatus() = %v, %v, want %v", ok, err, tt.wantOK)
+			}
+			if got := s.GetJobs()["job-1"].Status; got != tt.wantStatus {
+				t.Fatalf("status = %q, want %q", got, tt.wantStatus)
+			}
+		})
+	}
+
+	if _, err := NewMemoryStore[string]().TransitionStatus("missing", "pending", "cancelled"); err == nil {
+		t.Fatal("TransitionStatus() succeeded for a missing job")
+	}
+}
+
+func TestMemoryStoreExplainFetch(t *testing.T) {
+	s := NewMemoryStore[string]()
+	if err := s.AddJob(&scheduler.Job[string]{Id: "job-1", Status: "pending", ProcessAfter: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := s.ClaimNext(time.Minute); err != nil || !ok {
+		t.Fatalf("ClaimNext() = %v, %v", ok, err)
+	}
+
+	e, err := s.ExplainFetch("job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Invisible || e.NotDue || e.WrongStatus() {
+		t.Fatalf("ExplainFetch() = %s, want only invisible", e)
+	}
+
+	if _, err := s.ExplainFetch("missing"); err == nil {
+		t.Fatal("ExplainFetch() succeeded for a missing job")
+	}
+}
+
+func TestMemoryStoreCountInvisible(t *testing.T) {
+	now := time.Now()
+	visibleAt := func(d time.Duration) *time.Time {
+		ts := now.Add(d)
+		return &ts
+	}
+
+	tests := []struct {
+		name string
+		jobs []*scheduler.Job[string]
+		want int
+	}{
+		{name: "empty store"},
+		{
+			name: "pending jobs hidden by a visibility timeout",
+			jobs: []*scheduler.Job[string]{
+				{Id: "hidden-1", Status: "pending", ProcessAfter: now, VisibleAfter: visibleAt(time.Minute)},
+				{Id: "hidden-2", Status: "pending", ProcessAfter: now, VisibleAfter: visibleAt(time.Hour)},
+				{Id: "visible", Status: "pending", ProcessAfter: now},
+			},
+			want: 2,
+		},
+		{
+			name: "expired timeouts and finished jobs aren't counted",
+			jobs: []*scheduler.Job[string]{
+				{Id: "recovered", Status: "pending", ProcessAfter: now, VisibleAfter: visibleAt(-time.Second)},
+				{Id: "completed", Status: "completed", ProcessAfter: now, VisibleAfter: visibleAt(time.Minute)},
+				{Id: "hidden", Status: "pending", ProcessAfter: now, VisibleAfter: visibleAt(time.Minute)},
+			},
+			want: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore[string]()
+			for _, job := range tt.jobs {
+				if err := s.AddJob(job); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got, err := s.CountInvisible(); err != nil || got != tt.want {
+				t.Fatalf("CountInvisible() = %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreDeprioritizeFailing(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []MemoryStoreOption
+		wantOrder []string
+	}{
+		{name: "earliest due first by default", wantOrder: []string{"failing", "retried-once", "fresh"}},
+		{name: "fewest attempts first", opts: []MemoryStoreOption{WithDeprioritizeFailing()}, wantOrder: []string{"fresh", "retried-once", "failing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore[string](tt.opts...)
+
+			// The failing job keeps coming back due right away, ahead of the others
+			now := time.Now()
+			jobs := []*scheduler.Job[string]{
+				{Id: "failing", Status: "pending", Attempts: 4, ProcessAfter: now.Add(-time.Minute)},
+				{Id: "retried-once", Status: "pending", Attempts: 1, ProcessAfter: now.Add(-30 * time.Second)},
+				{Id: "fresh", Status: "pending", ProcessAfter: now.Add(-time.Second)},
+			}
+			for _, job := range jobs {
+				if err := s.AddJob(job); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fetched, err := s.FetchPendingJobs(now, 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var order []string
+			for _, job := range fetched {
+				order = append(order, job.Id)
+			}
+			if !slices.Equal(order, tt.wantOrder) {
+				t.Fatalf("fetch order = %v, want %v", order, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreFetchCompleted(t *testing.T) {
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	at := func(hour int) *time.Time {
+		ts := day.Add(time.Duration(hour) * time.Hour)
+		return &ts
+	}
+
+	s := NewMemoryStore[string]()
+	jobs := []*scheduler.Job[string]{
+		{Id: "yesterday", Status: "completed", ProcessedAt: at(-1)},
+		{Id: "midnight", Status: "completed", ProcessedAt: at(0)},
+		{Id: "noon", Status: "completed", ProcessedAt: at(12)},
+		{Id: "morning", Status: "completed", ProcessedAt: at(9)},
+		{Id: "failed-at-noon", Status: "failed", ProcessedAt: at(12)},
+		{Id: "pending", Status: "pending", ProcessAfter: *at(10)},
+		{Id: "tomorrow", Status: "completed", ProcessedAt: at(24)},
+	}
+	for _, job := range jobs {
+		if err := s.AddJob(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		limit    int
+		want     []string
+	}{
+		{name: "whole day", from: *at(0), to: *at(24), want: []string{"midnight", "morning", "noon"}},
+		{name: "limited", from: *at(0), to: *at(24), limit: 2, want: []string{"midnight", "morning"}},
+		{name: "end is exclusive", from: *at(9), to: *at(12), want: []string{"morning"}},
+		{name: "empty window", from: *at(13), to: *at(23)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completed, err := s.FetchCompleted(tt.from, tt.to, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, job := range completed {
+				got = append(got, job.Id)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("FetchCompleted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreJobVisibleAfter(t *testing.T) {
+	tests := []struct {
+		name          string
+		due           time.Duration // Due time relative to now
+		hold          time.Duration // Visibility time relative to now
+		wantBefore    bool          // Whether the job is fetched right away
+		wantAfterHold bool          // Whether it is fetched once the hold is over
+	}{
+		{name: "due but held invisible", due: -time.Minute, hold: 100 * time.Millisecond, wantAfterHold: true},
+		{name: "hold already over", due: -time.Minute, hold: -time.Second, wantBefore: true, wantAfterHold: true},
+		{name: "visible but not due", due: time.Hour, hold: 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Now()
+			job := scheduler.NewJob(now.Add(tt.due), "cool-down", scheduler.WithJobVisibleAfter[string](now.Add(tt.hold)))
+			if !job.ProcessAfter.Equal(now.Add(tt.due)) {
+				t.Fatalf("ProcessAfter = %v, want the logical due time %v", job.ProcessAfter, now.Add(tt.due))
+			}
+
+			s := NewMemoryStore[string]()
+			if err := s.AddJob(job); err != nil {
+				t.Fatal(err)
+			}
+			fetched := func() bool {
+				entries, err := s.FetchPendingJobs(time.Now(), 10, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return len(entries) == 1
+			}
+
+			if got := fetched(); got != tt.wantBefore {
+				t.Fatalf("job fetched before the hold is over = %v, want %v", got, tt.wantBefore)
+			}
+			time.Sleep(time.Until(now.Add(tt.hold)) + 10*time.Millisecond)
+			if got := fetched(); got != tt.wantAfterHold {
+				t.Fatalf("job fetched after the hold = %v, want %v", got, tt.wantAfterHold)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreShiftSchedule(t *testing.T) {
+	from := time.Now().Add(time.Hour).Truncate(time.Minute)
+	to := from.Add(2 * time.Hour)
+	hiddenUntil := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		job     scheduler.Job[string]
+		shifted bool
+	}{
+		{name: "due before the window", job: scheduler.Job[string]{Status: "pending", ProcessAfter: from.Add(-time.Second)}},
+		{name: "due at the window start", job: scheduler.Job[string]{Status: "pending", ProcessAfter: from}, shifted: true},
+		{name: "due within the window", job: scheduler.Job[string]{Status: "pending", ProcessAfter: from.Add(time.Hour)}, shifted: true},
+		{name: "due at the window end", job: scheduler.Job[string]{Status: "pending", ProcessAfter: to}},
+		{name: "completed within the window", job: scheduler.Job[string]{Status: "completed", ProcessAfter: from.Add(time.Hour)}},
+		{name: "being processed", job: scheduler.Job[string]{Status: "pending", ProcessAfter: from.Add(time.Hour), VisibleAfter: &hiddenUntil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore[string]()
+			job := tt.job
+			job.Id = "job-1"
+			if err := s.AddJob(&job); err != nil {
+				t.Fatal(err)
+			}
+
+			want, wantShifted := tt.job.ProcessAfter, 0
+			if tt.shifted {
+				want, wantShifted = want.Add(3*time.Hour), 1
+			}
+			shifted, err := s.ShiftSchedule(from, to, 3*time.Hour)
+			if err != nil || shifted != wantShifted {
+				t.Fatalf("ShiftSchedule() = %d, %v, want %d", shifted, err, wantShifted)
+			}
+			if got := s.GetJobs()["job-1"].ProcessAfter; !got.Equal(want) {
+				t.Fatalf("ProcessAfter = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreTieBreak(t *testing.T) {
+	// Equally due jobs in the order they are added, after one due earlier that always goes first
+	added := []string{"job-a", "job-b", "job-c", "job-d", "job-e", "job-f", "job-g", "job-h"}
+	lifo := slices.Clone(added)
+	slices.Reverse(lifo)
+	random := slices.Clone(added)
+	slices.SortFunc(random, func(a, b string) int { return cmp.Compare(scheduler.TieKey(a), scheduler.TieKey(b)) })
+	if slices.Equal(random, added) || slices.Equal(random, lifo) {
+		t.Fatalf("fixture hashes to %v, pick ids whose random order differs from FIFO and LIFO", random)
+	}
+
+	tests := []struct {
+		name      string
+		tieBreak  scheduler.TieBreak
+		wantOrder []string
+	}{
+		{name: "fifo", tieBreak: scheduler.TieBreakFIFO, wantOrder: added},
+		{name: "lifo", tieBreak: scheduler.TieBreakLIFO, wantOrder: lifo},
+		{name: "random", tieBreak: scheduler.TieBreakRandom, wantOrder: random},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore[string]()
+			s.SetTieBreak(tt.tieBreak)
+
+			now := time.Now()
+			due := now.Add(-time.Minute)
+			if err := s.AddJob(&scheduler.Job[string]{Id: "earliest", Status: "pending", ProcessAfter: due.Add(-time.Second)}); err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range added {
+				if err := s.AddJob(&scheduler.Job[string]{Id: id, Status: "pending", ProcessAfter: due}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// The order is the same on every fetch
+			for range 3 {
+				fetched, err := s.FetchPendingJobs(now, 0, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var order []string
+				for _, job := range fetched {
+					order = append(order, job.Id)
+				}
+				if want := append([]string{"earliest"}, tt.wantOrder...); !slices.Equal(order, want) {
+					t.Fatalf("fetch order = %v, want %v", order, want)
+				}
+			}
+		})
+	}
+}
@@ -1,12 +1,144 @@
 package mongo
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	scheduler "go-sched"
+)
 
 type Job[T any] struct {
-	Id           string     `bson:"_id"`
-	Status       string     `bson:"status"`
-	ProcessAfter time.Time  `bson:"processAfter"`
-	VisibleAfter *time.Time `bson:"visibleAfter,omitempty"`
-	ProcessedAt  *time.Time `bson:"processedAt,omitempty"`
-	Payload      T          `bson:"payload"`
+	Id               string                   `bson:"_id"`
+	Status           string                   `bson:"status"`
+	Type             string                   `bson:"type,omitempty"`
+	CreatedAt        time.Time                `bson:"createdAt"`
+	ProcessAfter     storedTime               `bson:"processAfter"`
+	VisibleAfter     *storedTime              `bson:"visibleAfter,omitempty"`
+	ProcessedAt      *time.Time               `bson:"processedAt,omitempty"`
+	CallbackURL      string                   `bson:"callbackUrl,omitempty"`
+	IdempotencyKey   string                   `bson:"idempotencyKey,omitempty"`
+	TenantID         string                   `bson:"tenantId,omitempty"`
+	Attempts         int                      `bson:"attempts"`
+	ProcessedBy      string                   `bson:"processedBy,omitempty"`
+	Priority         int                      `bson:"priority"`
+	Interval         time.Duration            `bson:"interval,omitempty"`
+	RemainingRuns    int                      `bson:"remainingRuns,omitempty"`
+	Sequence         int64                    `bson:"sequence,omitempty"`
+	TieKey           int64                    `bson:"tieKey"` // Hash of the id, orders ties with TieBreakRandom
+	Checkpoint       []byte                   `bson:"checkpoint,omitempty"`
+	TerminalReason   scheduler.TerminalReason `bson:"terminalReason,omitempty"`
+	SealedPayload    []byte                   `bson:"sealedPayload,omitempty"` // Payload encrypted by the scheduler's SecretManager
+	Metadata         scheduler.Metadata       `bson:"metadata,omitempty"`
+	Payload          *T                       `bson:"payload,omitempty"`
+	EncryptedPayload []byte                   `bson:"encryptedPayload,omitempty"` // Set instead of Payload when an encrypter is configured
+	DedupKey         string                   `bson:"dedupKey,omitempty"`         // Unique while the dedup window lasts
+}
+
+// newJob converts a scheduler job into its document representation
+func newJob[T any](job *scheduler.Job[T], cfg storeConfig) (*Job[T], error) {
+	doc := &Job[T]{
+		Id:             job.Id,
+		Status:         job.Status,
+		Type:           job.Type,
+		CreatedAt:      job.CreatedAt,
+		ProcessAfter:   newStoredTime(job.ProcessAfter, cfg.timeFormat),
+		VisibleAfter:   newStoredTimePtr(job.VisibleAfter, cfg.timeFormat),
+		ProcessedAt:    job.ProcessedAt,
+		CallbackURL:    job.CallbackURL,
+		IdempotencyKey: job.IdempotencyKey,
+		TenantID:       job.TenantID,
+		Attempts:       job.Attempts,
+		ProcessedBy:    job.ProcessedBy,
+		Priority:       job.Priority,
+		Interval:       job.Interval,
+		RemainingRuns:  job.RemainingRuns,
+		Sequence:       job.Sequence,
+		TieKey:         scheduler.TieKey(job.Id),
+		Checkpoint:     job.Checkpoint,
+		TerminalReason: job.TerminalReason,
+		SealedPayload:  job.SealedPayload,
+		Metadata:       job.Metadata,
+	}
+
+	if cfg.tenantID != "" {
+		doc.TenantID = cfg.tenantID
+	}
+
+	if cfg.encrypter == nil {
+		payload := job.Payload
+		doc.Payload = &payload
+		return doc, nil
+	}
+
+	plaintext, err := json.Marshal(job.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.EncryptedPayload, err = cfg.encrypter.Encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// observePayload records the size of a stored job's payload with the configured collector,
+// measured as JSON before encryption whatever the document encoding
+func observePayload[T any](job *scheduler.Job[T], cfg storeConfig) {
+	if cfg.metrics == nil {
+		return
+	}
+	if raw, err := json.Marshal(job.Payload); err == nil {
+		cfg.metrics.ObservePayloadBytes(job.Type, len(raw))
+	}
+}
+
+// toSchedulerJob converts the document back into a scheduler job, decrypting the payload if needed
+func (j *Job[T]) toSchedulerJob(cfg storeConfig) (*scheduler.Job[T], error) {
+	job := &scheduler.Job[T]{
+		Id:             j.Id,
+		Status:         j.Status,
+		Type:           j.Type,
+		CreatedAt:      j.CreatedAt,
+		ProcessAfter:   j.ProcessAfter.time,
+		VisibleAfter:   j.VisibleAfter.timePtr(),
+		ProcessedAt:    j.ProcessedAt,
+		CallbackURL:    j.CallbackURL,
+		IdempotencyKey: j.IdempotencyKey,
+		TenantID:       j.TenantID,
+		Attempts:       j.Attempts,
+		ProcessedBy:    j.ProcessedBy,
+		Priority:       j.Priority,
+		Interval:       j.Interval,
+		RemainingRuns:  j.RemainingRuns,
+		Sequence:       j.Sequence,
+		Checkpoint:     j.Checkpoint,
+		TerminalReason: j.TerminalReason,
+		SealedPayload:  j.SealedPayload,
+		Metadata:       j.Metadata,
+	}
+
+	if j.EncryptedPayload == nil {
+		if j.Payload != nil {
+			job.Payload = *j.Payload
+		}
+		return job, nil
+	}
+
+	if cfg.encrypter == nil {
+		return nil, errors.New("job payload is encrypted but no encrypter is configured")
+	}
+
+	plaintext, err := cfg.encrypter.Decrypt(j.EncryptedPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(plaintext, &job.Payload); err != nil {
+		return nil, err
+	}
+
+	return job, nil
 }
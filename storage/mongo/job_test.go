@@ -0,0 +1,169 @@
+package mongo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+type emailPayload struct {
+	To      string `json:"to" bson:"to"`
+	Subject string `json:"subject" bson:"subject"`
+}
+
+func TestJobDocumentPayloadEncryption(t *testing.T) {
+	enc, err := scheduler.NewAESGCMEncrypter(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		cfg           storeConfig
+		wantEncrypted bool
+	}{
+		{name: "plaintext", cfg: storeConfig{}},
+		{name: "encrypted", cfg: storeConfig{encrypter: enc}, wantEncrypted: true},
+		{name: "encrypted epoch millis", cfg: storeConfig{encrypter: enc, timeFormat: TimeFormatEpochMillis}, wantEncrypted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := emailPayload{To: "a@example.com", Subject: "confidential"}
+			job := &scheduler.Job[emailPayload]{Id: "job-1", Status: "pending", ProcessAfter: time.Now().Truncate(time.Millisecond), Payload: payload}
+
+			doc, err := newJob(job, tt.cfg)
+			if err != nil {
+				t.Fatalf("newJob() error = %v", err)
+			}
+
+			// Round trip through BSON as the driver would on write and fetch
+			stored, err := bson.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if encrypted := !bytes.Contains(stored, []byte("confidential")); encrypted != tt.wantEncrypted {
+				t.Fatalf("stored payload encrypted = %v, want %v", encrypted, tt.wantEncrypted)
+			}
+
+			var fetched Job[emailPayload]
+			if err := bson.Unmarshal(stored, &fetched); err != nil {
+				t.Fatal(err)
+			}
+			if (fetched.EncryptedPayload != nil) != tt.wantEncrypted || (fetched.Payload == nil) != tt.wantEncrypted {
+				t.Fatalf("fetched document has encrypted payload %v and payload %v", fetched.EncryptedPayload != nil, fetched.Payload != nil)
+			}
+
+			got, err := fetched.toSchedulerJob(tt.cfg)
+			if err != nil {
+				t.Fatalf("toSchedulerJob() error = %v", err)
+			}
+			if got.Payload != payload {
+				t.Fatalf("payload = %+v, want %+v", got.Payload, payload)
+			}
+			if !got.ProcessAfter.Equal(job.ProcessAfter) {
+				t.Fatalf("ProcessAfter = %s, want %s", got.ProcessAfter, job.ProcessAfter)
+			}
+		})
+	}
+}
+
+func TestJobDocumentEncryptedWithoutEncrypter(t *testing.T) {
+	enc, _ := scheduler.NewAESGCMEncrypter(bytes.Repeat([]byte{1}, 32))
+	doc, err := newJob(&scheduler.Job[emailPayload]{Id: "job-1"}, storeConfig{encrypter: enc})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := doc.toSchedulerJob(storeConfig{}); err == nil {
+		t.Fatal("toSchedulerJob() decoded an encrypted payload without an encrypter")
+	}
+}
+
+func TestMongoStorePayloadMetrics(t *testing.T) {
+	enc, err := scheduler.NewAESGCMEncrypter(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	duplicate := mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key"})
+
+	tests := []struct {
+		name      string
+		opts      []MongoStoreOption
+		responses []bson.D
+		write     func(store *MongoStore[string], job *scheduler.Job[string]) error
+		wantCount uint64
+	}{
+		{
+			name:      "added",
+			responses: []bson.D{sequenceResponse(1), mtest.CreateSuccessResponse()},
+			write: func(store *MongoStore[string], job *scheduler.Job[string]) error {
+				return store.AddJob(job)
+			},
+			wantCount: 1,
+		},
+		{
+			name:      "added encrypted",
+			opts:      []MongoStoreOption{WithEncrypter(enc)},
+			responses: []bson.D{sequenceResponse(1), mtest.CreateSuccessResponse()},
+			write: func(store *MongoStore[string], job *scheduler.Job[string]) error {
+				return store.AddJob(job)
+			},
+			wantCount: 1,
+		},
+		{
+			name:      "added unique",
+			responses: []bson.D{mtest.CreateSuccessResponse(), sequenceResponse(1), mtest.CreateSuccessResponse()},
+			write: func(store *MongoStore[string], job *scheduler.Job[string]) error {
+				return store.AddUniqueJob(job, "report:daily", time.Hour)
+			},
+			wantCount: 1,
+		},
+		{
+			name:      "rejected as a duplicate",
+			responses: []bson.D{mtest.CreateSuccessResponse(), sequenceResponse(1), duplicate},
+			write: func(store *MongoStore[string], job *scheduler.Job[string]) error {
+				if err := store.AddUniqueJob(job, "report:daily", time.Hour); !errors.Is(err, scheduler.ErrDuplicateJob) {
+					return fmt.Errorf("AddUniqueJob() error = %v, want ErrDuplicateJob", err)
+				}
+				return nil
+			},
+		},
+		{
+			name:      "updated",
+			responses: []bson.D{mtest.CreateSuccessResponse()},
+			write: func(store *MongoStore[string], job *scheduler.Job[string]) error {
+				return store.UpdateJob(job)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTest(t, func(mt *mtest.T) {
+				mt.AddMockResponses(tt.responses...)
+				metrics := scheduler.NewPayloadMetrics(100, 1000)
+				store := NewMongoStore[string](mt.DB, "jobs", append(tt.opts, WithMetricsCollector(metrics))...)
+
+				job := &scheduler.Job[string]{Id: "job-1", Type: "report", Status: "pending", Payload: strings.Repeat("x", 500)}
+				if err := tt.write(store, job); err != nil {
+					mt.Fatal(err)
+				}
+
+				// Payloads are observed as JSON, a string payload gains its quotes
+				h := metrics.Histogram()
+				if h.Count != tt.wantCount || h.Sum != tt.wantCount*502 {
+					mt.Fatalf("observed %d payloads of %d bytes, want %d of 502 bytes", h.Count, h.Sum, tt.wantCount)
+				}
+			})
+		})
+	}
+}
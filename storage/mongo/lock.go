@@ -0,0 +1,67 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// lockCollection returns the collection holding the leader lock document, next to the jobs collection
+func (s *MongoStore[T]) lockCollection() *mongo.Collection {
+	return s.db.Collection(s.colName + "_locks")
+}
+
+// lockID returns the id of the leader lock document, one lock per tenant
+func (s *MongoStore[T]) lockID() string {
+	if s.cfg.tenantID != "" {
+		return "scheduler:" + s.cfg.tenantID
+	}
+	return "scheduler"
+}
+
+// TryAcquireLock takes the leader lock for ttl if it is free, expired or already held by this store
+// A concurrent acquire of the same free lock fails on the unique _id and reports the lock as not held
+func (s *MongoStore[T]) TryAcquireLock(ctx context.Context, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	filter := bson.M{
+		"_id": s.lockID(),
+		"$or": []bson.M{
+			{"owner": s.owner},
+			{"expiresAt": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": s.owner, "expiresAt": now.Add(ttl)}}
+
+	_, err := s.lockCollection().UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RenewLock extends the leader lock by ttl if it is still held by this store
+func (s *MongoStore[T]) RenewLock(ctx context.Context, ttl time.Duration) (bool, error) {
+	result, err := s.lockCollection().UpdateOne(ctx,
+		bson.M{"_id": s.lockID(), "owner": s.owner},
+		bson.M{"$set": bson.M{"expiresAt": time.Now().Add(ttl)}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// ReleaseLock deletes the leader lock document if it is held by this store
+func (s *MongoStore[T]) ReleaseLock(ctx context.Context) error {
+	_, err := s.lockCollection().DeleteOne(ctx, bson.M{"_id": s.lockID(), "owner": s.owner})
+	return err
+}
@@ -3,73 +3,348 @@ package mongo
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync/atomic"
 	"time"
 
 	scheduler "go-sched"
 
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrCappedCollection is returned for operations a capped collection does not support
+var ErrCappedCollection = errors.New("operation not supported on a capped collection")
+
 type MongoStore[T any] struct {
 	db      *mongo.Database
 	colName string
+	cfg     storeConfig
+	owner   string // Identifies this store instance as the leader lock owner
+
+	decodeErrors atomic.Int64
+	tieBreak     atomic.Int64 // scheduler.TieBreak set with SetTieBreak
 }
 
-func NewMongoStore[T any](db *mongo.Database, colName string) *MongoStore[T] {
-	return &MongoStore[T]{
+func NewMongoStore[T any](db *mongo.Database, colName string, opts ...MongoStoreOption) *MongoStore[T] {
+	s := &MongoStore[T]{
 		db:      db,
 		colName: colName,
+		cfg:     storeConfig{partialIndex: true},
+		owner:   uuid.New().String(),
+	}
+
+	for _, opt := range opts {
+		opt(&s.cfg)
+	}
+
+	return s
+}
+
+// NewMongoStoreForTenant creates a store whose reads and writes are scoped to a single tenant
+func NewMongoStoreForTenant[T any](db *mongo.Database, colName string, tenantID string, opts ...MongoStoreOption) *MongoStore[T] {
+	return NewMongoStore[T](db, colName, append(opts, WithTenant(tenantID))...)
+}
+
+// scoped restricts the filter to the store's tenant, if any
+func (s *MongoStore[T]) scoped(filter bson.M) bson.M {
+	if s.cfg.tenantID != "" {
+		filter["tenantId"] = s.cfg.tenantID
 	}
+	return filter
 }
 
+// FetchPendingJobs retrieves pending jobs that are ready to be processed. With a positive
+// visibilityTimeout each job is locked as it is read, see FetchAndLockJobsAtomic, unless aging is
+// configured, which needs the jobs sorted by a computed priority.
 func (s *MongoStore[T]) FetchPendingJobs(after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	return s.fetch(after, limit, nil, visibilityTimeout)
+}
+
+// FetchPendingJobsByType retrieves pending jobs that are ready to be processed and pass typeFilter
+func (s *MongoStore[T]) FetchPendingJobsByType(typeFilter scheduler.TypeFilter, after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	return s.fetch(after, limit, &typeFilter, visibilityTimeout)
+}
+
+// FetchAndLockJobsAtomic retrieves up to limit pending jobs that are ready to be processed, making
+// each invisible for visibilityTimeout in the same findOneAndUpdate that reads it, so two
+// schedulers can never fetch the same job. Jobs are read one at a time through a single session,
+// in priority order without aging. Jobs already locked when an error occurs are returned, the
+// error only if there are none.
+func (s *MongoStore[T]) FetchAndLockJobsAtomic(after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	return s.fetchAndLock(s.pendingFilter(after, nil), limit, visibilityTimeout)
+}
+
+// fetch returns up to limit due and visible pending jobs in fetch order, of the types passing
+// typeFilter if it isn't nil, locking them for visibilityTimeout if it is positive
+func (s *MongoStore[T]) fetch(after time.Time, limit int, typeFilter *scheduler.TypeFilter, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	filter := s.pendingFilter(after, typeFilter)
+	if visibilityTimeout > 0 && s.cfg.aging == 0 {
+		return s.fetchAndLock(filter, limit, visibilityTimeout)
+	}
+
 	collection := s.db.Collection(s.colName)
 
-	filter := bson.M{
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var cursor *mongo.Cursor
+	var err error
+	if s.cfg.aging > 0 {
+		cursor, err = collection.Aggregate(ctx, s.agedPipeline(filter, limit))
+	} else {
+		findOptions := options.Find().SetSort(s.fetchSort("priority"))
+		if limit > 0 {
+			findOptions.SetLimit(int64(limit))
+		}
+		cursor, err = collection.Find(ctx, filter, findOptions)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	jobs := make([]*scheduler.Job[T], 0)
+
+	for cursor.Next(ctx) {
+		entry, err := s.decodeJob(cursor)
+		if err != nil {
+			// A single undecodable job must not starve the rest of the batch
+			id, _ := cursor.Current.Lookup("_id").StringValueOK()
+			s.skipUndecodable(ctx, id, err)
+			continue
+		}
+
+		jobs = append(jobs, entry)
+	}
+
+	return jobs, cursor.Err()
+}
+
+// fetchAndLock finds and locks jobs matching filter one at a time with findOneAndUpdate until
+// limit jobs, or every matching job if limit isn't positive, are locked
+func (s *MongoStore[T]) fetchAndLock(filter bson.M, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	collection := s.db.Collection(s.colName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// A session keeps the reads on the same replica set member
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	updateOptions := options.FindOneAndUpdate().
+		SetSort(s.fetchSort("priority")).
+		SetReturnDocument(options.After)
+
+	jobs := make([]*scheduler.Job[T], 0)
+	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+		for limit <= 0 || len(jobs) < limit {
+			update := bson.M{"$set": bson.M{
+				"visibleAfter": s.cfg.timeFormat.timeValue(time.Now().Add(visibilityTimeout)),
+			}}
+
+			result := collection.FindOneAndUpdate(sc, filter, update, updateOptions)
+			if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+				return nil
+			}
+			if result.Err() != nil {
+				return result.Err()
+			}
+
+			entry, err := s.decodeResult(result)
+			if err != nil {
+				// The job is locked now, so it isn't found again by this loop
+				raw, _ := result.Raw()
+				id, _ := raw.Lookup("_id").StringValueOK()
+				s.skipUndecodable(sc, id, err)
+				continue
+			}
+
+			jobs = append(jobs, entry)
+		}
+		return nil
+	})
+	if err != nil && len(jobs) == 0 {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// pendingFilter matches due and visible pending jobs of the store's tenant, of the types passing
+// typeFilter if it isn't nil
+func (s *MongoStore[T]) pendingFilter(after time.Time, typeFilter *scheduler.TypeFilter) bson.M {
+	filter := s.scoped(bson.M{
 		"status":       "pending",
-		"processAfter": bson.M{"$lt": after},
+		"processAfter": bson.M{"$lt": s.cfg.timeFormat.timeValue(after)},
 		"$or": []bson.M{
 			{"visibleAfter": bson.M{"$exists": false}},
 			{"visibleAfter": nil},
-			{"visibleAfter": bson.M{"$lt": time.Now()}},
+			{"visibleAfter": bson.M{"$lt": s.cfg.timeFormat.timeValue(time.Now())}},
 		},
+	})
+
+	// Untyped jobs have no type field, which $nin matches
+	if typeFilter != nil && typeFilter.Exclude {
+		filter["type"] = bson.M{"$nin": typeFilter.Types}
+	} else if typeFilter != nil {
+		filter["type"] = bson.M{"$in": typeFilter.Types}
 	}
 
-	findOptions := options.Find()
-	if limit > 0 {
-		findOptions.SetLimit(int64(limit))
+	return filter
+}
+
+// decodeJob decodes the cursor's current document into a scheduler job
+func (s *MongoStore[T]) decodeJob(cursor *mongo.Cursor) (*scheduler.Job[T], error) {
+	var job Job[T]
+	if err := cursor.Decode(&job); err != nil {
+		return nil, err
 	}
 
+	return job.toSchedulerJob(s.cfg)
+}
+
+// ClaimNext makes the next due, visible pending job in fetch order invisible for visibilityTimeout
+// in the same findOneAndUpdate that selects it and returns it, or false if no job is due. Aging
+// isn't applied, as findOneAndUpdate can't sort on a computed priority.
+func (s *MongoStore[T]) ClaimNext(visibilityTimeout time.Duration) (*scheduler.Job[T], bool, error) {
+	collection := s.db.Collection(s.colName)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	cursor, err := collection.Find(ctx, filter, findOptions)
-	if err != nil {
+	updateOptions := options.FindOneAndUpdate().
+		SetSort(s.fetchSort("priority")).
+		SetReturnDocument(options.After)
+
+	for {
+		now := time.Now()
+		update := bson.M{"$set": bson.M{
+			"visibleAfter": s.cfg.timeFormat.timeValue(now.Add(visibilityTimeout)),
+		}}
+
+		result := collection.FindOneAndUpdate(ctx, s.pendingFilter(now, nil), update, updateOptions)
+		if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+			return nil, false, nil
+		}
+		if result.Err() != nil {
+			return nil, false, result.Err()
+		}
+
+		entry, err := s.decodeResult(result)
+		if err != nil {
+			// The job is invisible now, so the next iteration claims the job after it
+			raw, _ := result.Raw()
+			id, _ := raw.Lookup("_id").StringValueOK()
+			s.skipUndecodable(ctx, id, err)
+			continue
+		}
+
+		return entry, true, nil
+	}
+}
+
+// decodeResult decodes a document returned by findOneAndUpdate into a scheduler job
+func (s *MongoStore[T]) decodeResult(result *mongo.SingleResult) (*scheduler.Job[T], error) {
+	var job Job[T]
+	if err := result.Decode(&job); err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
-	jobs := make([]*scheduler.Job[T], 0)
+	return job.toSchedulerJob(s.cfg)
+}
 
-	for cursor.Next(ctx) {
-		var job Job[T]
-		if err := cursor.Decode(&job); err != nil {
-			return nil, err
-		}
+// skipUndecodable records a job skipped by FetchPendingJobs and moves it to the poison status, if configured
+func (s *MongoStore[T]) skipUndecodable(ctx context.Context, id string, err error) {
+	s.decodeErrors.Add(1)
 
-		jobs = append(jobs, &scheduler.Job[T]{
-			Id:           job.Id,
-			Status:       job.Status,
-			ProcessAfter: job.ProcessAfter,
-			VisibleAfter: job.VisibleAfter,
-			ProcessedAt:  job.ProcessedAt,
-			Payload:      job.Payload,
+	if s.cfg.onDecodeError != nil {
+		s.cfg.onDecodeError(id, err)
+	}
+
+	if s.cfg.poisonStatus != "" && id != "" {
+		// Best effort, a job left pending is skipped again on the next fetch
+		s.db.Collection(s.colName).UpdateOne(ctx, s.scoped(bson.M{"_id": id}), bson.M{
+			"$set": bson.M{"status": s.cfg.poisonStatus},
 		})
 	}
+}
+
+// DecodeErrors returns the number of jobs FetchPendingJobs skipped because they couldn't be decoded
+func (s *MongoStore[T]) DecodeErrors() int64 {
+	return s.decodeErrors.Load()
+}
+
+// fetchSort orders jobs by priorityField descending, then fewest attempts if configured, earliest due
+// and the tie break
+func (s *MongoStore[T]) fetchSort(priorityField string) bson.D {
+	sort := bson.D{{Key: priorityField, Value: -1}}
+	if s.cfg.deprioritizeFailing {
+		sort = append(sort, bson.E{Key: "attempts", Value: 1})
+	}
+	return append(sort, bson.E{Key: "processAfter", Value: 1}, s.tieSort())
+}
 
-	return jobs, nil
+// SetTieBreak orders pending jobs that are equally due and of equal priority by tb. Random ties
+// are ordered by the tieKey field, jobs added before it existed come first.
+func (s *MongoStore[T]) SetTieBreak(tb scheduler.TieBreak) {
+	s.tieBreak.Store(int64(tb))
+}
+
+// tieSort returns the sort key ordering equally due jobs of equal priority
+func (s *MongoStore[T]) tieSort() bson.E {
+	switch scheduler.TieBreak(s.tieBreak.Load()) {
+	case scheduler.TieBreakLIFO:
+		return bson.E{Key: "sequence", Value: -1}
+	case scheduler.TieBreakRandom:
+		return bson.E{Key: "tieKey", Value: 1}
+	default:
+		return bson.E{Key: "sequence", Value: 1}
+	}
+}
+
+// serverNow returns an aggregation expression for the server's current time in the store's time format
+func (s *MongoStore[T]) serverNow() any {
+	if s.cfg.timeFormat == TimeFormatEpochMillis {
+		return bson.M{"$toLong": "$$NOW"}
+	}
+	return "$$NOW"
+}
+
+// storedTimeType returns the BSON type alias of times in the store's time format
+func (s *MongoStore[T]) storedTimeType() string {
+	if s.cfg.timeFormat == TimeFormatEpochMillis {
+		return "long"
+	}
+	return "date"
+}
+
+// agedPipeline orders matching jobs by priority plus one for every aging interval waited past processAfter
+func (s *MongoStore[T]) agedPipeline(filter bson.M, limit int) mongo.Pipeline {
+	effectivePriority := bson.M{"$add": bson.A{
+		bson.M{"$ifNull": bson.A{"$priority", 0}},
+		bson.M{"$max": bson.A{0, bson.M{"$floor": bson.M{"$divide": bson.A{
+			bson.M{"$subtract": bson.A{s.serverNow(), "$processAfter"}},
+			s.cfg.aging.Milliseconds(),
+		}}}}},
+	}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$addFields", Value: bson.M{"effectivePriority": effectivePriority}}},
+		{{Key: "$sort", Value: s.fetchSort("effectivePriority")}},
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+	}
+
+	return pipeline
 }
 
 func (s *MongoStore[T]) UpdateJob(job *scheduler.Job[T]) error {
@@ -77,19 +352,25 @@ func (s *MongoStore[T]) UpdateJob(job *scheduler.Job[T]) error {
 		return errors.New("job Id cannot be empty")
 	}
 
-	collection := s.db.Collection(s.colName)
+	collection := s.writeCollection()
 
-	filter := bson.M{"_id": job.Id}
+	filter := s.scoped(bson.M{"_id": job.Id})
 
 	update := bson.M{
 		"$set": bson.M{
-			"status":       job.Status,
-			"visibleAfter": job.VisibleAfter,
-			"processedAt":  job.ProcessedAt,
+			"status":         job.Status,
+			"processAfter":   s.cfg.timeFormat.timeValue(job.ProcessAfter),
+			"visibleAfter":   s.cfg.timeFormat.timePtrValue(job.VisibleAfter),
+			"processedAt":    job.ProcessedAt,
+			"attempts":       job.Attempts,
+			"processedBy":    job.ProcessedBy,
+			"remainingRuns":  job.RemainingRuns,
+			"checkpoint":     []byte(job.Checkpoint),
+			"terminalReason": job.TerminalReason,
 		},
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := s.writeContext()
 	defer cancel()
 
 	_, err := collection.UpdateOne(ctx, filter, update)
@@ -100,29 +381,499 @@ func (s *MongoStore[T]) UpdateJob(job *scheduler.Job[T]) error {
 	return nil
 }
 
+// TransitionStatus sets the job status to `to` only if it is currently `from`
+// A missing job or one being processed is reported as not transitioned
+func (s *MongoStore[T]) TransitionStatus(id, from, to string) (bool, error) {
+	if id == "" {
+		return false, errors.New("job Id cannot be empty")
+	}
+	if from == "processing" {
+		return false, nil
+	}
+
+	collection := s.db.Collection(s.colName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "status": from}
+	if from == "pending" {
+		// A pending job hidden by a visibility timeout is being processed
+		filter["$or"] = []bson.M{
+			{"visibleAfter": bson.M{"$exists": false}},
+			{"visibleAfter": nil},
+			{"visibleAfter": bson.M{"$lt": s.cfg.timeFormat.timeValue(time.Now())}},
+		}
+	}
+
+	result, err := collection.UpdateOne(ctx, s.scoped(filter), bson.M{
+		"$set": bson.M{"status": to},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
 func (s *MongoStore[T]) AddJob(job *scheduler.Job[T]) error {
 	if job.Id == "" {
 		return errors.New("job Id cannot be empty")
 	}
 
+	collection := s.writeCollection()
+
+	ctx, cancel := s.writeContext()
+	defer cancel()
+
+	// Taken outside the session, so concurrent transactions don't conflict on the counter and an
+	// aborted one only leaves a gap in the sequence
+	seqCtx, seqCancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer seqCancel()
+
+	var err error
+	job.Sequence, err = s.nextSequence(seqCtx)
+	if err != nil {
+		return err
+	}
+
+	jobDoc, err := newJob(job, s.cfg)
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.InsertOne(ctx, jobDoc)
+	if err != nil {
+		return err
+	}
+
+	observePayload(job, s.cfg)
+	return nil
+}
+
+// nextSequence increments and returns the store's job sequence, kept in a counter document
+// next to the jobs collection so it survives restarts and is shared by all instances
+func (s *MongoStore[T]) nextSequence(ctx context.Context) (int64, error) {
+	var counter struct {
+		Value int64 `bson:"value"`
+	}
+
+	err := s.db.Collection(s.colName+"_counters").FindOneAndUpdate(ctx,
+		bson.M{"_id": "sequence"},
+		bson.M{"$inc": bson.M{"value": int64(1)}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return 0, err
+	}
+
+	return counter.Value, nil
+}
+
+// AddUniqueJob adds the job unless a job with the same dedup key was added within window
+// Relies on the unique partial dedupKey index created by EnsureIndexes
+func (s *MongoStore[T]) AddUniqueJob(job *scheduler.Job[T], key string, window time.Duration) error {
+	if job.Id == "" {
+		return errors.New("job Id cannot be empty")
+	}
+
+	collection := s.writeCollection()
+
+	ctx, cancel := s.writeContext()
+	defer cancel()
+
+	// Release the key from jobs whose dedup window has passed
+	_, err := collection.UpdateMany(ctx, s.scoped(bson.M{
+		"dedupKey":  key,
+		"createdAt": bson.M{"$lte": time.Now().Add(-window)},
+	}), bson.M{"$unset": bson.M{"dedupKey": ""}})
+	if err != nil {
+		return err
+	}
+
+	// Taken outside the session, like in AddJob
+	seqCtx, seqCancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer seqCancel()
+
+	job.Sequence, err = s.nextSequence(seqCtx)
+	if err != nil {
+		return err
+	}
+
+	jobDoc, err := newJob(job, s.cfg)
+	if err != nil {
+		return err
+	}
+	if jobDoc.CreatedAt.IsZero() {
+		jobDoc.CreatedAt = time.Now()
+	}
+	jobDoc.DedupKey = key
+
+	_, err = collection.InsertOne(ctx, jobDoc)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: key %s", scheduler.ErrDuplicateJob, key)
+	}
+	if err != nil {
+		return err
+	}
+
+	observePayload(job, s.cfg)
+	return nil
+}
+
+// EnsureIndexes creates the collection (capped, if configured) and the indexes used by FetchPendingJobs
+func (s *MongoStore[T]) EnsureIndexes(ctx context.Context) error {
+	if s.cfg.capped {
+		names, err := s.db.ListCollectionNames(ctx, bson.M{"name": s.colName})
+		if err != nil {
+			return err
+		}
+
+		if len(names) == 0 {
+			createOptions := options.CreateCollection().
+				SetCapped(true).
+				SetSizeInBytes(s.cfg.cappedSize)
+			if s.cfg.cappedMaxDoc > 0 {
+				createOptions.SetMaxDocuments(s.cfg.cappedMaxDoc)
+			}
+
+			if err := s.db.CreateCollection(ctx, s.colName, createOptions); err != nil {
+				return err
+			}
+		}
+	}
+
+	collection := s.db.Collection(s.colName)
+
+	_, err := collection.Indexes().CreateOne(ctx, s.fetchIndex())
+	if err != nil {
+		return err
+	}
+
+	// Expired leader locks are cleaned up by MongoDB, acquiring checks expiresAt itself as cleanup lags
+	_, err = s.lockCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Dedup keys are unique only while set, AddUniqueJob unsets them once the window passes
+	dedupKeys := bson.D{{Key: "dedupKey", Value: 1}}
+	if s.cfg.tenantID != "" {
+		dedupKeys = append(bson.D{{Key: "tenantId", Value: 1}}, dedupKeys...)
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: dedupKeys,
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"dedupKey": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only jobs with an idempotency key are indexed, for CancelByKey
+	idempotencyKeys := bson.D{{Key: "idempotencyKey", Value: 1}}
+	if s.cfg.tenantID != "" {
+		idempotencyKeys = append(bson.D{{Key: "tenantId", Value: 1}}, idempotencyKeys...)
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    idempotencyKeys,
+		Options: options.Index().SetPartialFilterExpression(bson.M{"idempotencyKey": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(s.cfg.textFields) > 0 {
+		if _, err := collection.Indexes().CreateOne(ctx, s.textIndex()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchIndex returns the index used by FetchPendingJobs, partial over pending jobs unless disabled.
+// Its keys follow the fetch sort so jobs are read in index order without an in-memory sort.
+func (s *MongoStore[T]) fetchIndex() mongo.IndexModel {
+	keys := append(bson.D{{Key: "status", Value: 1}}, s.fetchSort("priority")...)
+	if s.cfg.partialIndex {
+		// The status is fixed by the partial filter, visibleAfter is filtered on after the sort keys
+		keys = append(s.fetchSort("priority"), bson.E{Key: "visibleAfter", Value: 1})
+	}
+	if s.cfg.tenantID != "" {
+		keys = append(bson.D{{Key: "tenantId", Value: 1}}, keys...)
+	}
+
+	if !s.cfg.partialIndex {
+		return mongo.IndexModel{Keys: keys}
+	}
+
+	return mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetPartialFilterExpression(bson.M{"status": "pending"}),
+	}
+}
+
+// CountInvisible returns the number of pending jobs whose visibility timeout hasn't expired
+func (s *MongoStore[T]) CountInvisible() (int, error) {
 	collection := s.db.Collection(s.colName)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	jobDoc := Job[T]{
-		Id:           job.Id,
-		Status:       job.Status,
-		ProcessAfter: job.ProcessAfter,
-		VisibleAfter: job.VisibleAfter,
-		ProcessedAt:  job.ProcessedAt,
-		Payload:      job.Payload,
+	count, err := collection.CountDocuments(ctx, s.scoped(bson.M{
+		"status":       "pending",
+		"visibleAfter": bson.M{"$gt": s.cfg.timeFormat.timeValue(time.Now())},
+	}))
+	if err != nil {
+		return 0, err
 	}
 
-	_, err := collection.InsertOne(ctx, jobDoc)
+	return int(count), nil
+}
+
+// FetchCompleted returns up to limit jobs (0 for no limit) completed within [from, to), ordered by completion time
+func (s *MongoStore[T]) FetchCompleted(from, to time.Time, limit int) ([]*scheduler.Job[T], error) {
+	collection := s.db.Collection(s.colName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "processedAt", Value: 1}})
+	if limit > 0 {
+		findOptions.SetLimit(int64(limit))
+	}
+
+	cursor, err := collection.Find(ctx, s.scoped(bson.M{
+		"status":      "completed",
+		"processedAt": bson.M{"$gte": from, "$lt": to},
+	}), findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	jobs := make([]*scheduler.Job[T], 0)
+	for cursor.Next(ctx) {
+		entry, err := s.decodeJob(cursor)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, entry)
+	}
+
+	return jobs, cursor.Err()
+}
+
+// CountFailedByReason returns the number of failed jobs per terminal reason
+func (s *MongoStore[T]) CountFailedByReason() (map[scheduler.TerminalReason]int, error) {
+	collection := s.db.Collection(s.colName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: s.scoped(bson.M{"status": "failed"})}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$ifNull": bson.A{"$terminalReason", ""}},
+			"count": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := make(map[scheduler.TerminalReason]int)
+	for cursor.Next(ctx) {
+		var row struct {
+			Reason scheduler.TerminalReason `bson:"_id"`
+			Count  int                      `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.Reason] = row.Count
+	}
+
+	return counts, cursor.Err()
+}
+
+// RecoverExpiredLeases makes pending jobs whose visibility timeout has lapsed visible again,
+// comparing against the server's $$NOW so the result doesn't depend on the caller's clock
+func (s *MongoStore[T]) RecoverExpiredLeases() (int, error) {
+	collection := s.db.Collection(s.colName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := collection.UpdateMany(ctx, s.scoped(bson.M{
+		"status":       "pending",
+		"visibleAfter": bson.M{"$type": s.storedTimeType()},
+		"$expr":        bson.M{"$lt": bson.A{"$visibleAfter", s.serverNow()}},
+	}), bson.M{
+		"$unset": bson.M{"visibleAfter": ""},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.ModifiedCount), nil
+}
+
+// CountJobsByPayloadField counts jobs whose payload field equals value without fetching them,
+// field uses dot notation for nested fields, e.g. "customer.tenantId". With an index on
+// payload.<field> (prefixed by tenantId for tenant-scoped stores) the count is answered from
+// the index alone. Encrypted payloads can't be queried.
+func (s *MongoStore[T]) CountJobsByPayloadField(ctx context.Context, field, value string) (int64, error) {
+	if s.cfg.encrypter != nil {
+		return 0, errors.New("payload fields can't be queried when payloads are encrypted")
+	}
+
+	return s.db.Collection(s.colName).CountDocuments(ctx, s.scoped(bson.M{"payload." + field: value}))
+}
+
+// ExplainFetch reports which fetch predicates the job fails right now
+func (s *MongoStore[T]) ExplainFetch(id string) (scheduler.FetchExplanation, error) {
+	collection := s.db.Collection(s.colName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var job Job[T]
+	err := collection.FindOne(ctx, s.scoped(bson.M{"_id": id})).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return scheduler.FetchExplanation{}, fmt.Errorf("job not found: %s", id)
+	}
+	if err != nil {
+		return scheduler.FetchExplanation{}, err
+	}
+
+	entry, err := job.toSchedulerJob(s.cfg)
+	if err != nil {
+		return scheduler.FetchExplanation{}, err
+	}
+
+	return scheduler.ExplainFetch(entry, time.Now()), nil
+}
+
+// DeleteJob removes a job from the store, not supported for capped collections
+func (s *MongoStore[T]) DeleteJob(id string) error {
+	if id == "" {
+		return errors.New("job Id cannot be empty")
+	}
+
+	if s.cfg.capped {
+		return ErrCappedCollection
+	}
+
+	collection := s.writeCollection()
+
+	ctx, cancel := s.writeContext()
+	defer cancel()
+
+	_, err := collection.DeleteOne(ctx, s.scoped(bson.M{"_id": id}))
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// CancelByKey deletes the pending jobs with the idempotency key that aren't being processed,
+// not supported for capped collections
+func (s *MongoStore[T]) CancelByKey(key string) (int, error) {
+	if key == "" {
+		return 0, errors.New("idempotency key cannot be empty")
+	}
+
+	if s.cfg.capped {
+		return 0, ErrCappedCollection
+	}
+
+	collection := s.writeCollection()
+
+	ctx, cancel := s.writeContext()
+	defer cancel()
+
+	filter := s.scoped(bson.M{
+		"idempotencyKey": key,
+		"status":         "pending",
+		"$or": []bson.M{
+			{"visibleAfter": bson.M{"$exists": false}},
+			{"visibleAfter": nil},
+			{"visibleAfter": bson.M{"$lt": s.cfg.timeFormat.timeValue(time.Now())}},
+		},
+	})
+
+	result, err := collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.DeletedCount), nil
+}
+
+// ShiftSchedule adds by to the due time of pending jobs due within [from, to) in a single
+// pipeline update, by is applied with millisecond precision
+func (s *MongoStore[T]) ShiftSchedule(from, to time.Time, by time.Duration) (int, error) {
+	collection := s.db.Collection(s.colName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	filter := s.scoped(bson.M{
+		"status": "pending",
+		"processAfter": bson.M{
+			"$gte": s.cfg.timeFormat.timeValue(from),
+			"$lt":  s.cfg.timeFormat.timeValue(to),
+		},
+	})
+
+	// Adding milliseconds works for both dates and epoch milliseconds
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"processAfter": bson.M{"$add": bson.A{"$processAfter", by.Milliseconds()}},
+	}}}}
+
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.ModifiedCount), nil
+}
+
+// IterateJobs streams all jobs from a cursor, calling fn for each until it returns false
+func (s *MongoStore[T]) IterateJobs(ctx context.Context, fn func(*scheduler.Job[T]) bool) error {
+	collection := s.db.Collection(s.colName)
+
+	cursor, err := collection.Find(ctx, s.scoped(bson.M{}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var job Job[T]
+		if err := cursor.Decode(&job); err != nil {
+			return err
+		}
+
+		entry, err := job.toSchedulerJob(s.cfg)
+		if err != nil {
+			return err
+		}
+
+		if !fn(entry) {
+			return nil
+		}
+	}
+
+	return cursor.Err()
+}
@@ -0,0 +1,639 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// The tests below run the store against a mocked deployment, checking the commands it sends
+// without a server
+
+// mockTest runs fn with a client whose commands are answered by the responses fn adds
+func mockTest(t *testing.T, fn func(mt *mtest.T)) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("mock", fn)
+}
+
+// sequenceResponse is the reply to the findAndModify taking the next job sequence
+func sequenceResponse(value int64) bson.D {
+	return mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: "sequence"}, {Key: "value", Value: value}}})
+}
+
+// jobsResponse is the reply to a find returning the given job documents
+func jobsResponse(docs ...bson.D) bson.D {
+	return mtest.CreateCursorResponse(0, "test.jobs", mtest.FirstBatch, docs...)
+}
+
+// startedCommand returns the first command named name the client sent
+func startedCommand(mt *mtest.T, name string) bson.Raw {
+	mt.Helper()
+
+	for _, event := range mt.GetAllStartedEvents() {
+		if event.CommandName == name {
+			return event.Command
+		}
+	}
+	mt.Fatalf("no %s command sent", name)
+	return nil
+}
+
+func TestMongoStoreTenantIsolation(t *testing.T) {
+	tests := []struct {
+		name  string
+		query func(store *MongoStore[string]) error
+		// The command sent and the path of its tenantId field
+		command string
+		path    []string
+		replies func(tenant string) []bson.D
+	}{
+		{
+			name: "fetch",
+			query: func(store *MongoStore[string]) error {
+				_, err := store.FetchPendingJobs(time.Now(), 10, 0)
+				return err
+			},
+			command: "find",
+			path:    []string{"filter", "tenantId"},
+			replies: func(tenant string) []bson.D {
+				return []bson.D{jobsResponse(bson.D{{Key: "_id", Value: "job-1"}, {Key: "status", Value: "pending"}, {Key: "tenantId", Value: tenant}})}
+			},
+		},
+		{
+			name: "add",
+			query: func(store *MongoStore[string]) error {
+				return store.AddJob(&scheduler.Job[string]{Id: "job-1", Status: "pending", TenantID: "other"})
+			},
+			command: "insert",
+			path:    []string{"documents", "0", "tenantId"},
+			replies: func(string) []bson.D { return []bson.D{sequenceResponse(1), mtest.CreateSuccessResponse()} },
+		},
+		{
+			name: "update",
+			query: func(store *MongoStore[string]) error {
+				return store.UpdateJob(&scheduler.Job[string]{Id: "job-1", Status: "completed"})
+			},
+			command: "update",
+			path:    []string{"updates", "0", "q", "tenantId"},
+			replies: func(string) []bson.D { return []bson.D{mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1})} },
+		},
+		{
+			name: "delete",
+			query: func(store *MongoStore[string]) error {
+				return store.DeleteJob("job-1")
+			},
+			command: "delete",
+			path:    []string{"deletes", "0", "q", "tenantId"},
+			replies: func(string) []bson.D { return []bson.D{mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1})} },
+		},
+	}
+
+	for _, tt := range tests {
+		for _, tenant := range []string{"tenant-a", "tenant-b"} {
+			t.Run(tt.name+" "+tenant, func(t *testing.T) {
+				mockTest(t, func(mt *mtest.T) {
+					mt.AddMockResponses(tt.replies(tenant)...)
+					store := NewMongoStoreForTenant[string](mt.DB, "jobs", tenant)
+
+					if err := tt.query(store); err != nil {
+						mt.Fatalf("%s failed: %v", tt.name, err)
+					}
+
+					got, ok := startedCommand(mt, tt.command).Lookup(tt.path...).StringValueOK()
+					if !ok || got != tenant {
+						mt.Fatalf("%s command tenantId = %q, want %q", tt.command, got, tenant)
+					}
+				})
+			})
+		}
+	}
+}
+
+func TestMongoStorePriorityAging(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []MongoStoreOption
+		wantCommand string
+		wantSortKey string
+	}{
+		{name: "no aging sorts by stored priority", wantCommand: "find", wantSortKey: "priority"},
+		{name: "aging sorts by computed priority", opts: []MongoStoreOption{WithAgingInterval(time.Minute)}, wantCommand: "aggregate", wantSortKey: "effectivePriority"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTest(t, func(mt *mtest.T) {
+				mt.AddMockResponses(jobsResponse())
+				store := NewMongoStore[string](mt.DB, "jobs", tt.opts...)
+
+				if _, err := store.FetchPendingJobs(time.Now(), 10, 0); err != nil {
+					mt.Fatal(err)
+				}
+
+				cmd := startedCommand(mt, tt.wantCommand)
+				sort, ok := cmd.Lookup("sort").DocumentOK()
+				if tt.wantCommand == "aggregate" {
+					sort, ok = cmd.Lookup("pipeline", "2", "$sort").DocumentOK()
+				}
+				if !ok {
+					mt.Fatalf("%s command has no sort: %s", tt.wantCommand, cmd)
+				}
+				first, err := sort.IndexErr(0)
+				if err != nil || first.Key() != tt.wantSortKey || first.Value().AsInt32() != -1 {
+					mt.Fatalf("sort = %s, want %s descending first", sort, tt.wantSortKey)
+				}
+			})
+		})
+	}
+}
+
+func TestMongoStoreTransitionStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		from           string
+		matched        int32 // Documents matching the filter
+		wantOK         bool
+		wantVisibility bool // Whether the filter skips jobs hidden by a visibility timeout
+	}{
+		{name: "status matches", from: "pending", matched: 1, wantOK: true, wantVisibility: true},
+		{name: "status doesn't match", from: "pending", matched: 0, wantVisibility: true},
+		{name: "from a status without visibility", from: "paused", matched: 1, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTest(t, func(mt *mtest.T) {
+				mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: tt.matched}, bson.E{Key: "nModified", Value: tt.matched}))
+				store := NewMongoStore[string](mt.DB, "jobs")
+
+				ok, err := store.TransitionStatus("job-1", tt.from, "cancelled")
+				if err != nil || ok != tt.wantOK {
+					mt.Fatalf("TransitionStatus() = %v, %v, want %v", ok, err, tt.wantOK)
+				}
+
+				// The current status is part of the filter, so the update only applies if it still matches
+				cmd := startedCommand(mt, "update")
+				if from, _ := cmd.Lookup("updates", "0", "q", "status").StringValueOK(); from != tt.from {
+					mt.Fatalf("update filter status = %q, want %s", from, tt.from)
+				}
+				if to, _ := cmd.Lookup("updates", "0", "u", "$set", "status").StringValueOK(); to != "cancelled" {
+					mt.Fatalf("update sets status %q, want cancelled", to)
+				}
+				// A worker processing the job would overwrite the new status with its result
+				if _, err := cmd.LookupErr("updates", "0", "q", "$or"); (err == nil) != tt.wantVisibility {
+					mt.Fatalf("update filter checks visibility = %v, want %v", err == nil, tt.wantVisibility)
+				}
+			})
+		})
+	}
+
+	t.Run("processing", func(t *testing.T) {
+		store := NewMongoStore[string](nil, "jobs")
+		if ok, err := store.TransitionStatus("job-1", "processing", "cancelled"); ok || err != nil {
+			t.Fatalf("TransitionStatus() = %v, %v, want a processing job left alone", ok, err)
+		}
+	})
+}
+
+func TestMongoStoreSkipsUndecodableJobs(t *testing.T) {
+	tests := []struct {
+		name         string
+		poisonStatus string
+	}{
+		{name: "left pending"},
+		{name: "moved to poison status", poisonStatus: "poison"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTest(t, func(mt *mtest.T) {
+				job := func(id string, payload any) bson.D {
+					return bson.D{{Key: "_id", Value: id}, {Key: "status", Value: "pending"}, {Key: "payload", Value: payload}}
+				}
+				// The corrupt document holds a string where the payload struct is expected
+				mt.AddMockResponses(
+					jobsResponse(
+						job("job-1", bson.D{{Key: "to", Value: "a@example.com"}}),
+						job("job-2", "not an email"),
+						job("job-3", bson.D{{Key: "to", Value: "b@example.com"}}),
+					),
+					mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+				)
+
+				var skipped []string
+				opts := []MongoStoreOption{WithOnDecodeError(func(id string, err error) { skipped = append(skipped, id) })}
+				if tt.poisonStatus != "" {
+					opts = append(opts, WithPoisonStatus(tt.poisonStatus))
+				}
+				store := NewMongoStore[emailPayload](mt.DB, "jobs", opts...)
+
+				jobs, err := store.FetchPendingJobs(time.Now(), 10, 0)
+				if err != nil {
+					mt.Fatalf("FetchPendingJobs() error = %v", err)
+				}
+				if len(jobs) != 2 || jobs[0].Id != "job-1" || jobs[1].Id != "job-3" {
+					mt.Fatalf("FetchPendingJobs() = %v, want job-1 and job-3", jobs)
+				}
+				if len(skipped) != 1 || skipped[0] != "job-2" || store.DecodeErrors() != 1 {
+					mt.Fatalf("skipped %v (%d decode errors), want job-2", skipped, store.DecodeErrors())
+				}
+
+				var poisoned string
+				for _, event := range mt.GetAllStartedEvents() {
+					if event.CommandName == "update" {
+						poisoned, _ = event.Command.Lookup("updates", "0", "u", "$set", "status").StringValueOK()
+					}
+				}
+				if poisoned != tt.poisonStatus {
+					mt.Fatalf("undecodable job moved to status %q, want %q", poisoned, tt.poisonStatus)
+				}
+			})
+		})
+	}
+}
+
+// orderPayload nests the tenant id, as payload fields are often queried by a dotted path
+type orderPayload struct {
+	Customer struct {
+		TenantID string `bson:"tenantId"`
+	} `bson:"customer"`
+}
+
+func TestMongoStoreCountJobsByPayloadField(t *testing.T) {
+	tests := []struct {
+		name        string
+		storeTenant string
+		tenant      string
+	}{
+		{name: "unscoped store", tenant: "tenant-3"},
+		{name: "tenant scoped store", storeTenant: "team-a", tenant: "tenant-0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTest(t, func(mt *mtest.T) {
+				var opts []MongoStoreOption
+				if tt.storeTenant != "" {
+					opts = append(opts, WithTenant(tt.storeTenant))
+				}
+				store := NewMongoStore[orderPayload](mt.DB, "jobs", opts...)
+
+				// 100 jobs spread over 7 tenants
+				for i := range 100 {
+					mt.AddMockResponses(sequenceResponse(int64(i+1)), mtest.CreateSuccessResponse())
+					var payload orderPayload
+					payload.Customer.TenantID = fmt.Sprintf("tenant-%d", i%7)
+					if err := store.AddJob(&scheduler.Job[orderPayload]{Id: fmt.Sprintf("job-%d", i), Status: "pending", Payload: payload}); err != nil {
+						mt.Fatal(err)
+					}
+				}
+
+				// Count the stored documents the field path selects, which the server would count
+				var want int64
+				for _, event := range mt.GetAllStartedEvents() {
+					if event.CommandName != "insert" {
+						continue
+					}
+					if value, _ := event.Command.Lookup("documents", "0", "payload", "customer", "tenantId").StringValueOK(); value == tt.tenant {
+						want++
+					}
+				}
+				mt.ClearEvents()
+				mt.AddMockResponses(jobsResponse(bson.D{{Key: "_id", Value: 1}, {Key: "n", Value: int32(want)}}))
+
+				got, err := store.CountJobsByPayloadField(context.Background(), "customer.tenantId", tt.tenant)
+				if err != nil {
+					mt.Fatalf("CountJobsByPayloadField() error = %v", err)
+				}
+				if got != want || want == 0 {
+					mt.Fatalf("CountJobsByPayloadField() = %d, want %d", got, want)
+				}
+
+				match := startedCommand(mt, "aggregate").Lookup("pipeline", "0", "$match").Document()
+				if value, _ := match.Lookup("payload.customer.tenantId").StringValueOK(); value != tt.tenant {
+					mt.Fatalf("count filter = %s, want payload.customer.tenantId %q", match, tt.tenant)
+				}
+				if scope, _ := match.Lookup("tenantId").StringValueOK(); scope != tt.storeTenant {
+					mt.Fatalf("count filter = %s, want tenant %q", match, tt.storeTenant)
+				}
+			})
+		})
+	}
+}
+
+func TestMongoStoreDeprioritizeFailing(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []MongoStoreOption
+		wantSort []string
+	}{
+		{name: "earliest due first by default", wantSort: []string{"priority", "processAfter", "sequence"}},
+		{name: "fewest attempts first", opts: []MongoStoreOption{WithDeprioritizeFailing()}, wantSort: []string{"priority", "attempts", "processAfter", "sequence"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTest(t, func(mt *mtest.T) {
+				mt.AddMockResponses(jobsResponse())
+				store := NewMongoStore[string](mt.DB, "jobs", tt.opts...)
+
+				if _, err := store.FetchPendingJobs(time.Now(), 10, 0); err != nil {
+					mt.Fatal(err)
+				}
+
+				elements, err := startedCommand(mt, "find").Lookup("sort").Document().Elements()
+				if err != nil {
+					mt.Fatal(err)
+				}
+				var keys []string
+				for _, e := range elements {
+					keys = append(keys, e.Key())
+				}
+				if !slices.Equal(keys, tt.wantSort) {
+					mt.Fatalf("sort keys = %v, want %v", keys, tt.wantSort)
+				}
+			})
+		})
+	}
+}
+
+func TestMongoStoreRecoverExpiredLeases(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []MongoStoreOption
+		wantType string
+		wantNow  bson.RawValue // The server time expression visibleAfter is compared to
+	}{
+		{name: "native dates", wantType: "date", wantNow: bsonValue("$$NOW")},
+		{name: "epoch millis", opts: []MongoStoreOption{WithTimeFormat(TimeFormatEpochMillis)}, wantType: "long", wantNow: bsonValue(bson.D{{Key: "$toLong", Value: "$$NOW"}})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTest(t, func(mt *mtest.T) {
+				mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 3}, bson.E{Key: "nModified", Value: 3}))
+				store := NewMongoStore[string](mt.DB, "jobs", tt.opts...)
+
+				recovered, err := store.RecoverExpiredLeases()
+				if err != nil || recovered != 3 {
+					mt.Fatalf("RecoverExpiredLeases() = %d, %v, want 3", recovered, err)
+				}
+
+				// Lapsed leases are found by the server clock, no client time is sent
+				update := startedCommand(mt, "update").Lookup("updates", "0")
+				filter := update.Document().Lookup("q").Document()
+				if got, _ := filter.Lookup("visibleAfter", "$type").StringValueOK(); got != tt.wantType {
+					mt.Fatalf("filter visibleAfter type = %q, want %q", got, tt.wantType)
+				}
+				now := filter.Lookup("$expr", "$lt").Array().Index(1).Value()
+				if !now.Equal(tt.wantNow) {
+					mt.Fatalf("visibleAfter compared to %s, want %s", now, tt.wantNow)
+				}
+				if _, err := update.Document().LookupErr("u", "$unset", "visibleAfter"); err != nil {
+					mt.Fatalf("update doesn't clear visibleAfter: %s", update)
+				}
+			})
+		})
+	}
+}
+
+// bsonValue marshals v as a single BSON value, for comparing against command fields
+func bsonValue(v any) bson.RawValue {
+	t, data, err := bson.MarshalValue(v)
+	if err != nil {
+		panic(err)
+	}
+	return bson.RawValue{Type: t, Value: data}
+}
+
+func TestMongoStoreTimeFormatRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   TimeFormat
+		wantType bsontype.Type
+	}{
+		{name: "native dates", format: TimeFormatNative, wantType: bsontype.DateTime},
+		{name: "epoch millis", format: TimeFormatEpochMillis, wantType: bsontype.Int64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTest(t, func(mt *mtest.T) {
+				store := NewMongoStore[string](mt.DB, "jobs", WithTimeFormat(tt.format))
+				due := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+				visible := due.Add(30 * time.Second)
+
+				mt.AddMockResponses(sequenceResponse(1), mtest.CreateSuccessResponse())
+				if err := store.AddJob(&scheduler.Job[string]{Id: "job-1", Status: "pending", ProcessAfter: due, VisibleAfter: &visible, Payload: "a"}); err != nil {
+					mt.Fatal(err)
+				}
+
+				stored := startedCommand(mt, "insert").Lookup("documents", "0").Document()
+				for _, field := range []string{"processAfter", "visibleAfter"} {
+					if got := stored.Lookup(field).Type; got != tt.wantType {
+						mt.Fatalf("%s stored as %s, want %s", field, got, tt.wantType)
+					}
+				}
+				if tt.format == TimeFormatEpochMillis && stored.Lookup("processAfter").Int64() != due.UnixMilli() {
+					mt.Fatalf("processAfter stored as %s, want %d", stored.Lookup("processAfter"), due.UnixMilli())
+				}
+
+				// Fetching queries in the same representation and decodes the stored document back
+				mt.ClearEvents()
+				var doc bson.D
+				if err := bson.Unmarshal(stored, &doc); err != nil {
+					mt.Fatal(err)
+				}
+				mt.AddMockResponses(jobsResponse(doc))
+
+				jobs, err := store.FetchPendingJobs(time.Now(), 10, 0)
+				if err != nil {
+					mt.Fatal(err)
+				}
+				if got := startedCommand(mt, "find").Lookup("filter", "processAfter", "$lt").Type; got != tt.wantType {
+					mt.Fatalf("fetch queries processAfter as %s, want %s", got, tt.wantType)
+				}
+				if len(jobs) != 1 || !jobs[0].ProcessAfter.Equal(due) || !jobs[0].VisibleAfter.Equal(visible) {
+					mt.Fatalf("fetched %+v, want processAfter %s and visibleAfter %s", jobs, due, visible)
+				}
+			})
+		})
+	}
+}
+
+func TestMongoStoreTextSearchJobs(t *testing.T) {
+	tests := []struct {
+		name    string
+		orderBy ListOrder
+		// The sort the find command should carry
+		sortKey string
+	}{
+		{name: "by due time", orderBy: OrderByProcessAfter, sortKey: "processAfter"},
+		{name: "by text score", orderBy: TextScore, sortKey: "score"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTest(t, func(mt *mtest.T) {
+				store := NewMongoStore[emailPayload](mt.DB, "jobs", WithTextIndexFields([]string{"subject"}))
+
+				// 1000 email jobs, every tenth about an invoice
+				for i := range 1000 {
+					mt.AddMockResponses(sequenceResponse(int64(i+1)), mtest.CreateSuccessResponse())
+					subject := fmt.Sprintf("Welcome aboard #%d", i)
+					if i%10 == 0 {
+						subject = fmt.Sprintf("Your invoice #%d", i)
+					}
+					if err := store.AddJob(&scheduler.Job[emailPayload]{Id: fmt.Sprintf("job-%d", i), Status: "pending", Payload: emailPayload{Subject: subject}}); err != nil {
+						mt.Fatal(err)
+					}
+				}
+
+				// Reply with the stored documents the server would match
+				var matches []bson.D
+				for _, event := range mt.GetAllStartedEvents() {
+					if event.CommandName != "insert" {
+						continue
+					}
+					doc := event.Command.Lookup("documents", "0").Document()
+					if subject, _ := doc.Lookup("payload", "subject").StringValueOK(); !strings.Contains(subject, "invoice") {
+						continue
+					}
+					var match bson.D
+					if err := bson.Unmarshal(doc, &match); err != nil {
+						mt.Fatal(err)
+					}
+					matches = append(matches, match)
+				}
+				mt.ClearEvents()
+				mt.AddMockResponses(jobsResponse(matches...))
+
+				jobs, err := store.TextSearchJobs(context.Background(), "invoice", ListOptions{Limit: 500, OrderBy: tt.orderBy})
+				if err != nil {
+					mt.Fatalf("TextSearchJobs() error = %v", err)
+				}
+				if len(jobs) != 100 {
+					mt.Fatalf("TextSearchJobs() returned %d jobs, want 100", len(jobs))
+				}
+				for _, job := range jobs {
+					if !strings.Contains(job.Payload.Subject, "invoice") {
+						mt.Fatalf("TextSearchJobs() returned %s with subject %q", job.Id, job.Payload.Subject)
+					}
+				}
+
+				find := startedCommand(mt, "find")
+				if search, _ := find.Lookup("filter", "$text", "$search").StringValueOK(); search != "invoice" {
+					mt.Fatalf("find filter = %s, want $text search %q", find.Lookup("filter"), "invoice")
+				}
+				if _, err := find.Lookup("sort").Document().LookupErr(tt.sortKey); err != nil {
+					mt.Fatalf("find sort = %s, want %s", find.Lookup("sort"), tt.sortKey)
+				}
+				_, projected := find.Lookup("projection").DocumentOK()
+				if projected != (tt.orderBy == TextScore) {
+					mt.Fatalf("find projection = %s, want score projected %v", find.Lookup("projection"), tt.orderBy == TextScore)
+				}
+			})
+		})
+	}
+}
+
+func TestMongoStoreShiftSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   TimeFormat
+		by       time.Duration
+		wantType bsontype.Type
+	}{
+		{name: "native dates", format: TimeFormatNative, by: 3 * time.Hour, wantType: bsontype.DateTime},
+		{name: "epoch millis", format: TimeFormatEpochMillis, by: 90 * time.Minute, wantType: bsontype.Int64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTest(t, func(mt *mtest.T) {
+				store := NewMongoStore[string](mt.DB, "jobs", WithTimeFormat(tt.format))
+				from := time.Now().Add(time.Hour)
+				to := from.Add(2 * time.Hour)
+
+				mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 4}, bson.E{Key: "nModified", Value: 4}))
+				shifted, err := store.ShiftSchedule(from, to, tt.by)
+				if err != nil || shifted != 4 {
+					mt.Fatalf("ShiftSchedule() = %d, %v, want 4", shifted, err)
+				}
+
+				update := startedCommand(mt, "update").Lookup("updates", "0")
+				filter := update.Document().Lookup("q").Document()
+				if status, _ := filter.Lookup("status").StringValueOK(); status != "pending" {
+					mt.Fatalf("shift filter = %s, want pending jobs", filter)
+				}
+				for _, bound := range []string{"$gte", "$lt"} {
+					if got := filter.Lookup("processAfter", bound).Type; got != tt.wantType {
+						mt.Fatalf("shift filter processAfter.%s is %s, want %s", bound, got, tt.wantType)
+					}
+				}
+				add := update.Document().Lookup("u", "0", "$set", "processAfter", "$add")
+				if got := add.Array().Index(1).Value(); !got.Equal(bsonValue(tt.by.Milliseconds())) {
+					mt.Fatalf("shift adds %s, want %d milliseconds", got, tt.by.Milliseconds())
+				}
+				if multi, _ := update.Document().Lookup("multi").BooleanOK(); !multi {
+					mt.Fatal("shift updates a single job, want every job in the window")
+				}
+			})
+		})
+	}
+}
+
+func TestMongoStoreTieBreak(t *testing.T) {
+	tests := []struct {
+		name     string
+		tieBreak scheduler.TieBreak
+		wantKey  string
+		wantDir  int32
+	}{
+		{name: "fifo", tieBreak: scheduler.TieBreakFIFO, wantKey: "sequence", wantDir: 1},
+		{name: "lifo", tieBreak: scheduler.TieBreakLIFO, wantKey: "sequence", wantDir: -1},
+		{name: "random", tieBreak: scheduler.TieBreakRandom, wantKey: "tieKey", wantDir: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTest(t, func(mt *mtest.T) {
+				store := NewMongoStore[string](mt.DB, "jobs")
+				store.SetTieBreak(tt.tieBreak)
+
+				// Every job stores the hash random ties are ordered by
+				mt.AddMockResponses(sequenceResponse(1), mtest.CreateSuccessResponse())
+				if err := store.AddJob(&scheduler.Job[string]{Id: "job-1", Status: "pending", ProcessAfter: time.Now()}); err != nil {
+					mt.Fatal(err)
+				}
+				if got := startedCommand(mt, "insert").Lookup("documents", "0", "tieKey"); !got.Equal(bsonValue(scheduler.TieKey("job-1"))) {
+					mt.Fatalf("stored tieKey = %s, want %d", got, scheduler.TieKey("job-1"))
+				}
+
+				mt.AddMockResponses(jobsResponse())
+				if _, err := store.FetchPendingJobs(time.Now(), 10, 0); err != nil {
+					mt.Fatal(err)
+				}
+
+				// Ties are broken after priority and due time
+				elements, err := startedCommand(mt, "find").Lookup("sort").Document().Elements()
+				if err != nil {
+					mt.Fatal(err)
+				}
+				last := elements[len(elements)-1]
+				if last.Key() != tt.wantKey || last.Value().AsInt32() != tt.wantDir || elements[len(elements)-2].Key() != "processAfter" {
+					mt.Fatalf("sort = %v, want processAfter then %s %d", elements, tt.wantKey, tt.wantDir)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package mongo
+
+import (
+	"log/slog"
+	"time"
+
+	scheduler "go-sched"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoStoreOption configures optional MongoStore behaviour
+type MongoStoreOption func(*storeConfig)
+
+type storeConfig struct {
+	encrypter           scheduler.Encrypter
+	tenantID            string
+	aging               time.Duration
+	capped              bool
+	cappedSize          int64
+	cappedMaxDoc        int64
+	partialIndex        bool
+	onDecodeError       func(id string, err error)
+	poisonStatus        string
+	deprioritizeFailing bool
+	timeFormat          TimeFormat
+	textFields          []string
+	log                 *slog.Logger
+	metrics             scheduler.MetricsCollector
+	session             mongo.Session
+}
+
+// logger returns the configured logger, slog's default logger if none is set
+func (cfg storeConfig) logger() *slog.Logger {
+	if cfg.log == nil {
+		return slog.Default()
+	}
+	return cfg.log
+}
+
+// WithEncrypter encrypts job payloads at rest, payloads are serialized to JSON
+// and stored as an opaque blob which is decrypted on read
+func WithEncrypter(encrypter scheduler.Encrypter) MongoStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.encrypter = encrypter
+	}
+}
+
+// WithCappedCollection makes EnsureIndexes create the collection as a capped collection
+// of sizeBytes holding at most maxDocs documents (0 for no document limit). The oldest
+// documents are evicted automatically in FIFO order once the cap is reached. Capped
+// collections do not support deletes, so DeleteJob returns ErrCappedCollection.
+func WithCappedCollection(sizeBytes int64, maxDocs int64) MongoStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.capped = true
+		cfg.cappedSize = sizeBytes
+		cfg.cappedMaxDoc = maxDocs
+	}
+}
+
+// WithTenant scopes the store to a single tenant, jobs are stamped with the tenant
+// and the store filters every query by a tenantId field
+func WithTenant(tenantID string) MongoStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.tenantID = tenantID
+	}
+}
+
+// WithAgingInterval raises a job's fetch priority by one for every interval it has waited
+// past its ProcessAfter, preventing starvation of low priority jobs. The effective priority
+// is computed in an aggregation pipeline.
+func WithAgingInterval(interval time.Duration) MongoStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.aging = interval
+	}
+}
+
+// WithPartialIndex controls whether EnsureIndexes creates the fetch index as a partial index
+// over pending jobs only (default true). The partial index stays small as completed and failed
+// jobs accumulate; disable it when other queries, e.g. listing jobs by status, need an index
+// covering every status.
+func WithPartialIndex(enabled bool) MongoStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.partialIndex = enabled
+	}
+}
+
+// WithOnDecodeError calls fn for every job FetchPendingJobs skips because its document can't be
+// decoded, e.g. after a payload schema change. The rest of the batch is still returned.
+func WithOnDecodeError(fn func(id string, err error)) MongoStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.onDecodeError = fn
+	}
+}
+
+// WithPoisonStatus moves jobs that can't be decoded to status, e.g. "poison", so they stop being
+// fetched and can be inspected later. Without it undecodable jobs stay pending and are skipped on
+// every fetch.
+func WithPoisonStatus(status string) MongoStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.poisonStatus = status
+	}
+}
+
+// WithDeprioritizeFailing orders jobs with fewer attempts first among jobs of the same priority,
+// so during a partial outage jobs that keep failing don't crowd out fresh and long-waiting ones
+func WithDeprioritizeFailing() MongoStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.deprioritizeFailing = true
+	}
+}
+
+// WithTimeFormat sets how processAfter and visibleAfter are stored and queried (default
+// TimeFormatNative). Documents in either format are read, but only jobs stored in the configured
+// format are fetched, so migrate existing documents before switching.
+func WithTimeFormat(format TimeFormat) MongoStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.timeFormat = format
+	}
+}
+
+// WithLogger sets the logger for warnings the store handles itself, e.g. a ShardedMongoStore
+// skipping an unavailable shard (default: slog.Default())
+func WithLogger(log *slog.Logger) MongoStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.log = log
+	}
+}
+
+// WithTextIndexFields makes EnsureIndexes create a text index over the given payload fields, in
+// dot notation for nested fields, e.g. "subject" or "body.text", enabling TextSearchJobs. MongoDB
+// allows a single text index per collection.
+func WithTextIndexFields(fields []string) MongoStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.textFields = fields
+	}
+}
+
+// WithMetricsCollector records the payload size of every job the store adds with collector,
+// e.g. a scheduler.PayloadMetrics. Payloads are measured as JSON before encryption.
+func WithMetricsCollector(collector scheduler.MetricsCollector) MongoStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.metrics = collector
+	}
+}
+
+// WithSession makes AddJob, AddUniqueJob, UpdateJob, DeleteJob and CancelByKey run in session,
+// joining the transaction the caller started on it, e.g. to add a job in the same transaction as the
+// application documents it belongs to. Other operations don't use the session, and
+// ShardedMongoStore, whose shards may live on other clusters, mustn't be given one. See UseSession
+// to bind a session for the duration of a transaction only.
+func WithSession(session mongo.Session) MongoStoreOption {
+	return func(cfg *storeConfig) {
+		cfg.session = session
+	}
+}
@@ -0,0 +1,85 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	scheduler "go-sched"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ListOrder decides the order of listed jobs
+type ListOrder int
+
+const (
+	// OrderByProcessAfter lists jobs by due time, earliest first
+	OrderByProcessAfter ListOrder = iota
+	// TextScore lists text search results by relevance, best match first
+	TextScore
+)
+
+// ListOptions pages and orders job listings
+type ListOptions struct {
+	Limit   int // Maximum number of jobs to return, 0 for no limit
+	Skip    int // Number of jobs to skip, for paging
+	OrderBy ListOrder
+}
+
+// textIndex returns the text index over the configured payload fields, prefixed by tenantId for
+// tenant-scoped stores so searches only scan the tenant's jobs
+func (s *MongoStore[T]) textIndex() mongo.IndexModel {
+	var keys bson.D
+	if s.cfg.tenantID != "" {
+		keys = append(keys, bson.E{Key: "tenantId", Value: 1})
+	}
+	for _, field := range s.cfg.textFields {
+		keys = append(keys, bson.E{Key: "payload." + field, Value: "text"})
+	}
+
+	return mongo.IndexModel{Keys: keys}
+}
+
+// TextSearchJobs returns jobs whose payload text fields match query, using MongoDB's $text search
+// syntax. The store needs WithTextIndexFields and EnsureIndexes; encrypted payloads can't be searched.
+func (s *MongoStore[T]) TextSearchJobs(ctx context.Context, query string, opts ListOptions) ([]*scheduler.Job[T], error) {
+	if s.cfg.encrypter != nil {
+		return nil, errors.New("payload fields can't be searched when payloads are encrypted")
+	}
+	if len(s.cfg.textFields) == 0 {
+		return nil, errors.New("text search needs text index fields, see WithTextIndexFields")
+	}
+
+	findOptions := options.Find()
+	if opts.OrderBy == TextScore {
+		score := bson.M{"score": bson.M{"$meta": "textScore"}}
+		findOptions.SetProjection(score).SetSort(score)
+	} else {
+		findOptions.SetSort(bson.D{{Key: "processAfter", Value: 1}, {Key: "sequence", Value: 1}})
+	}
+	if opts.Limit > 0 {
+		findOptions.SetLimit(int64(opts.Limit))
+	}
+	if opts.Skip > 0 {
+		findOptions.SetSkip(int64(opts.Skip))
+	}
+
+	cursor, err := s.db.Collection(s.colName).Find(ctx, s.scoped(bson.M{"$text": bson.M{"$search": query}}), findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	jobs := make([]*scheduler.Job[T], 0)
+	for cursor.Next(ctx) {
+		entry, err := s.decodeJob(cursor)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, entry)
+	}
+
+	return jobs, cursor.Err()
+}
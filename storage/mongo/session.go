@@ -0,0 +1,44 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// writeCollection returns the jobs collection written by AddJob, AddUniqueJob, UpdateJob, DeleteJob
+// and CancelByKey, through the client of the store's session if it has one
+func (s *MongoStore[T]) writeCollection() *mongo.Collection {
+	if s.cfg.session == nil {
+		return s.db.Collection(s.colName)
+	}
+	return s.cfg.session.Client().Database(s.db.Name()).Collection(s.colName)
+}
+
+// writeContext returns the context of a single AddJob, AddUniqueJob, UpdateJob, DeleteJob or
+// CancelByKey call, carrying the store's session if it has one so the write joins the session's
+// transaction
+func (s *MongoStore[T]) writeContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	if s.cfg.session != nil {
+		ctx = mongo.NewSessionContext(ctx, s.cfg.session)
+	}
+	return ctx, cancel
+}
+
+// UseSession runs fn in a transaction on session with a copy of store whose AddJob, AddUniqueJob,
+// UpdateJob, DeleteJob and CancelByKey join the transaction, committing it if fn returns nil and
+// aborting it otherwise. Write application documents with mongo.NewSessionContext(ctx, session) to
+// include them. Like mongo.Session.WithTransaction, fn is run again on transient transaction errors.
+func UseSession[T any](ctx context.Context, store *MongoStore[T], session mongo.Session, fn func(*MongoStore[T]) error) error {
+	cfg := store.cfg
+	cfg.session = session
+	bound := &MongoStore[T]{db: store.db, colName: store.colName, cfg: cfg, owner: store.owner}
+	bound.tieBreak.Store(store.tieBreak.Load())
+
+	_, err := session.WithTransaction(ctx, func(mongo.SessionContext) (any, error) {
+		return nil, fn(bound)
+	})
+	return err
+}
@@ -0,0 +1,241 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	scheduler "go-sched"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/sync/errgroup"
+)
+
+// shardReplicas is the number of points each shard gets on the hash ring, spreading jobs evenly
+const shardReplicas = 100
+
+// ShardedMongoStore spreads jobs across several databases, each job lives on the shard its id
+// hashes to. Shards are identified by their position, so the shard list must keep its order.
+type ShardedMongoStore[T any] struct {
+	shards []*MongoStore[T]
+	ring   []ringPoint
+	cfg    storeConfig
+
+	tieBreak atomic.Int64 // scheduler.TieBreak set with SetTieBreak
+}
+
+// ringPoint is a position on the consistent hash ring owned by a shard
+type ringPoint struct {
+	hash  uint32
+	shard int
+}
+
+// NewShardedMongoStore creates a store that assigns jobs to shards by consistent hashing of the
+// job id, each shard keeps its jobs in colName
+func NewShardedMongoStore[T any](shards []*mongo.Database, colName string, opts ...MongoStoreOption) *ShardedMongoStore[T] {
+	s := &ShardedMongoStore[T]{cfg: storeConfig{partialIndex: true}}
+	for _, opt := range opts {
+		opt(&s.cfg)
+	}
+
+	for i, db := range shards {
+		s.shards = append(s.shards, NewMongoStore[T](db, colName, opts...))
+		for r := 0; r < shardReplicas; r++ {
+			s.ring = append(s.ring, ringPoint{hash: hashKey(fmt.Sprintf("%d-%d", i, r)), shard: i})
+		}
+	}
+	sort.Slice(s.ring, func(i, j int) bool { return s.ring[i].hash < s.ring[j].hash })
+
+	return s
+}
+
+func hashKey(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+// shardFor returns the shard owning the job id, the first ring point at or after the id's hash
+func (s *ShardedMongoStore[T]) shardFor(id string) *MongoStore[T] {
+	hash := hashKey(id)
+	i := sort.Search(len(s.ring), func(i int) bool { return s.ring[i].hash >= hash })
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.shards[s.ring[i].shard]
+}
+
+// FetchPendingJobs queries every shard concurrently and returns the limit best jobs across them.
+// A failing shard is logged and skipped, an error is returned only if every shard fails.
+func (s *ShardedMongoStore[T]) FetchPendingJobs(after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	return s.fetch(after, limit, nil)
+}
+
+// FetchPendingJobsByType is FetchPendingJobs restricted to jobs passing filter
+func (s *ShardedMongoStore[T]) FetchPendingJobsByType(filter scheduler.TypeFilter, after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	return s.fetch(after, limit, &filter)
+}
+
+// fetch merges the limit best pending jobs across the shards, of the types passing filter if it
+// isn't nil
+func (s *ShardedMongoStore[T]) fetch(after time.Time, limit int, filter *scheduler.TypeFilter) ([]*scheduler.Job[T], error) {
+	var mu sync.Mutex
+	var jobs []*scheduler.Job[T]
+	var errs []error
+
+	var g errgroup.Group
+	for i, shard := range s.shards {
+		g.Go(func() error {
+			// Shards only read, locking would hide the jobs dropped by the merge below
+			entries, err := shard.fetch(after, limit, filter, 0)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				s.cfg.logger().Warn("failed to fetch pending jobs from shard, skipping it", "shard", i, "error", err)
+				errs = append(errs, fmt.Errorf("shard %d: %w", i, err))
+				return nil
+			}
+			jobs = append(jobs, entries...)
+			return nil
+		})
+	}
+	g.Wait()
+
+	if len(s.shards) > 0 && len(errs) == len(s.shards) {
+		return nil, errors.Join(errs...)
+	}
+
+	s.sortJobs(jobs, time.Now())
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+
+	return jobs, nil
+}
+
+// sortJobs orders jobs merged from several shards the way a single shard fetches them
+func (s *ShardedMongoStore[T]) sortJobs(jobs []*scheduler.Job[T], now time.Time) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		a, b := jobs[i], jobs[j]
+		if pa, pb := a.EffectivePriority(now, s.cfg.aging), b.EffectivePriority(now, s.cfg.aging); pa != pb {
+			return pa > pb
+		}
+		if s.cfg.deprioritizeFailing && a.Attempts != b.Attempts {
+			return a.Attempts < b.Attempts
+		}
+		if !a.ProcessAfter.Equal(b.ProcessAfter) {
+			return a.ProcessAfter.Before(b.ProcessAfter)
+		}
+		return scheduler.TieBreakLess(scheduler.TieBreak(s.tieBreak.Load()), a, b)
+	})
+}
+
+// SetTieBreak orders pending jobs that are equally due and of equal priority by tb on every shard
+// and when merging their jobs
+func (s *ShardedMongoStore[T]) SetTieBreak(tb scheduler.TieBreak) {
+	s.tieBreak.Store(int64(tb))
+	for _, shard := range s.shards {
+		shard.SetTieBreak(tb)
+	}
+}
+
+// FetchCompleted returns up to limit jobs (0 for no limit) completed within [from, to) across all
+// shards, ordered by completion time
+func (s *ShardedMongoStore[T]) FetchCompleted(from, to time.Time, limit int) ([]*scheduler.Job[T], error) {
+	var jobs []*scheduler.Job[T]
+	for i, shard := range s.shards {
+		entries, err := shard.FetchCompleted(from, to, limit)
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: %w", i, err)
+		}
+		jobs = append(jobs, entries...)
+	}
+
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].ProcessedAt.Before(*jobs[j].ProcessedAt)
+	})
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+
+	return jobs, nil
+}
+
+// UpdateJob updates the job on its shard
+func (s *ShardedMongoStore[T]) UpdateJob(job *scheduler.Job[T]) error {
+	if job.Id == "" {
+		return errors.New("job Id cannot be empty")
+	}
+
+	return s.shardFor(job.Id).UpdateJob(job)
+}
+
+// AddJob adds the job to its shard
+func (s *ShardedMongoStore[T]) AddJob(job *scheduler.Job[T]) error {
+	if job.Id == "" {
+		return errors.New("job Id cannot be empty")
+	}
+
+	return s.shardFor(job.Id).AddJob(job)
+}
+
+// CancelByKey cancels the jobs with the idempotency key on every shard, returning how many were
+// cancelled along with the errors of shards that failed
+func (s *ShardedMongoStore[T]) CancelByKey(key string) (int, error) {
+	cancelled := 0
+	var errs []error
+	for i, shard := range s.shards {
+		n, err := shard.CancelByKey(key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("shard %d: %w", i, err))
+		}
+		cancelled += n
+	}
+
+	return cancelled, errors.Join(errs...)
+}
+
+// ShiftSchedule shifts the jobs due within [from, to) on every shard, returning how many were
+// shifted along with the errors of shards that failed
+func (s *ShardedMongoStore[T]) ShiftSchedule(from, to time.Time, by time.Duration) (int, error) {
+	shifted := 0
+	var errs []error
+	for i, shard := range s.shards {
+		n, err := shard.ShiftSchedule(from, to, by)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("shard %d: %w", i, err))
+		}
+		shifted += n
+	}
+
+	return shifted, errors.Join(errs...)
+}
+
+// BatchAddJobs adds each job to its shard, returning the errors of jobs that couldn't be added
+func (s *ShardedMongoStore[T]) BatchAddJobs(jobs []*scheduler.Job[T]) error {
+	var errs []error
+	for _, job := range jobs {
+		if err := s.AddJob(job); err != nil {
+			errs = append(errs, fmt.Errorf("job %s: %w", job.Id, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// EnsureIndexes creates the collection and indexes on every shard
+func (s *ShardedMongoStore[T]) EnsureIndexes(ctx context.Context) error {
+	for i, shard := range s.shards {
+		if err := shard.EnsureIndexes(ctx); err != nil {
+			return fmt.Errorf("shard %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
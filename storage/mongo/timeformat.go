@@ -0,0 +1,80 @@
+package mongo
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// TimeFormat decides how the processAfter and visibleAfter fields are stored
+type TimeFormat int
+
+const (
+	// TimeFormatNative stores times as BSON dates
+	TimeFormatNative TimeFormat = iota
+	// TimeFormatEpochMillis stores times as int64 milliseconds since the Unix epoch, for consumers
+	// of the raw documents that expect integers
+	TimeFormatEpochMillis
+)
+
+// timeValue returns t in the representation the store writes and queries with
+func (f TimeFormat) timeValue(t time.Time) any {
+	if f == TimeFormatEpochMillis {
+		return t.UnixMilli()
+	}
+	return t
+}
+
+// timePtrValue is timeValue for optional times, nil stays nil
+func (f TimeFormat) timePtrValue(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return f.timeValue(*t)
+}
+
+// storedTime is a job time field in the store's time format, either format is accepted on read
+// so documents written before a format change still decode
+type storedTime struct {
+	time   time.Time
+	format TimeFormat
+}
+
+func newStoredTime(t time.Time, format TimeFormat) storedTime {
+	return storedTime{time: t, format: format}
+}
+
+func newStoredTimePtr(t *time.Time, format TimeFormat) *storedTime {
+	if t == nil {
+		return nil
+	}
+	stored := newStoredTime(*t, format)
+	return &stored
+}
+
+// timePtr returns the time of an optional stored time
+func (t *storedTime) timePtr() *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.time
+}
+
+func (t storedTime) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(t.format.timeValue(t.time))
+}
+
+func (t *storedTime) UnmarshalBSONValue(typ bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: typ, Value: data}
+	switch typ {
+	case bsontype.DateTime:
+		t.time, t.format = raw.Time(), TimeFormatNative
+	case bsontype.Int64:
+		t.time, t.format = time.UnixMilli(raw.Int64()), TimeFormatEpochMillis
+	default:
+		return fmt.Errorf("unsupported BSON type %s for a job time", typ)
+	}
+	return nil
+}
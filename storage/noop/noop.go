@@ -0,0 +1,73 @@
+package noop
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	scheduler "go-sched"
+)
+
+// NoopStore is a JobStore that generates pending jobs on demand and discards all writes
+// It has no backend cost, so it isolates the scheduler's own fetch and dispatch overhead for benchmarks
+type NoopStore[T any] struct {
+	payload   T
+	fetched   atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+}
+
+// NewNoopStore creates a store whose generated jobs all carry payload
+func NewNoopStore[T any](payload T) *NoopStore[T] {
+	return &NoopStore[T]{payload: payload}
+}
+
+// FetchPendingJobs returns limit freshly generated jobs that are due immediately
+func (s *NoopStore[T]) FetchPendingJobs(after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	now := time.Now()
+	first := s.fetched.Add(int64(limit)) - int64(limit)
+
+	jobs := make([]*scheduler.Job[T], limit)
+	for i := range jobs {
+		jobs[i] = &scheduler.Job[T]{
+			Id:           strconv.FormatInt(first+int64(i), 10),
+			Status:       "pending",
+			CreatedAt:    now,
+			ProcessAfter: now,
+			Payload:      s.payload,
+		}
+	}
+
+	return jobs, nil
+}
+
+// UpdateJob discards the update, only counting jobs that reached a final status
+func (s *NoopStore[T]) UpdateJob(job *scheduler.Job[T]) error {
+	switch job.Status {
+	case "completed":
+		s.completed.Add(1)
+	case "failed", "expired":
+		s.failed.Add(1)
+	}
+	return nil
+}
+
+// AddJob discards the job
+func (s *NoopStore[T]) AddJob(job *scheduler.Job[T]) error {
+	return nil
+}
+
+// Fetched returns the number of jobs generated so far
+func (s *NoopStore[T]) Fetched() int64 {
+	return s.fetched.Load()
+}
+
+// Completed returns the number of jobs the scheduler marked completed
+func (s *NoopStore[T]) Completed() int64 {
+	return s.completed.Load()
+}
+
+// Failed returns the number of jobs the scheduler marked failed
+func (s *NoopStore[T]) Failed() int64 {
+	return s.failed.Load()
+}
@@ -0,0 +1,95 @@
+package readonly
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	scheduler "go-sched"
+)
+
+// ErrReadOnly is returned for every write made through a ReadOnlyStore
+var ErrReadOnly = errors.New("store is read-only")
+
+// ReadOnlyStore wraps a JobStore for admin tools and dashboards: reads pass through to the inner
+// store while writes fail with ErrReadOnly. Optional read capabilities the inner store lacks
+// return an error wrapping scheduler.ErrNotSupported.
+type ReadOnlyStore[T any] struct {
+	inner scheduler.JobStore[T]
+}
+
+// NewReadOnly creates a read-only view of inner
+func NewReadOnly[T any](inner scheduler.JobStore[T]) *ReadOnlyStore[T] {
+	return &ReadOnlyStore[T]{inner: inner}
+}
+
+// FetchPendingJobs lists the inner store's due pending jobs. visibilityTimeout is ignored and the
+// inner store is always asked for none, as stores lock the jobs they return for a positive timeout
+// and would hide them from workers.
+func (s *ReadOnlyStore[T]) FetchPendingJobs(after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[T], error) {
+	return s.inner.FetchPendingJobs(after, limit, 0)
+}
+
+// UpdateJob always fails with ErrReadOnly
+func (s *ReadOnlyStore[T]) UpdateJob(job *scheduler.Job[T]) error {
+	return &scheduler.SchedulerError{Op: "update job", JobID: job.Id, Cause: ErrReadOnly}
+}
+
+// AddJob always fails with ErrReadOnly
+func (s *ReadOnlyStore[T]) AddJob(job *scheduler.Job[T]) error {
+	return &scheduler.SchedulerError{Op: "add job", JobID: job.Id, Cause: ErrReadOnly}
+}
+
+// DeleteJob always fails with ErrReadOnly
+func (s *ReadOnlyStore[T]) DeleteJob(id string) error {
+	return &scheduler.SchedulerError{Op: "delete job", JobID: id, Cause: ErrReadOnly}
+}
+
+// IterateJobs streams the inner store's jobs
+func (s *ReadOnlyStore[T]) IterateJobs(ctx context.Context, fn func(*scheduler.Job[T]) bool) error {
+	iterator, ok := s.inner.(scheduler.JobIterator[T])
+	if !ok {
+		return notSupported("iterate jobs")
+	}
+	return iterator.IterateJobs(ctx, fn)
+}
+
+// FetchCompleted lists the inner store's completed jobs within [from, to)
+func (s *ReadOnlyStore[T]) FetchCompleted(from, to time.Time, limit int) ([]*scheduler.Job[T], error) {
+	fetcher, ok := s.inner.(scheduler.CompletedFetcher[T])
+	if !ok {
+		return nil, notSupported("fetch completed jobs")
+	}
+	return fetcher.FetchCompleted(from, to, limit)
+}
+
+// ExplainFetch reports why the inner store isn't fetching a job
+func (s *ReadOnlyStore[T]) ExplainFetch(id string) (scheduler.FetchExplanation, error) {
+	explainer, ok := s.inner.(scheduler.FetchExplainer)
+	if !ok {
+		return scheduler.FetchExplanation{}, notSupported("explain fetch")
+	}
+	return explainer.ExplainFetch(id)
+}
+
+// CountInvisible counts the inner store's jobs hidden by a visibility timeout
+func (s *ReadOnlyStore[T]) CountInvisible() (int, error) {
+	counter, ok := s.inner.(scheduler.InvisibleCounter)
+	if !ok {
+		return 0, notSupported("count invisible jobs")
+	}
+	return counter.CountInvisible()
+}
+
+// CountFailedByReason counts the inner store's failed jobs by terminal reason
+func (s *ReadOnlyStore[T]) CountFailedByReason() (map[scheduler.TerminalReason]int, error) {
+	counter, ok := s.inner.(scheduler.FailureCounter)
+	if !ok {
+		return nil, notSupported("count failed jobs")
+	}
+	return counter.CountFailedByReason()
+}
+
+func notSupported(op string) error {
+	return &scheduler.SchedulerError{Op: op, Cause: scheduler.ErrNotSupported}
+}
@@ -0,0 +1,156 @@
+package readonly
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+	"go-sched/storage/noop"
+)
+
+func TestReadOnlyStoreBlocksWrites(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(s *ReadOnlyStore[string]) error
+	}{
+		{name: "add", write: func(s *ReadOnlyStore[string]) error {
+			return s.AddJob(&scheduler.Job[string]{Id: "new", Status: "pending", ProcessAfter: time.Now()})
+		}},
+		{name: "update", write: func(s *ReadOnlyStore[string]) error {
+			return s.UpdateJob(&scheduler.Job[string]{Id: "job-1", Status: "completed"})
+		}},
+		{name: "delete", write: func(s *ReadOnlyStore[string]) error {
+			return s.DeleteJob("job-1")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := storage.NewMemoryStore[string]()
+			if err := inner.AddJob(&scheduler.Job[string]{Id: "job-1", Status: "pending", ProcessAfter: time.Now()}); err != nil {
+				t.Fatal(err)
+			}
+
+			err := tt.write(NewReadOnly[string](inner))
+			var schedErr *scheduler.SchedulerError
+			if !errors.Is(err, ErrReadOnly) || !errors.As(err, &schedErr) {
+				t.Fatalf("%s error = %v, want a SchedulerError wrapping ErrReadOnly", tt.name, err)
+			}
+
+			jobs := inner.GetJobs()
+			if job, ok := jobs["job-1"]; len(jobs) != 1 || !ok || job.Status != "pending" {
+				t.Fatalf("inner store changed by a blocked %s: %v", tt.name, jobs)
+			}
+		})
+	}
+}
+
+func TestReadOnlyStorePassesReads(t *testing.T) {
+	now := time.Now()
+	completedAt := now.Add(-time.Minute)
+	hiddenUntil := now.Add(time.Hour)
+	inner := storage.NewMemoryStore[string]()
+	jobs := []*scheduler.Job[string]{
+		{Id: "due", Status: "pending", ProcessAfter: now.Add(-time.Second)},
+		{Id: "hidden", Status: "pending", ProcessAfter: now.Add(-time.Second), VisibleAfter: &hiddenUntil},
+		{Id: "done", Status: "completed", ProcessAfter: now.Add(-time.Hour), ProcessedAt: &completedAt},
+	}
+	for _, job := range jobs {
+		if err := inner.AddJob(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewReadOnly[string](inner)
+
+	tests := []struct {
+		name string
+		read func() (int, error) // Returns the number of jobs read
+		want int
+	}{
+		{name: "fetch pending", read: func() (int, error) {
+			fetched, err := s.FetchPendingJobs(now, 10, 0)
+			return len(fetched), err
+		}, want: 1},
+		{name: "iterate", read: func() (int, error) {
+			n := 0
+			err := s.IterateJobs(context.Background(), func(*scheduler.Job[string]) bool {
+				n++
+				return true
+			})
+			return n, err
+		}, want: 3},
+		{name: "fetch completed", read: func() (int, error) {
+			completed, err := s.FetchCompleted(now.Add(-time.Hour), now, 0)
+			return len(completed), err
+		}, want: 1},
+		{name: "count invisible", read: s.CountInvisible, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := tt.read(); err != nil || got != tt.want {
+				t.Fatalf("%s read %d jobs, %v, want %d", tt.name, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadOnlyStoreNotSupported(t *testing.T) {
+	s := NewReadOnly[string](noop.NewNoopStore(""))
+
+	if _, err := s.CountInvisible(); !errors.Is(err, scheduler.ErrNotSupported) {
+		t.Fatalf("CountInvisible() error = %v, want ErrNotSupported", err)
+	}
+	if _, err := s.FetchCompleted(time.Now().Add(-time.Hour), time.Now(), 0); !errors.Is(err, scheduler.ErrNotSupported) {
+		t.Fatalf("FetchCompleted() error = %v, want ErrNotSupported", err)
+	}
+}
+
+// lockingStore hides the jobs it fetches for the visibility timeout like the Mongo store does
+type lockingStore struct {
+	*storage.MemoryStore[string]
+}
+
+func (s *lockingStore) FetchPendingJobs(after time.Time, limit int, visibilityTimeout time.Duration) ([]*scheduler.Job[string], error) {
+	jobs, err := s.MemoryStore.FetchPendingJobs(after, limit, visibilityTimeout)
+	if err != nil || visibilityTimeout <= 0 {
+		return jobs, err
+	}
+	for _, job := range jobs {
+		job.MakeInvisible(visibilityTimeout)
+		if err := s.MemoryStore.UpdateJob(job); err != nil {
+			return nil, err
+		}
+	}
+	return jobs, nil
+}
+
+func TestReadOnlyStoreFetchLeavesJobsVisible(t *testing.T) {
+	tests := []struct {
+		name              string
+		visibilityTimeout time.Duration
+	}{
+		{name: "no timeout"},
+		{name: "positive timeout", visibilityTimeout: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &lockingStore{MemoryStore: storage.NewMemoryStore[string]()}
+			if err := inner.AddJob(&scheduler.Job[string]{Id: "job-1", Status: "pending", ProcessAfter: time.Now().Add(-time.Second)}); err != nil {
+				t.Fatal(err)
+			}
+
+			fetched, err := NewReadOnly[string](inner).FetchPendingJobs(time.Now(), 10, tt.visibilityTimeout)
+			if err != nil || len(fetched) != 1 {
+				t.Fatalf("FetchPendingJobs() = %d jobs, %v, want 1", len(fetched), err)
+			}
+			if !inner.GetJobs()["job-1"].IsVisible() {
+				t.Fatal("job hidden from workers by a read-only fetch")
+			}
+		})
+	}
+}
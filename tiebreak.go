@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// TieBreak orders pending jobs that are equally due and of equal priority
+type TieBreak int
+
+const (
+	// TieBreakFIFO fetches the job added first, by the sequence the store assigns on insert
+	TieBreakFIFO TieBreak = iota
+	// TieBreakLIFO fetches the job added last
+	TieBreakLIFO
+	// TieBreakRandom orders jobs by a hash of their id, spreading them regardless of when they
+	// were added, e.g. across the shards they are written to
+	TieBreakRandom
+)
+
+func (tb TieBreak) String() string {
+	switch tb {
+	case TieBreakLIFO:
+		return "lifo"
+	case TieBreakRandom:
+		return "random"
+	default:
+		return "fifo"
+	}
+}
+
+// TieKey returns the hash of a job id that TieBreakRandom orders jobs by, stores persist it
+// next to the job to sort on it
+func TieKey(id string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return int64(h.Sum64())
+}
+
+// TieBreakLess reports whether tb fetches a before b, two jobs equally due and of equal priority
+func TieBreakLess[T any](tb TieBreak, a, b *Job[T]) bool {
+	switch tb {
+	case TieBreakLIFO:
+		return a.Sequence > b.Sequence
+	case TieBreakRandom:
+		return TieKey(a.Id) < TieKey(b.Id)
+	default:
+		return a.Sequence < b.Sequence
+	}
+}
+
+// applyTieBreak sets the configured tie break on the store, which must implement TieBreaker
+// unless the tie break is the default FIFO
+func (s *Scheduler[T]) applyTieBreak() error {
+	if s.tieBreak == TieBreakFIFO {
+		return nil
+	}
+
+	breaker, ok := s.store.(TieBreaker)
+	if !ok {
+		return &SchedulerError{Op: "tie break", Cause: fmt.Errorf("%s: %w", s.tieBreak, ErrNotSupported)}
+	}
+
+	breaker.SetTieBreak(s.tieBreak)
+	return nil
+}
@@ -0,0 +1,204 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// typePool is a fixed set of workers dedicated to one job type, fed by its own fetch loop so a
+// burst of jobs of another type can't take its workers
+type typePool struct {
+	jobType   string
+	workers   int
+	busy      atomic.Int64 // Jobs being processed by the pool's workers
+	lastFetch atomic.Int64 // Unix nanoseconds of the last fetch that returned jobs
+}
+
+// sharedTypeFilter returns the filter keeping jobs of pooled types away from the shared workers,
+// nil when no pools are configured
+func (s *Scheduler[T]) sharedTypeFilter() *TypeFilter {
+	if len(s.typePools) == 0 {
+		return nil
+	}
+
+	filter := &TypeFilter{Exclude: true}
+	for _, p := range s.typePools {
+		filter.Types = append(filter.Types, p.jobType)
+	}
+	return filter
+}
+
+// pooledType reports whether jobs of jobType are handled by a per-type pool rather than the shared workers
+func (s *Scheduler[T]) pooledType(jobType string) bool {
+	for _, p := range s.typePools {
+		if p.jobType == jobType {
+			return true
+		}
+	}
+	return false
+}
+
+// sharedActiveJobs returns the number of jobs being processed by the shared workers
+func (s *Scheduler[T]) sharedActiveJobs() int {
+	active := int(s.activeJobs.Load())
+	for _, p := range s.typePools {
+		active -= int(p.busy.Load())
+	}
+	return max(active, 0)
+}
+
+// typePoolsFetchedWithin reports whether any per-type pool fetched jobs within d
+func (s *Scheduler[T]) typePoolsFetchedWithin(d time.Duration) bool {
+	for _, p := range s.typePools {
+		if time.Since(time.Unix(0, p.lastFetch.Load())) < d {
+			return true
+		}
+	}
+	return false
+}
+
+// startTypePools starts each per-type pool in the group, the store must support fetching by type
+func (s *Scheduler[T]) startTypePools(ctx context.Context, leader *leaderLock, pools *errgroup.Group) error {
+	if len(s.typePools) == 0 {
+		return nil
+	}
+	if _, ok := s.store.(TypeFetcher[T]); !ok {
+		return &SchedulerError{Op: "worker pools", Cause: ErrNotSupported}
+	}
+
+	// Pool worker ids follow the shared workers'
+	firstID := s.workerCount
+	if s.pool != nil {
+		firstID = max(firstID, s.pool.max)
+	}
+
+	for _, p := range s.typePools {
+		p.lastFetch.Store(time.Now().UnixNano())
+		poolFirstID := firstID
+		pools.Go(func() error {
+			return s.runTypePool(ctx, p, leader, poolFirstID)
+		})
+		firstID += p.workers
+	}
+
+	return nil
+}
+
+// runTypePool fetches jobs of the pool's type for as many workers as are free and dispatches them
+// to the pool's workers until ctx is done, returning the errors of updates that failed during shutdown
+func (s *Scheduler[T]) runTypePool(ctx context.Context, p *typePool, leader *leaderLock, firstID int) error {
+	jobs := make(chan *Job[T], p.workers)
+	filter := &TypeFilter{Types: []string{p.jobType}}
+
+	var workers errgroup.Group
+	for i := range p.workers {
+		workers.Go(func() error {
+			return s.typePoolWorker(ctx, p, firstID+i, jobs)
+		})
+	}
+
+	var errs []error
+	errorDelay := s.errorInterval
+	for ctx.Err() == nil {
+		// Only the instance holding the leader lock fetches, and not while results wait to be saved
+		if (leader != nil && !leader.isLeader()) || s.completionsBehind() || s.TypePaused(p.jobType) {
+			sleepContext(ctx, s.pollInterval())
+			continue
+		}
+
+		availableSlots := p.workers - int(p.busy.Load()) - len(jobs)
+		if availableSlots <= 0 {
+			sleepContext(ctx, s.pollInterval())
+			continue
+		}
+
+		entries, err := s.fetchJobs(ctx, availableSlots, filter)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			err = &SchedulerError{Op: "fetch jobs", Cause: err}
+			s.log.Error("failed to fetch pending entries for worker pool", "job-type", p.jobType, "error", err, "retry-in", errorDelay)
+			sleepContext(ctx, errorDelay)
+			errorDelay = min(errorDelay*2, s.maxErrorInterval)
+			continue
+		}
+		errorDelay = s.errorInterval
+
+		if len(entries) == 0 {
+			sleepContext(ctx, s.pollInterval())
+			continue
+		}
+		p.lastFetch.Store(time.Now().UnixNano())
+
+	dispatch:
+		for i, entry := range entries {
+			if ctx.Err() != nil {
+				errs = append(errs, s.releaseUndispatched(ctx, entries[i:]))
+				break
+			}
+
+			if s.jobExpired(entry, time.Now()) {
+				s.expireJob(ctx, entry)
+				continue
+			}
+
+			if s.dispatchFilter != nil && !s.dispatchFilter(entry) {
+				s.log.Debug("job rejected by dispatch filter, making it visible", "job-id", entry.Id)
+				s.releaseJob(ctx, entry)
+				continue
+			}
+
+			if err := s.claimJob(ctx, entry); errors.Is(err, ErrConcurrentModification) {
+				s.log.Debug("job changed since it was fetched, skipping it", "job-id", entry.Id, "job-type", p.jobType)
+				continue
+			}
+
+			s.log.Debug("dispatching job to worker pool", "event", EventJobDispatched, "job-id", entry.Id, "job-type", p.jobType)
+			select {
+			case jobs <- entry:
+			case <-ctx.Done():
+				errs = append(errs, s.releaseUndispatched(ctx, entries[i:]))
+				break dispatch
+			}
+		}
+	}
+
+	close(jobs)
+	for remainingJob := range jobs {
+		if err := s.releaseJob(ctx, remainingJob); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := workers.Wait(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// typePoolWorker processes the pool's jobs until the channel is closed, returning the errors of
+// saving results that failed once shutdown began
+func (s *Scheduler[T]) typePoolWorker(ctx context.Context, p *typePool, workerId int, jobs chan *Job[T]) error {
+	if !s.startWorker(ctx, workerId) {
+		return nil
+	}
+	defer s.stopWorker(workerId)
+
+	var errs []error
+	for job := range jobs {
+		p.busy.Add(1)
+		err := s.processJob(ctx, job, workerId)
+		p.busy.Add(-1)
+		if err != nil && ctx.Err() != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	s.log.Debug("worker pool worker finished", "worker-id", workerId, "job-type", p.jobType)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,148 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestTypePools(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []scheduler.Option[string]
+		jobs     map[string]int // Jobs submitted per type, "" for untyped jobs
+		wantBusy map[string]int // Jobs of each type running at once
+	}{
+		{
+			name:     "pools run next to the shared workers",
+			opts:     []scheduler.Option[string]{scheduler.WithPool[string]("email", 3), scheduler.WithPool[string]("report", 1)},
+			jobs:     map[string]int{"email": 10, "report": 5, "": 5},
+			wantBusy: map[string]int{"email": 3, "report": 1, "": 2},
+		},
+		{
+			name:     "a backlog of one type doesn't take another type's workers",
+			opts:     []scheduler.Option[string]{scheduler.WithPool[string]("report", 2)},
+			jobs:     map[string]int{"report": 20, "email": 20},
+			wantBusy: map[string]int{"report": 2, "email": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Jobs block until released, so every worker stays busy with its first job
+			var mu sync.Mutex
+			busy := make(map[string]int)
+			release := make(chan struct{})
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				mu.Lock()
+				busy[job.Type]++
+				mu.Unlock()
+				<-release
+				return nil
+			}
+
+			store := storage.NewMemoryStore[string]()
+			s := scheduler.NewScheduler(store, 2, 10*time.Millisecond, time.Minute, handler, discardLogger(), tt.opts...)
+			var ids []string
+			for jobType, n := range tt.jobs {
+				for range n {
+					job := s.NewJob(time.Now(), jobType, scheduler.WithJobType[string](jobType))
+					if err := s.SubmitJob(job); err != nil {
+						t.Fatal(err)
+					}
+					ids = append(ids, job.Id)
+				}
+			}
+			s.Run(ctx)
+
+			// Give every pool time to fill up, then make sure none went past its worker count
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				mu.Lock()
+				got := maps.Clone(busy)
+				mu.Unlock()
+				if maps.Equal(got, tt.wantBusy) {
+					time.Sleep(100 * time.Millisecond)
+					mu.Lock()
+					got = maps.Clone(busy)
+					mu.Unlock()
+					if !maps.Equal(got, tt.wantBusy) {
+						t.Fatalf("jobs running per type = %v, want %v", got, tt.wantBusy)
+					}
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("jobs running per type = %v, want %v", got, tt.wantBusy)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			close(release)
+			for _, id := range ids {
+				waitForStatus(t, store, id, "completed", 5*time.Second)
+			}
+		})
+	}
+}
+
+func TestTypePoolsBypassCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name       string
+		failType   string // Type of the job failing first, "" for an untyped job
+		wantShared string // Status the shared workers' jobs reach afterwards
+	}{
+		{name: "pooled failure leaves the circuit closed", failType: "report", wantShared: "completed"},
+		{name: "shared failure opens the circuit", failType: "", wantShared: "pending"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				if job.Payload == "fail" {
+					return errors.New("downstream unavailable")
+				}
+				return nil
+			}
+
+			store := storage.NewMemoryStore[string]()
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger(),
+				scheduler.WithPool[string]("report", 1),
+				scheduler.WithCircuitBreaker[string](1, time.Hour),
+			)
+			failing := s.NewJob(time.Now(), "fail", scheduler.WithJobType[string](tt.failType))
+			if err := s.SubmitJob(failing); err != nil {
+				t.Fatal(err)
+			}
+			s.Run(ctx)
+			waitForStatus(t, store, failing.Id, "failed", 5*time.Second)
+
+			var ids []string
+			for range 3 {
+				job := s.NewJob(time.Now(), "email")
+				if err := s.SubmitJob(job); err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, job.Id)
+			}
+			if tt.wantShared == "pending" {
+				// Give an open circuit the chance to wrongly dispatch
+				time.Sleep(200 * time.Millisecond)
+			}
+			for _, id := range ids {
+				waitForStatus(t, store, id, tt.wantShared, 5*time.Second)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+)
+
+const (
+	webhookMaxTries       = 5
+	webhookMaxElapsedTime = time.Minute
+)
+
+// WebhookResult is the body POSTed to a job's CallbackURL once the job completes or fails
+type WebhookResult[T any] struct {
+	JobId       string         `json:"jobId"`
+	Status      string         `json:"status"`
+	ProcessedAt *time.Time     `json:"processedAt,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Reason      TerminalReason `json:"reason,omitempty"` // Set for failed jobs
+	Payload     T              `json:"payload"`
+}
+
+// sendCallback delivers the result of a completed or failed job to its callback URL in the
+// background, jobs still pending for a retry or another run aren't reported. Delivery failures
+// are logged and never affect the job itself.
+func (s *Scheduler[T]) sendCallback(ctx context.Context, job Job[T], handlerErr error) {
+	if job.CallbackURL == "" || (job.Status != "completed" && job.Status != "failed") {
+		return
+	}
+
+	result := WebhookResult[T]{
+		JobId:       job.Id,
+		Status:      job.Status,
+		ProcessedAt: job.ProcessedAt,
+		Reason:      job.TerminalReason,
+		Payload:     job.Payload,
+	}
+	if handlerErr != nil {
+		result.Error = handlerErr.Error()
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		s.log.Error("failed to marshal job callback", "job-id", job.Id, "error", err)
+		return
+	}
+
+	// Deliveries outlive the scheduler context so results are not dropped on shutdown
+	ctx = context.WithoutCancel(ctx)
+
+	s.callbacks.Add(1)
+	go func() {
+		defer s.callbacks.Done()
+
+		_, err := backoff.Retry(ctx, func() (any, error) {
+			return nil, s.postCallback(ctx, job.CallbackURL, body)
+		}, backoff.WithMaxTries(webhookMaxTries), backoff.WithMaxElapsedTime(webhookMaxElapsedTime), backoff.WithNotify(func(err error, d time.Duration) {
+			s.log.Error("failed to deliver job callback, retrying...", "job-id", job.Id, "error", err, "duration", d)
+		}))
+		if err != nil {
+			s.log.Error("failed to deliver job callback after retries", "job-id", job.Id, "error", err)
+			return
+		}
+
+		s.log.Debug("delivered job callback", "job-id", job.Id)
+	}()
+}
+
+func (s *Scheduler[T]) postCallback(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	err = fmt.Errorf("callback returned status %d", resp.StatusCode)
+	// Client errors won't succeed on retry, except for rate limiting
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return backoff.Permanent(err)
+	}
+
+	return err
+}
@@ -0,0 +1,92 @@
+package scheduler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	scheduler "go-sched"
+	"go-sched/storage"
+)
+
+func TestJobCallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		handlerErr  error
+		opts        []scheduler.Option[string]
+		failSave    bool   // Fail the update saving the job result
+		wantStatus  string // Empty if no callback is expected
+		wantErrText string
+	}{
+		{name: "completed job", wantStatus: "completed"},
+		{name: "failed job", handlerErr: errors.New("smtp down"), wantStatus: "failed", wantErrText: "smtp down"},
+		{
+			name:       "job pending a retry isn't reported",
+			handlerErr: errors.New("smtp down"),
+			opts:       []scheduler.Option[string]{scheduler.WithRetry[string](3, func(int) time.Duration { return time.Hour })},
+		},
+		{name: "unsaved result isn't reported", failSave: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			results := make(chan scheduler.WebhookResult[string], 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var result scheduler.WebhookResult[string]
+				if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+					t.Errorf("failed to decode callback: %v", err)
+				}
+				results <- result
+			}))
+			defer server.Close()
+
+			store := &hookStore[string]{MemoryStore: storage.NewMemoryStore[string]()}
+			if tt.failSave {
+				store.onUpdate = func(job *scheduler.Job[string]) error {
+					if job.Status != "pending" {
+						return errors.New("store unavailable")
+					}
+					return nil
+				}
+			}
+
+			handler := func(ctx context.Context, job scheduler.Job[string]) error {
+				return tt.handlerErr
+			}
+			s := scheduler.NewScheduler(store, 1, 10*time.Millisecond, time.Minute, handler, discardLogger(), tt.opts...)
+			job := s.NewJob(time.Now(), "welcome email", scheduler.WithJobCallbackURL[string](server.URL))
+			if err := s.SubmitJob(job); err != nil {
+				t.Fatal(err)
+			}
+			s.Run(ctx)
+
+			if tt.wantStatus == "" {
+				select {
+				case result := <-results:
+					t.Fatalf("unexpected callback %+v", result)
+				case <-time.After(500 * time.Millisecond):
+				}
+				return
+			}
+
+			select {
+			case result := <-results:
+				if result.JobId != job.Id || result.Status != tt.wantStatus || result.Error != tt.wantErrText || result.Payload != "welcome email" {
+					t.Fatalf("callback = %+v, want job %s with status %q and error %q", result, job.Id, tt.wantStatus, tt.wantErrText)
+				}
+				if (result.ProcessedAt != nil) != (tt.wantStatus == "completed") {
+					t.Fatalf("callback ProcessedAt = %v, want it set for completed jobs only", result.ProcessedAt)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("callback not received")
+			}
+		})
+	}
+}